@@ -0,0 +1,270 @@
+// Package audit reports the images of a library that are candidates for
+// cleanup: exact duplicates, images without paths, very large originals and
+// images that appear unused.
+package audit
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/internal/workpool"
+	"github.com/img-src-io/sdk-go/models/components"
+)
+
+const (
+	// DefaultLargeSize is used when Options.LargeSize is zero.
+	DefaultLargeSize = 20 << 20
+	// DefaultUnusedAge is used when Options.UnusedAge is zero.
+	DefaultUnusedAge = 90 * 24 * time.Hour
+)
+
+// Finding is a reason an image is reported.
+type Finding string
+
+const (
+	// FindingDuplicate marks images sharing their ImageMetadata.Hash with
+	// another image. The server deduplicates uploads, but copies appear
+	// across migrations.
+	FindingDuplicate Finding = "duplicate"
+	// FindingNoPaths marks images no path points to.
+	FindingNoPaths Finding = "no_paths"
+	// FindingLarge marks originals of at least Options.LargeSize bytes.
+	FindingLarge Finding = "large"
+	// FindingUnused marks images older than Options.UnusedAge without an
+	// active signed URL that Options.Used does not claim.
+	FindingUnused Finding = "unused"
+)
+
+// Options configures Run.
+type Options struct {
+	// Folder limits the audit to images under it. Defaults to the whole
+	// library.
+	Folder string
+	// LargeSize is the size from which originals are reported as large.
+	// Defaults to DefaultLargeSize; a negative value disables the finding.
+	LargeSize int64
+	// UnusedAge is how old an image must be to be reported as unused.
+	// Defaults to DefaultUnusedAge; a negative value disables the finding.
+	UnusedAge time.Duration
+	// Used, if set, reports whether an image is known to be in use, e.g.
+	// from CDN logs. The API records the active signed URL of images, but
+	// not which images presets were applied to.
+	Used func(img Image) bool
+	// Concurrency is the number of metadata requests in parallel. Defaults
+	// to 4.
+	Concurrency int
+	// OnImage, if set, is called after every image with its outcome. It may
+	// be called from several goroutines at once.
+	OnImage func(img Image, err error)
+}
+
+// Image is an audited image.
+type Image struct {
+	ID               string                `json:"id"`
+	Hash             string                `json:"hash"`
+	Paths            []string              `json:"paths"`
+	Visibility       components.Visibility `json:"visibility"`
+	Size             int64                 `json:"size"`
+	MimeType         string                `json:"mime_type"`
+	OriginalFilename string                `json:"original_filename"`
+	UploadedAt       time.Time             `json:"uploaded_at"`
+	// HasSignedURL reports whether the image has an active signed URL.
+	HasSignedURL bool      `json:"has_signed_url"`
+	Findings     []Finding `json:"findings"`
+	// DuplicateOf are the other images with the same hash.
+	DuplicateOf []string `json:"duplicate_of,omitempty"`
+}
+
+// ImageError is the failure of a single image.
+type ImageError struct {
+	ImageID string
+	Err     error
+}
+
+func (e ImageError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"image_id": e.ImageID, "error": e.Err.Error()})
+}
+
+// Report is the result of Run.
+type Report struct {
+	// Scanned is the number of images audited.
+	Scanned int `json:"scanned"`
+	// Images are the images with at least one finding, sorted by ID.
+	Images []Image `json:"images"`
+	// Counts is the number of images per finding.
+	Counts map[Finding]int `json:"counts"`
+	Failed []ImageError    `json:"failed,omitempty"`
+}
+
+// Err returns the errors of every failed image joined together, or nil.
+func (r *Report) Err() error {
+	if r == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, f := range r.Failed {
+		errs = append(errs, fmt.Errorf("image %s: %w", f.ImageID, f.Err))
+	}
+	return errors.Join(errs...)
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// csvHeader is the header row of WriteCSV.
+var csvHeader = []string{"finding", "image_id", "hash", "size", "mime_type", "visibility", "uploaded_at", "has_signed_url", "paths", "duplicate_of"}
+
+// WriteCSV writes one row per finding of every image, so that cleanup jobs
+// can filter on the first column. Lists are separated by spaces.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write(csvHeader)
+	for _, img := range r.Images {
+		for _, f := range img.Findings {
+			_ = cw.Write([]string{
+				string(f),
+				img.ID,
+				img.Hash,
+				strconv.FormatInt(img.Size, 10),
+				img.MimeType,
+				string(img.Visibility),
+				img.UploadedAt.UTC().Format(time.RFC3339),
+				strconv.FormatBool(img.HasSignedURL),
+				strings.Join(img.Paths, " "),
+				strings.Join(img.DuplicateOf, " "),
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Run lists the library once, fetches the metadata of every image in
+// parallel and reports the images with findings. Failures of individual
+// images are recorded in the report rather than aborting the run.
+func Run(ctx context.Context, client *sdkgo.Imgsrc, opts *Options) (*Report, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	largeSize := opts.LargeSize
+	if largeSize == 0 {
+		largeSize = DefaultLargeSize
+	}
+	unusedAge := opts.UnusedAge
+	if unusedAge == 0 {
+		unusedAge = DefaultUnusedAge
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	var items []components.ImageListItem
+	err := client.Images.Walk(ctx, opts.Folder, func(img components.ImageListItem) error {
+		items = append(items, img)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing images: %w", err)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+
+	images := make([]Image, len(items))
+	errs := make([]error, len(items))
+	workpool.ForEach(ctx, len(items), concurrency, func(ctx context.Context, i int) {
+		images[i], errs[i] = fetchImage(ctx, client, items[i])
+		if opts.OnImage != nil {
+			opts.OnImage(images[i], errs[i])
+		}
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	report := &Report{Images: []Image{}, Counts: map[Finding]int{}}
+	var audited []*Image
+	byHash := map[string][]string{}
+	for i, item := range items {
+		if errs[i] != nil {
+			report.Failed = append(report.Failed, ImageError{ImageID: item.ID, Err: errs[i]})
+			continue
+		}
+		audited = append(audited, &images[i])
+		if h := images[i].Hash; h != "" {
+			byHash[h] = append(byHash[h], item.ID)
+		}
+	}
+	report.Scanned = len(audited)
+
+	now := time.Now()
+	for _, img := range audited {
+		if ids := byHash[img.Hash]; len(ids) > 1 {
+			img.Findings = append(img.Findings, FindingDuplicate)
+			for _, id := range ids {
+				if id != img.ID {
+					img.DuplicateOf = append(img.DuplicateOf, id)
+				}
+			}
+		}
+		if len(img.Paths) == 0 {
+			img.Findings = append(img.Findings, FindingNoPaths)
+		}
+		if largeSize > 0 && img.Size >= largeSize {
+			img.Findings = append(img.Findings, FindingLarge)
+		}
+		if unusedAge > 0 && !img.HasSignedURL && now.Sub(img.UploadedAt) >= unusedAge && (opts.Used == nil || !opts.Used(*img)) {
+			img.Findings = append(img.Findings, FindingUnused)
+		}
+
+		for _, f := range img.Findings {
+			report.Counts[f]++
+		}
+		if len(img.Findings) > 0 {
+			report.Images = append(report.Images, *img)
+		}
+	}
+	return report, nil
+}
+
+func fetchImage(ctx context.Context, client *sdkgo.Imgsrc, item components.ImageListItem) (Image, error) {
+	img := Image{
+		ID:               item.ID,
+		Paths:            item.Paths,
+		Visibility:       item.Visibility,
+		Size:             item.Size,
+		OriginalFilename: item.OriginalFilename,
+		UploadedAt:       item.UploadedAt,
+		HasSignedURL:     item.ActiveSignedUrl != nil && time.Unix(item.ActiveSignedUrl.ExpiresAt, 0).After(time.Now()),
+		Findings:         []Finding{},
+	}
+	if img.Paths == nil {
+		img.Paths = []string{}
+	}
+
+	res, err := client.Images.GetMetadata(ctx, item.ID)
+	if err != nil {
+		return img, fmt.Errorf("error getting image: %w", err)
+	}
+	meta := res.GetMetadataResponse()
+	if meta == nil {
+		return img, errors.New("empty metadata response")
+	}
+	img.Hash = meta.Metadata.Hash
+	img.MimeType = meta.Metadata.MimeType
+	img.Size = meta.Metadata.Size
+	return img, nil
+}
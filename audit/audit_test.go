@@ -0,0 +1,125 @@
+package audit_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/img-src-io/sdk-go/audit"
+	"github.com/img-src-io/sdk-go/internal/fakeapi"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	client := f.SDK()
+
+	fresh := f.Add("fresh", "a/fresh.png")
+	original := f.Add("original", "a/original.png")
+	migrated := f.Add("migrated", "b/migrated.png")
+	hash := f.Image(original).Hash
+	f.Update(migrated, func(img *fakeapi.Image) { img.Hash = hash })
+	large := f.Add(strings.Repeat("x", 200), "a/large.png")
+
+	pathless := f.Add("pathless", "pathless.png")
+	f.Update(pathless, func(img *fakeapi.Image) { img.Paths = nil })
+	for _, id := range []string{fresh, original, migrated, large, pathless} {
+		f.Update(id, func(img *fakeapi.Image) { img.UploadedAt = time.Now() })
+	}
+
+	old := time.Now().Add(-200 * 24 * time.Hour)
+	stale := f.Add("stale", "old/stale.png")
+	signed := f.Add("signed", "old/signed.png")
+	claimed := f.Add("claimed", "old/claimed.png")
+	for _, id := range []string{stale, signed, claimed} {
+		f.Update(id, func(img *fakeapi.Image) { img.UploadedAt = old })
+	}
+	_, err := client.Images.CreateSignedURL(context.Background(), signed, &components.CreateSignedURLRequest{})
+	require.NoError(t, err)
+
+	broken := f.Add("broken", "c/broken.png")
+	f.Fail(func(r *http.Request) int {
+		if r.URL.Path == "/api/v1/images/"+broken {
+			return http.StatusNotFound
+		}
+		return 0
+	})
+
+	report, err := audit.Run(context.Background(), client, &audit.Options{
+		LargeSize: 100,
+		Used:      func(img audit.Image) bool { return img.ID == claimed },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 8, report.Scanned)
+	require.Len(t, report.Failed, 1)
+	assert.Equal(t, broken, report.Failed[0].ImageID)
+	assert.Error(t, report.Err())
+
+	findings := map[string][]audit.Finding{}
+	for _, img := range report.Images {
+		findings[img.ID] = img.Findings
+	}
+	assert.Equal(t, map[string][]audit.Finding{
+		original: {audit.FindingDuplicate},
+		migrated: {audit.FindingDuplicate},
+		large:    {audit.FindingLarge},
+		pathless: {audit.FindingNoPaths},
+		stale:    {audit.FindingUnused},
+	}, findings)
+	assert.NotContains(t, findings, fresh)
+	assert.Equal(t, map[audit.Finding]int{
+		audit.FindingDuplicate: 2,
+		audit.FindingLarge:     1,
+		audit.FindingNoPaths:   1,
+		audit.FindingUnused:    1,
+	}, report.Counts)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteCSV(&buf))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 6)
+	assert.Equal(t, "finding", rows[0][0])
+	for _, row := range rows[1:] {
+		if row[1] == migrated {
+			assert.Equal(t, []string{"duplicate", migrated, hash}, row[:3])
+			assert.Equal(t, "b/migrated.png", row[8])
+			assert.Equal(t, original, row[9])
+		}
+	}
+
+	buf.Reset()
+	require.NoError(t, report.WriteJSON(&buf))
+	var decoded struct {
+		Images []audit.Image
+		Failed []map[string]string
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, report.Images, decoded.Images)
+	assert.Equal(t, broken, decoded.Failed[0]["image_id"])
+}
+
+func TestRunDisabledFindings(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	id := f.Add(strings.Repeat("x", 100<<10), "big.png")
+	f.Update(id, func(img *fakeapi.Image) { img.UploadedAt = time.Now().Add(-365 * 24 * time.Hour) })
+
+	report, err := audit.Run(context.Background(), f.SDK(), &audit.Options{LargeSize: -1, UnusedAge: -1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Scanned)
+	assert.Empty(t, report.Images)
+
+	report, err = audit.Run(context.Background(), f.SDK(), &audit.Options{LargeSize: 1 << 10})
+	require.NoError(t, err)
+	require.Len(t, report.Images, 1)
+	assert.Equal(t, []audit.Finding{audit.FindingLarge, audit.FindingUnused}, report.Images[0].Findings)
+}
@@ -0,0 +1,262 @@
+package backup
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type archiveFormat int
+
+const (
+	formatDir archiveFormat = iota
+	formatTar
+	formatTarGz
+	formatZip
+)
+
+// formatOf infers the format of an export from its name.
+func formatOf(name string) archiveFormat {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return formatZip
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return formatTarGz
+	case strings.HasSuffix(lower, ".tar"):
+		return formatTar
+	default:
+		return formatDir
+	}
+}
+
+// pack writes the files under dir into the archive dest. The archive is
+// written next to dest and renamed into place once complete.
+func pack(dir, dest string, format archiveFormat) (err error) {
+	tmp := dest + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("error creating archive: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(tmp)
+		}
+	}()
+
+	switch format {
+	case formatZip:
+		err = packZip(dir, f)
+	case formatTarGz:
+		gz := gzip.NewWriter(f)
+		if err = packTar(dir, gz); err == nil {
+			err = gz.Close()
+		}
+	default:
+		err = packTar(dir, f)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing archive: %w", err)
+	}
+
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("error writing archive: %w", err)
+	}
+	return os.Rename(tmp, dest)
+}
+
+// walkFiles calls fn for every regular file under dir with its
+// slash-separated name relative to dir, skipping partial downloads.
+func walkFiles(dir string, fn func(name, full string, info fs.FileInfo) error) error {
+	return filepath.WalkDir(dir, func(full string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.HasSuffix(full, partSuffix) {
+			return err
+		}
+		rel, err := filepath.Rel(dir, full)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(filepath.ToSlash(rel), full, info)
+	})
+}
+
+func packTar(dir string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+	err := walkFiles(dir, func(name, full string, info fs.FileInfo) error {
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = name
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		return copyFile(tw, full)
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+func packZip(dir string, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	err := walkFiles(dir, func(name, full string, info fs.FileInfo) error {
+		hdr, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		hdr.Name = name
+		// Images are compressed already.
+		hdr.Method = zip.Store
+		if name == ManifestName {
+			hdr.Method = zip.Deflate
+		}
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		return copyFile(fw, full)
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func copyFile(w io.Writer, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// open returns a directory holding the export at src, extracting archives
+// into a temporary directory that cleanup removes.
+func open(src string) (dir string, cleanup func(), err error) {
+	format := formatOf(src)
+	if format == formatDir {
+		return src, func() {}, nil
+	}
+
+	dir, err = os.MkdirTemp("", "imgsrc-backup-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+
+	if format == formatZip {
+		err = unpackZip(src, dir)
+	} else {
+		err = unpackTar(src, dir, format == formatTarGz)
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("error extracting archive: %w", err)
+	}
+	return dir, cleanup, nil
+}
+
+// extractPath maps an archive entry name to a path under dir, rejecting
+// names that would escape it.
+func extractPath(dir, name string) (string, error) {
+	clean := path.Clean("/" + name)
+	if clean == "/" || strings.Contains(name, `\`) {
+		return "", fmt.Errorf("invalid archive entry %q", name)
+	}
+	return filepath.Join(dir, filepath.FromSlash(clean[1:])), nil
+}
+
+func writeExtracted(dir, name string, r io.Reader) error {
+	dst, err := extractPath(dir, name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func unpackTar(src, dir string, gzipped bool) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if gzipped {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := writeExtracted(dir, hdr.Name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func unpackZip(src, dir string) error {
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	for _, zf := range zr.File {
+		if zf.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return err
+		}
+		err = writeExtracted(dir, zf.Name, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,308 @@
+package backup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/internal/workpool"
+	"github.com/img-src-io/sdk-go/models/components"
+)
+
+// ErrHashMismatch is recorded for images whose downloaded original does not
+// match ImageMetadata.Hash.
+var ErrHashMismatch = errors.New("SHA256 does not match the image hash")
+
+// partSuffix marks downloads in progress.
+const partSuffix = ".part"
+
+// ExportOptions configures Export.
+type ExportOptions struct {
+	// Folder limits the export to images under it. Defaults to the whole library.
+	Folder string
+	// Since makes the export incremental: only images uploaded after it are
+	// exported. Use Manifest.LatestUpload of the previous export. An
+	// incremental export into the directory of an earlier export adds to its
+	// manifest; an existing archive is not overwritten.
+	Since time.Time
+	// Concurrency is the number of images downloaded in parallel. Defaults to 4.
+	Concurrency int
+	// OnImage, if set, is called after every image with its outcome. It may
+	// be called from several goroutines at once.
+	OnImage func(img Image, err error)
+}
+
+// ImageError is the failure of a single image.
+type ImageError struct {
+	ImageID string
+	Err     error
+}
+
+// ExportReport summarizes an Export run.
+type ExportReport struct {
+	Dest     string
+	Manifest *Manifest
+	// Downloaded is the number of originals downloaded by this run.
+	Downloaded int
+	// Reused is the number of originals already present from an interrupted run.
+	Reused int
+	Failed []ImageError
+}
+
+// Err returns the errors of every failed image joined together, or nil.
+func (r *ExportReport) Err() error {
+	if r == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, f := range r.Failed {
+		errs = append(errs, fmt.Errorf("image %s: %w", f.ImageID, f.Err))
+	}
+	return errors.Join(errs...)
+}
+
+// Export writes the originals of the account's images together with a
+// manifest, the presets and the settings to dest.
+//
+// dest is a directory unless its name ends in .tar, .tar.gz, .tgz or .zip,
+// in which case the export is staged in dest+".partial" and packed into the
+// archive once every image succeeded. Running Export again with the same dest
+// resumes an interrupted export: originals already downloaded and matching
+// their hash are kept.
+func Export(ctx context.Context, client *sdkgo.Imgsrc, dest string, opts *ExportOptions) (*ExportReport, error) {
+	if opts == nil {
+		opts = &ExportOptions{}
+	}
+
+	format := formatOf(dest)
+	dir := dest
+	if format != formatDir {
+		dir = dest + ".partial"
+	}
+	var previous *Manifest
+	if !opts.Since.IsZero() {
+		if format != formatDir {
+			if _, err := os.Stat(dest); err == nil {
+				return nil, fmt.Errorf("%s already exists and an incremental export cannot be added to an archive", dest)
+			}
+		}
+		prev, err := readManifestFile(filepath.Join(dir, ManifestName))
+		switch {
+		case err == nil:
+			previous = prev
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "images"), 0o755); err != nil {
+		return nil, fmt.Errorf("error creating export directory: %w", err)
+	}
+
+	manifest := &Manifest{Version: ManifestVersion, CreatedAt: time.Now().UTC(), Presets: []components.Preset{}, Images: []Image{}}
+	if !opts.Since.IsZero() {
+		since := opts.Since.UTC()
+		manifest.Since = &since
+	}
+
+	settings, err := client.Settings.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting settings: %w", err)
+	}
+	if out := settings.GetSettingsResponse(); out != nil {
+		manifest.Settings = &out.Settings
+	}
+
+	presets, err := client.Presets.ListPresets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing presets: %w", err)
+	}
+	if out := presets.GetListPresetsResponse(); out != nil {
+		manifest.Presets = append(manifest.Presets, out.Presets...)
+	}
+
+	var items []components.ImageListItem
+	err = client.Images.Walk(ctx, opts.Folder, func(img components.ImageListItem) error {
+		if opts.Since.IsZero() || img.UploadedAt.After(opts.Since) {
+			items = append(items, img)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing images: %w", err)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+
+	report := &ExportReport{Dest: dest, Manifest: manifest}
+	images := make([]Image, len(items))
+	errs := make([]error, len(items))
+	reused := make([]bool, len(items))
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	workpool.ForEach(ctx, len(items), concurrency, func(ctx context.Context, i int) {
+		images[i], reused[i], errs[i] = exportImage(ctx, client, dir, items[i])
+		if opts.OnImage != nil {
+			opts.OnImage(images[i], errs[i])
+		}
+	})
+	if err := ctx.Err(); err != nil {
+		return report, err
+	}
+
+	for i, item := range items {
+		switch {
+		case errs[i] != nil:
+			report.Failed = append(report.Failed, ImageError{ImageID: item.ID, Err: errs[i]})
+		case reused[i]:
+			report.Reused++
+		default:
+			report.Downloaded++
+		}
+		if errs[i] == nil {
+			manifest.Images = append(manifest.Images, images[i])
+		}
+	}
+	if previous != nil {
+		manifest.merge(previous)
+	}
+
+	if err := writeManifestFile(filepath.Join(dir, ManifestName), manifest); err != nil {
+		return report, err
+	}
+	if err := report.Err(); err != nil {
+		return report, err
+	}
+
+	if format != formatDir {
+		if err := pack(dir, dest, format); err != nil {
+			return report, err
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return report, fmt.Errorf("error removing staging directory: %w", err)
+		}
+	}
+
+	return report, nil
+}
+
+// exportImage downloads one original into dir unless a verified copy is
+// already there.
+func exportImage(ctx context.Context, client *sdkgo.Imgsrc, dir string, item components.ImageListItem) (Image, bool, error) {
+	res, err := client.Images.GetMetadata(ctx, item.ID)
+	if err != nil {
+		return Image{ID: item.ID}, false, fmt.Errorf("error getting image: %w", err)
+	}
+	meta := res.GetMetadataResponse()
+	if meta == nil {
+		return Image{ID: item.ID}, false, errors.New("empty metadata response")
+	}
+
+	img := Image{
+		ID:               meta.ID,
+		Hash:             meta.Metadata.Hash,
+		Paths:            item.Paths,
+		Visibility:       meta.Visibility,
+		Size:             meta.Metadata.Size,
+		Width:            meta.Metadata.Width,
+		Height:           meta.Metadata.Height,
+		MimeType:         meta.Metadata.MimeType,
+		OriginalFilename: meta.Metadata.OriginalFilename,
+		UploadedAt:       meta.Metadata.UploadedAt,
+		File:             path.Join("images", fileName(meta.ID, meta.Metadata.OriginalFilename)),
+	}
+
+	target := filepath.Join(dir, filepath.FromSlash(img.File))
+	if ok, err := verifyFile(target, img.Hash); err == nil && ok {
+		return img, true, nil
+	}
+
+	body, err := client.Images.OpenOriginal(ctx, meta)
+	if err != nil {
+		return img, false, err
+	}
+	defer body.Close()
+
+	if err := download(body, target, img.Hash); err != nil {
+		return img, false, err
+	}
+	return img, false, nil
+}
+
+// download writes r to target through a partial file, verifying it against
+// hash before moving it into place.
+func download(r io.Reader, target, hash string) error {
+	part := target + partSuffix
+	f, err := os.Create(part)
+	if err != nil {
+		return fmt.Errorf("error creating file: %w", err)
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), r); err != nil {
+		f.Close()
+		os.Remove(part)
+		return fmt.Errorf("error downloading original: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(part)
+		return fmt.Errorf("error writing file: %w", err)
+	}
+
+	if got := hex.EncodeToString(h.Sum(nil)); hash != "" && !strings.EqualFold(got, hash) {
+		os.Remove(part)
+		return fmt.Errorf("%w: got %s, want %s", ErrHashMismatch, got, hash)
+	}
+
+	return os.Rename(part, target)
+}
+
+// verifyFile reports whether name exists and matches hash.
+func verifyFile(name, hash string) (bool, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return false, err
+	}
+	return hash == "" || strings.EqualFold(hex.EncodeToString(h.Sum(nil)), hash), nil
+}
+
+// fileName derives the name of an exported original from the image ID and
+// the extension of its original filename.
+func fileName(id, original string) string {
+	name := sanitize(id)
+	if ext := strings.ToLower(path.Ext(original)); ext != "" && sanitize(ext[1:]) == ext[1:] {
+		name += ext
+	}
+	return name
+}
+
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
@@ -0,0 +1,192 @@
+package backup_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/img-src-io/sdk-go/backup"
+	"github.com/img-src-io/sdk-go/internal/fakeapi"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newExportLibrary(t *testing.T) (*fakeapi.Server, []string) {
+	t.Helper()
+	f := fakeapi.New(t)
+	ids := []string{
+		f.Add("first", "blog/a.png", "home/a.png"),
+		f.Add("second", "blog/2024/b.jpg"),
+		f.Add("third", "c.webp"),
+	}
+	f.Update(ids[1], func(img *fakeapi.Image) { img.Visibility = "private" })
+	f.AddPreset("thumb", map[string]any{"w": 200})
+	return f, ids
+}
+
+func TestExport_Directory(t *testing.T) {
+	t.Parallel()
+	f, ids := newExportLibrary(t)
+	dest := t.TempDir()
+
+	report, err := backup.Export(context.Background(), f.SDK(), dest, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Downloaded)
+	assert.Zero(t, report.Reused)
+
+	m, err := backup.ReadManifest(dest)
+	require.NoError(t, err)
+	assert.Equal(t, backup.ManifestVersion, m.Version)
+	require.NotNil(t, m.Settings)
+	assert.Equal(t, "alice", m.Settings.Username)
+	require.Len(t, m.Presets, 1)
+	assert.Equal(t, "thumb", m.Presets[0].Name)
+
+	require.Len(t, m.Images, 3)
+	for i, img := range m.Images {
+		stored := f.Image(ids[i])
+		assert.Equal(t, stored.ID, img.ID)
+		assert.Equal(t, stored.Hash, img.Hash)
+		assert.Equal(t, stored.Paths, img.Paths)
+		assert.Equal(t, components.Visibility(stored.Visibility), img.Visibility)
+		assert.Equal(t, stored.UploadedAt, img.UploadedAt)
+
+		data, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(img.File)))
+		require.NoError(t, err)
+		assert.Equal(t, stored.Data, data)
+	}
+	assert.Equal(t, "images/img002.jpg", m.Images[1].File)
+	assert.Equal(t, 1, f.Count("POST /api/v1/images/{id}/signed-url"))
+}
+
+func TestExport_Resume(t *testing.T) {
+	t.Parallel()
+	f, ids := newExportLibrary(t)
+	dest := t.TempDir()
+
+	f.Fail(func(r *http.Request) int {
+		if r.URL.Path == "/cdn/"+ids[2] {
+			return http.StatusBadGateway
+		}
+		return 0
+	})
+	report, err := backup.Export(context.Background(), f.SDK(), dest, nil)
+	require.Error(t, err)
+	require.Len(t, report.Failed, 1)
+	assert.Equal(t, ids[2], report.Failed[0].ImageID)
+	assert.Equal(t, 2, report.Downloaded)
+
+	f.Fail(nil)
+	report, err = backup.Export(context.Background(), f.SDK(), dest, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Downloaded)
+	assert.Equal(t, 2, report.Reused)
+	assert.Len(t, report.Manifest.Images, 3)
+}
+
+func TestExport_HashMismatch(t *testing.T) {
+	t.Parallel()
+	f, ids := newExportLibrary(t)
+	f.Update(ids[0], func(img *fakeapi.Image) { img.Data = []byte("corrupted") })
+	dest := t.TempDir()
+
+	report, err := backup.Export(context.Background(), f.SDK(), dest, nil)
+	require.ErrorIs(t, err, backup.ErrHashMismatch)
+	require.Len(t, report.Failed, 1)
+	assert.Equal(t, ids[0], report.Failed[0].ImageID)
+
+	_, err = os.Stat(filepath.Join(dest, "images", "img001.png"))
+	assert.True(t, os.IsNotExist(err))
+	leftovers, err := filepath.Glob(filepath.Join(dest, "images", "*.part"))
+	require.NoError(t, err)
+	assert.Empty(t, leftovers)
+}
+
+func TestExport_Archives(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"backup.tar", "backup.tar.gz", "backup.zip"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			f, _ := newExportLibrary(t)
+			dest := filepath.Join(t.TempDir(), name)
+
+			_, err := backup.Export(context.Background(), f.SDK(), dest, &backup.ExportOptions{Concurrency: 1})
+			require.NoError(t, err)
+
+			_, err = os.Stat(dest + ".partial")
+			assert.True(t, os.IsNotExist(err))
+
+			m, err := backup.ReadManifest(dest)
+			require.NoError(t, err)
+			assert.Len(t, m.Images, 3)
+		})
+	}
+}
+
+func TestExport_Incremental(t *testing.T) {
+	t.Parallel()
+	f, _ := newExportLibrary(t)
+	root := t.TempDir()
+
+	full, err := backup.Export(context.Background(), f.SDK(), filepath.Join(root, "full"), nil)
+	require.NoError(t, err)
+
+	id := f.Add("fourth", "blog/d.png")
+	report, err := backup.Export(context.Background(), f.SDK(), filepath.Join(root, "incr"), &backup.ExportOptions{
+		Since: full.Manifest.LatestUpload(),
+	})
+	require.NoError(t, err)
+	require.Len(t, report.Manifest.Images, 1)
+	assert.Equal(t, id, report.Manifest.Images[0].ID)
+	require.NotNil(t, report.Manifest.Since)
+	assert.Equal(t, full.Manifest.LatestUpload(), *report.Manifest.Since)
+}
+
+func TestExport_IncrementalIntoSameDir(t *testing.T) {
+	t.Parallel()
+	f, ids := newExportLibrary(t)
+	dest := t.TempDir()
+
+	full, err := backup.Export(context.Background(), f.SDK(), dest, nil)
+	require.NoError(t, err)
+
+	id := f.Add("fourth", "blog/d.png")
+	report, err := backup.Export(context.Background(), f.SDK(), dest, &backup.ExportOptions{
+		Since: full.Manifest.LatestUpload(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Downloaded)
+
+	m, err := backup.ReadManifest(dest)
+	require.NoError(t, err)
+	var got []string
+	for _, img := range m.Images {
+		got = append(got, img.ID)
+	}
+	assert.ElementsMatch(t, append(ids, id), got)
+	// The manifest still describes the whole library.
+	assert.Nil(t, m.Since)
+
+	archive := filepath.Join(t.TempDir(), "backup.tar")
+	_, err = backup.Export(context.Background(), f.SDK(), archive, nil)
+	require.NoError(t, err)
+	_, err = backup.Export(context.Background(), f.SDK(), archive, &backup.ExportOptions{Since: full.Manifest.LatestUpload()})
+	assert.ErrorContains(t, err, "already exists")
+}
+
+func TestExport_Folder(t *testing.T) {
+	t.Parallel()
+	f, _ := newExportLibrary(t)
+
+	report, err := backup.Export(context.Background(), f.SDK(), t.TempDir(), &backup.ExportOptions{Folder: "blog"})
+	require.NoError(t, err)
+	require.Len(t, report.Manifest.Images, 2)
+	for _, img := range report.Manifest.Images {
+		assert.True(t, strings.HasPrefix(img.Paths[0], "blog/"))
+	}
+}
@@ -0,0 +1,125 @@
+// Package backup exports an img-src account to a local directory or archive
+// and restores it again.
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/img-src-io/sdk-go/models/components"
+)
+
+// ManifestName is the name of the manifest inside an export.
+const ManifestName = "manifest.json"
+
+// ManifestVersion is the version of the manifest format written by Export.
+const ManifestVersion = 1
+
+// Manifest describes the contents of an export.
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// Since is set for incremental exports, which only contain images
+	// uploaded after it.
+	Since    *time.Time               `json:"since,omitempty"`
+	Settings *components.UserSettings `json:"settings,omitempty"`
+	Presets  []components.Preset      `json:"presets"`
+	Images   []Image                  `json:"images"`
+}
+
+// Image is an exported image.
+type Image struct {
+	ID               string                `json:"id"`
+	Hash             string                `json:"hash"`
+	Paths            []string              `json:"paths"`
+	Visibility       components.Visibility `json:"visibility"`
+	Size             int64                 `json:"size"`
+	Width            *int64                `json:"width,omitempty"`
+	Height           *int64                `json:"height,omitempty"`
+	MimeType         string                `json:"mime_type"`
+	OriginalFilename string                `json:"original_filename"`
+	UploadedAt       time.Time             `json:"uploaded_at"`
+	// File is the slash-separated location of the original inside the export.
+	File string `json:"file"`
+}
+
+// LatestUpload returns the newest UploadedAt of the manifest's images. It is
+// the Since to pass to the next incremental export.
+func (m *Manifest) LatestUpload() time.Time {
+	var latest time.Time
+	if m == nil {
+		return latest
+	}
+	for _, img := range m.Images {
+		if img.UploadedAt.After(latest) {
+			latest = img.UploadedAt
+		}
+	}
+	if latest.IsZero() && m.Since != nil {
+		latest = *m.Since
+	}
+	return latest
+}
+
+// merge adds the images of prev, an earlier export into the same directory,
+// that m does not contain, and widens Since to cover them.
+func (m *Manifest) merge(prev *Manifest) {
+	seen := make(map[string]bool, len(m.Images))
+	for _, img := range m.Images {
+		seen[img.ID] = true
+	}
+	for _, img := range prev.Images {
+		if !seen[img.ID] {
+			m.Images = append(m.Images, img)
+		}
+	}
+	sort.Slice(m.Images, func(i, j int) bool { return m.Images[i].ID < m.Images[j].ID })
+
+	if prev.Since == nil || (m.Since != nil && prev.Since.Before(*m.Since)) {
+		m.Since = prev.Since
+	}
+}
+
+// ReadManifest reads the manifest of an export directory or archive.
+func ReadManifest(src string) (*Manifest, error) {
+	dir, cleanup, err := open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	return readManifestFile(filepath.Join(dir, ManifestName))
+}
+
+func readManifestFile(name string) (*Manifest, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("error decoding manifest: %w", err)
+	}
+	if m.Version > ManifestVersion {
+		return nil, fmt.Errorf("unsupported manifest version %d", m.Version)
+	}
+	return &m, nil
+}
+
+func writeManifestFile(name string, m *Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding manifest: %w", err)
+	}
+
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("error writing manifest: %w", err)
+	}
+	return os.Rename(tmp, name)
+}
@@ -0,0 +1,402 @@
+package backup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/internal/hooks"
+	"github.com/img-src-io/sdk-go/internal/workpool"
+	"github.com/img-src-io/sdk-go/models/apierrors"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/img-src-io/sdk-go/models/operations"
+	"github.com/img-src-io/sdk-go/optionalnullable"
+)
+
+// maxRenames bounds the candidates tried by ConflictRename.
+const maxRenames = 100
+
+// ConflictPolicy decides what Restore does when a path or preset name is
+// already taken by something else in the target account.
+type ConflictPolicy string
+
+const (
+	// ConflictSkip leaves the existing path or preset alone.
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictOverwrite replaces the existing path or preset.
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictRename restores under a new name, e.g. "a-1.png".
+	ConflictRename ConflictPolicy = "rename"
+)
+
+// RestoreOptions configures Restore.
+type RestoreOptions struct {
+	// Conflict is the conflict policy. Defaults to ConflictSkip.
+	Conflict ConflictPolicy
+	// Concurrency is the number of images uploaded in parallel. Defaults to 4.
+	Concurrency int
+	// SkipPresets and SkipSettings leave the presets and settings alone.
+	SkipPresets  bool
+	SkipSettings bool
+	// MappingFile, if set, receives a JSON object mapping the IDs recorded in
+	// the manifest to the IDs in the target account.
+	MappingFile string
+	// Username owning the paths in the target account, needed to overwrite
+	// paths. Defaults to the username returned by Settings.Get.
+	Username string
+	// OnImage, if set, is called after every image with its outcome. It may
+	// be called from several goroutines at once.
+	OnImage func(res ImageResult)
+}
+
+// ImageResult is the outcome of restoring a single image.
+type ImageResult struct {
+	OldID string
+	// NewID is empty if no path of the image could be restored.
+	NewID string
+	// Paths are the paths the image was restored under.
+	Paths []string
+	// Skipped are the recorded paths left alone because of a conflict.
+	Skipped []string
+	// Renamed maps recorded paths to the paths they were restored under.
+	Renamed map[string]string
+	Err     error
+}
+
+// RestoreReport summarizes a Restore run.
+type RestoreReport struct {
+	Images []ImageResult
+	// Mapping maps the IDs recorded in the manifest to the new IDs.
+	Mapping          map[string]string
+	PresetsCreated   int
+	PresetsUpdated   int
+	PresetsSkipped   int
+	SettingsRestored bool
+	Failed           int
+}
+
+// Err returns the errors of every failed image joined together, or nil.
+func (r *RestoreReport) Err() error {
+	if r == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, res := range r.Images {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("image %s: %w", res.OldID, res.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Restore re-creates the images, presets and settings of the export at src
+// (a directory or archive written by Export) in the account of client.
+//
+// Every image is uploaded once per recorded path with the recorded
+// visibility; the server deduplicates the bytes so that all paths end up on
+// one image. Because of that, running Restore again is safe: images and
+// paths already restored are matched instead of duplicated.
+func Restore(ctx context.Context, client *sdkgo.Imgsrc, src string, opts *RestoreOptions) (*RestoreReport, error) {
+	if opts == nil {
+		opts = &RestoreOptions{}
+	}
+	policy := opts.Conflict
+	if policy == "" {
+		policy = ConflictSkip
+	}
+	switch policy {
+	case ConflictSkip, ConflictOverwrite, ConflictRename:
+	default:
+		return nil, fmt.Errorf("unknown conflict policy %q", policy)
+	}
+
+	dir, cleanup, err := open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	manifest, err := readManifestFile(filepath.Join(dir, ManifestName))
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RestoreReport{Mapping: map[string]string{}}
+
+	if !opts.SkipSettings && manifest.Settings != nil {
+		if _, err := client.Settings.Update(ctx, settingsRequest(manifest.Settings)); err != nil {
+			return report, fmt.Errorf("error restoring settings: %w", err)
+		}
+		report.SettingsRestored = true
+	}
+
+	if !opts.SkipPresets && len(manifest.Presets) > 0 {
+		if err := restorePresets(ctx, client, manifest.Presets, policy, report); err != nil {
+			return report, err
+		}
+	}
+
+	username := opts.Username
+	if username == "" && policy == ConflictOverwrite {
+		res, err := client.Settings.Get(ctx)
+		if err != nil {
+			return report, fmt.Errorf("error resolving username: %w", err)
+		}
+		username = res.GetSettingsResponse().GetSettings().Username
+	}
+
+	r := &restorer{client: client, dir: dir, policy: policy, username: username}
+	report.Images = make([]ImageResult, len(manifest.Images))
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	workpool.ForEach(ctx, len(manifest.Images), concurrency, func(ctx context.Context, i int) {
+		report.Images[i] = r.restoreImage(ctx, manifest.Images[i])
+		if opts.OnImage != nil {
+			opts.OnImage(report.Images[i])
+		}
+	})
+	if err := ctx.Err(); err != nil {
+		return report, err
+	}
+
+	for _, res := range report.Images {
+		if res.Err != nil {
+			report.Failed++
+		}
+		if res.NewID != "" {
+			report.Mapping[res.OldID] = res.NewID
+		}
+	}
+
+	if opts.MappingFile != "" {
+		if err := writeMapping(opts.MappingFile, report.Mapping); err != nil {
+			return report, err
+		}
+	}
+
+	return report, report.Err()
+}
+
+type restorer struct {
+	client   *sdkgo.Imgsrc
+	dir      string
+	policy   ConflictPolicy
+	username string
+}
+
+func (r *restorer) restoreImage(ctx context.Context, img Image) ImageResult {
+	res := ImageResult{OldID: img.ID}
+
+	data, err := os.ReadFile(filepath.Join(r.dir, filepath.FromSlash(img.File)))
+	if err != nil {
+		res.Err = fmt.Errorf("error reading original: %w", err)
+		return res
+	}
+	if sum := sha256.Sum256(data); img.Hash != "" && !strings.EqualFold(hex.EncodeToString(sum[:]), img.Hash) {
+		res.Err = fmt.Errorf("%w: %s", ErrHashMismatch, img.File)
+		return res
+	}
+
+	paths := img.Paths
+	if len(paths) == 0 {
+		paths = []string{""}
+	}
+
+	var last *components.UploadResponse
+	for _, p := range paths {
+		out, restored, err := r.restorePath(ctx, img, data, strings.Trim(p, "/"))
+		if err != nil {
+			res.Err = fmt.Errorf("error restoring path %s: %w", p, err)
+			return res
+		}
+		if out == nil {
+			res.Skipped = append(res.Skipped, p)
+			continue
+		}
+
+		if res.NewID != "" && out.ID != res.NewID {
+			res.Err = fmt.Errorf("path %s was restored to image %s instead of %s", p, out.ID, res.NewID)
+			return res
+		}
+		res.NewID = out.ID
+		res.Paths = append(res.Paths, restored)
+		if p != "" && restored != strings.Trim(p, "/") {
+			if res.Renamed == nil {
+				res.Renamed = map[string]string{}
+			}
+			res.Renamed[p] = restored
+		}
+		last = out
+	}
+
+	// An image that deduplicated against one already in the account keeps
+	// that image's visibility, so make sure the recorded one applies.
+	if last != nil && img.Visibility != "" && last.Visibility != img.Visibility {
+		if _, err := r.client.Images.UpdateVisibility(ctx, res.NewID, components.UpdateVisibilityRequest{Visibility: img.Visibility}); err != nil {
+			res.Err = fmt.Errorf("error restoring visibility: %w", err)
+		}
+	}
+
+	return res
+}
+
+// restorePath uploads data under p, applying the conflict policy. It returns
+// a nil response if the path was skipped, and the path actually used.
+func (r *restorer) restorePath(ctx context.Context, img Image, data []byte, p string) (*components.UploadResponse, string, error) {
+	out, err := r.upload(ctx, img, data, p)
+	if err == nil || !isConflict(err) || p == "" {
+		return out, p, err
+	}
+
+	switch r.policy {
+	case ConflictOverwrite:
+		if _, err := r.client.Images.DeletePath(ctx, r.username, p); err != nil {
+			return nil, "", fmt.Errorf("error removing existing path: %w", err)
+		}
+		out, err = r.upload(ctx, img, data, p)
+		return out, p, err
+	case ConflictRename:
+		ext := path.Ext(p)
+		base := strings.TrimSuffix(p, ext)
+		for i := 1; i <= maxRenames; i++ {
+			candidate := fmt.Sprintf("%s-%d%s", base, i, ext)
+			out, err = r.upload(ctx, img, data, candidate)
+			if err == nil || !isConflict(err) {
+				return out, candidate, err
+			}
+		}
+		return nil, "", fmt.Errorf("no free name after %d attempts", maxRenames)
+	default:
+		return nil, p, nil
+	}
+}
+
+func (r *restorer) upload(ctx context.Context, img Image, data []byte, p string) (*components.UploadResponse, error) {
+	filename := img.OriginalFilename
+	if p != "" {
+		filename = path.Base(p)
+	}
+
+	req := &operations.UploadImageRequestBody{
+		File: &operations.File{FileName: filename, Content: data},
+	}
+	if p != "" {
+		req.TargetPath = sdkgo.String(p)
+	}
+	if img.Visibility != "" {
+		visibility := img.Visibility
+		req.Visibility = &visibility
+	}
+
+	res, err := r.client.Images.Upload(hooks.WithExactUpload(ctx), req)
+	if err != nil {
+		return nil, err
+	}
+	if res.GetUploadResponse() == nil {
+		return nil, errors.New("empty upload response")
+	}
+	return res.GetUploadResponse(), nil
+}
+
+func restorePresets(ctx context.Context, client *sdkgo.Imgsrc, presets []components.Preset, policy ConflictPolicy, report *RestoreReport) error {
+	res, err := client.Presets.ListPresets(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing presets: %w", err)
+	}
+
+	existing := map[string]string{}
+	if out := res.GetListPresetsResponse(); out != nil {
+		for _, p := range out.Presets {
+			existing[p.Name] = p.ID
+		}
+	}
+
+	for _, p := range presets {
+		id, taken := existing[p.Name]
+		name := p.Name
+
+		switch {
+		case taken && policy == ConflictSkip:
+			report.PresetsSkipped++
+			continue
+		case taken && policy == ConflictOverwrite:
+			_, err := client.Presets.UpdatePreset(ctx, id, &components.UpdatePresetRequest{
+				Name:        sdkgo.String(p.Name),
+				Description: optionalnullable.From(p.Description),
+				Params:      p.Params,
+			})
+			if err != nil {
+				return fmt.Errorf("error updating preset %s: %w", p.Name, err)
+			}
+			report.PresetsUpdated++
+			continue
+		case taken && policy == ConflictRename:
+			for i := 1; ; i++ {
+				name = fmt.Sprintf("%s-%d", p.Name, i)
+				if _, ok := existing[name]; !ok {
+					break
+				}
+			}
+		}
+
+		created, err := client.Presets.CreatePreset(ctx, &components.CreatePresetRequest{
+			Name:        name,
+			Description: p.Description,
+			Params:      p.Params,
+		})
+		if err != nil {
+			return fmt.Errorf("error creating preset %s: %w", name, err)
+		}
+		existing[name] = created.GetPreset().GetID()
+		report.PresetsCreated++
+	}
+
+	return nil
+}
+
+func settingsRequest(s *components.UserSettings) *components.UpdateSettingsRequest {
+	return &components.UpdateSettingsRequest{
+		DeliveryFormats:  s.DeliveryFormats,
+		DefaultQuality:   sdkgo.Int64(s.DefaultQuality),
+		DefaultFitMode:   sdkgo.String(s.DefaultFitMode),
+		DefaultMaxWidth:  optionalnullable.From(s.DefaultMaxWidth),
+		DefaultMaxHeight: optionalnullable.From(s.DefaultMaxHeight),
+		Theme:            sdkgo.String(s.Theme),
+		Language:         sdkgo.String(s.Language),
+	}
+}
+
+// isConflict reports whether err is the API rejecting a path that is taken.
+func isConflict(err error) bool {
+	var errResp *apierrors.ErrorResponse
+	if errors.As(err, &errResp) {
+		return errResp.Error_.Status == http.StatusConflict ||
+			strings.Contains(strings.ToLower(errResp.Error_.Code), "conflict")
+	}
+	var apiErr *apierrors.APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict
+}
+
+func writeMapping(name string, mapping map[string]string) error {
+	data, err := json.MarshalIndent(mapping, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding mapping: %w", err)
+	}
+	if err := os.WriteFile(name, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("error writing mapping file: %w", err)
+	}
+	return nil
+}
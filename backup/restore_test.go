@@ -0,0 +1,148 @@
+package backup_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/img-src-io/sdk-go/backup"
+	"github.com/img-src-io/sdk-go/internal/fakeapi"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportLibrary(t *testing.T, dest string) (*fakeapi.Server, *backup.Manifest) {
+	t.Helper()
+	src, _ := newExportLibrary(t)
+	_, err := src.SDK().Settings.Update(context.Background(), &components.UpdateSettingsRequest{Theme: ptr("dark")})
+	require.NoError(t, err)
+
+	report, err := backup.Export(context.Background(), src.SDK(), dest, nil)
+	require.NoError(t, err)
+	return src, report.Manifest
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestRestore(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	src, manifest := exportLibrary(t, filepath.Join(root, "backup.tar.gz"))
+
+	dst := fakeapi.New(t)
+	dst.Add("unrelated", "other/x.png")
+	mappingFile := filepath.Join(root, "id-map.json")
+
+	report, err := backup.Restore(context.Background(), dst.SDK(), filepath.Join(root, "backup.tar.gz"), &backup.RestoreOptions{MappingFile: mappingFile})
+	require.NoError(t, err)
+	assert.True(t, report.SettingsRestored)
+	assert.Equal(t, 1, report.PresetsCreated)
+	require.Len(t, report.Mapping, 3)
+
+	for _, img := range manifest.Images {
+		restored := dst.Image(report.Mapping[img.ID])
+		require.NotNil(t, restored, img.ID)
+		assert.NotEqual(t, img.ID, restored.ID)
+		assert.Equal(t, src.Image(img.ID).Data, restored.Data)
+		assert.Equal(t, img.Paths, restored.Paths)
+		assert.Equal(t, string(img.Visibility), restored.Visibility)
+	}
+
+	assert.Equal(t, "dark", dst.Setting("theme"))
+	require.Len(t, dst.Presets(), 1)
+	assert.Equal(t, "thumb", dst.Presets()[0]["name"])
+
+	data, err := os.ReadFile(mappingFile)
+	require.NoError(t, err)
+	var mapping map[string]string
+	require.NoError(t, json.Unmarshal(data, &mapping))
+	assert.Equal(t, report.Mapping, mapping)
+
+	// A second run matches everything that is already there.
+	again, err := backup.Restore(context.Background(), dst.SDK(), filepath.Join(root, "backup.tar.gz"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, report.Mapping, again.Mapping)
+	assert.Equal(t, 1, again.PresetsSkipped)
+	assert.Equal(t, 4, dst.Len())
+	assert.Len(t, dst.Presets(), 1)
+}
+
+func TestRestore_Conflicts(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	_, manifest := exportLibrary(t, root)
+	first := manifest.Images[0]
+	require.Equal(t, []string{"blog/a.png", "home/a.png"}, first.Paths)
+
+	newTarget := func(t *testing.T) (*fakeapi.Server, string) {
+		dst := fakeapi.New(t)
+		squatter := dst.Add("squatter", "blog/a.png")
+		dst.AddPreset("thumb", map[string]any{"w": 100})
+		return dst, squatter
+	}
+
+	t.Run("skip", func(t *testing.T) {
+		t.Parallel()
+		dst, squatter := newTarget(t)
+
+		report, err := backup.Restore(context.Background(), dst.SDK(), root, &backup.RestoreOptions{Conflict: backup.ConflictSkip})
+		require.NoError(t, err)
+		res := report.Images[0]
+		assert.Equal(t, []string{"blog/a.png"}, res.Skipped)
+		assert.Equal(t, []string{"home/a.png"}, res.Paths)
+		assert.Equal(t, []string{"blog/a.png"}, dst.Image(squatter).Paths)
+		assert.Equal(t, 1, report.PresetsSkipped)
+		assert.EqualValues(t, 100, dst.Presets()[0]["params"].(map[string]any)["w"])
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		t.Parallel()
+		dst, squatter := newTarget(t)
+
+		report, err := backup.Restore(context.Background(), dst.SDK(), root, &backup.RestoreOptions{Conflict: backup.ConflictOverwrite})
+		require.NoError(t, err)
+		res := report.Images[0]
+		assert.Empty(t, res.Skipped)
+		assert.Equal(t, first.Paths, dst.Image(res.NewID).Paths)
+		assert.Nil(t, dst.Image(squatter))
+		assert.Equal(t, 1, report.PresetsUpdated)
+		require.Len(t, dst.Presets(), 1)
+		assert.EqualValues(t, 200, dst.Presets()[0]["params"].(map[string]any)["w"])
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		t.Parallel()
+		dst, squatter := newTarget(t)
+
+		report, err := backup.Restore(context.Background(), dst.SDK(), root, &backup.RestoreOptions{Conflict: backup.ConflictRename})
+		require.NoError(t, err)
+		res := report.Images[0]
+		assert.Equal(t, map[string]string{"blog/a.png": "blog/a-1.png"}, res.Renamed)
+		assert.Equal(t, []string{"blog/a-1.png", "home/a.png"}, dst.Image(res.NewID).Paths)
+		assert.Equal(t, []string{"blog/a.png"}, dst.Image(squatter).Paths)
+		assert.Equal(t, 1, report.PresetsCreated)
+		require.Len(t, dst.Presets(), 2)
+		assert.Equal(t, "thumb-1", dst.Presets()[1]["name"])
+	})
+}
+
+func TestRestore_RestoresVisibilityOfExistingImage(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	src, manifest := exportLibrary(t, root)
+	private := manifest.Images[1]
+	require.Equal(t, components.VisibilityPrivate, private.Visibility)
+
+	// The same bytes already live in the target as a public image.
+	dst := fakeapi.New(t)
+	existing := dst.Add(string(src.Image(private.ID).Data), "elsewhere/b.jpg")
+
+	report, err := backup.Restore(context.Background(), dst.SDK(), root, &backup.RestoreOptions{SkipPresets: true, SkipSettings: true})
+	require.NoError(t, err)
+	assert.Equal(t, existing, report.Mapping[private.ID])
+	assert.Equal(t, "private", dst.Image(existing).Visibility)
+	assert.ElementsMatch(t, []string{"elsewhere/b.jpg", "blog/2024/b.jpg"}, dst.Image(existing).Paths)
+}
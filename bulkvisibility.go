@@ -0,0 +1,231 @@
+package sdkgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/img-src-io/sdk-go/internal/workpool"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/img-src-io/sdk-go/models/operations"
+)
+
+// DefaultBulkSearchLimit is the number of search results considered when
+// VisibilitySelector.Query is set and BulkVisibilityOptions.SearchLimit is zero.
+const DefaultBulkSearchLimit = 100
+
+// ErrVisibilityMismatch is recorded for items whose visibility did not match
+// the requested one during the verify pass.
+var ErrVisibilityMismatch = errors.New("visibility mismatch")
+
+// VisibilitySelector selects the images of a bulk visibility change. The
+// selections are combined; at least one must be set.
+type VisibilitySelector struct {
+	// Prefix selects every image with a path under the folder.
+	Prefix string
+	// Query selects the results of Images.Search.
+	Query string
+	// IDs selects images by ID.
+	IDs []string
+}
+
+// BulkVisibilityOptions configures Images.UpdateVisibilityBulk.
+type BulkVisibilityOptions struct {
+	// DryRun reports what would change without changing anything.
+	DryRun bool
+	// Verify re-reads the visibility of every item after the update.
+	Verify bool
+	// Concurrency is the number of images processed in parallel. Defaults to 8.
+	Concurrency int
+	// SearchLimit is the number of search results requested for Query.
+	// Defaults to DefaultBulkSearchLimit. The run fails without changing
+	// anything if the query matches more images than that.
+	SearchLimit int64
+}
+
+// BulkVisibilityItem is the outcome for a single image.
+type BulkVisibilityItem struct {
+	ImageID string
+	// Previous is the visibility before the change. It is empty for images
+	// selected by ID only, which are updated unconditionally.
+	Previous components.Visibility
+	// Changed reports whether the image was (or, for dry runs, would be) updated.
+	Changed bool
+	// Verified reports whether the verify pass read back the requested visibility.
+	Verified bool
+	Err      error
+}
+
+// BulkVisibilityReport summarizes an UpdateVisibilityBulk run.
+type BulkVisibilityReport struct {
+	Visibility components.Visibility
+	DryRun     bool
+	Items      []BulkVisibilityItem
+	Changed    int
+	Unchanged  int
+	Failed     int
+}
+
+// Err returns the errors of every failed item joined together, or nil.
+func (r *BulkVisibilityReport) Err() error {
+	if r == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, item := range r.Items {
+		if item.Err != nil {
+			errs = append(errs, fmt.Errorf("image %s: %w", item.ImageID, item.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// UpdateVisibilityBulk sets the visibility of every selected image. Images
+// already at the requested visibility are left alone. Failures of individual
+// images are recorded in the report rather than aborting the run.
+func (s *Images) UpdateVisibilityBulk(ctx context.Context, sel VisibilitySelector, visibility components.Visibility, opts *BulkVisibilityOptions, reqOpts ...operations.Option) (*BulkVisibilityReport, error) {
+	if opts == nil {
+		opts = &BulkVisibilityOptions{}
+	}
+	if sel.Prefix == "" && sel.Query == "" && len(sel.IDs) == 0 {
+		return nil, errors.New("selector must set a prefix, a query or IDs")
+	}
+
+	items, err := s.selectVisibilityItems(ctx, sel, opts, reqOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &BulkVisibilityReport{Visibility: visibility, DryRun: opts.DryRun, Items: items}
+	for i := range report.Items {
+		report.Items[i].Changed = report.Items[i].Previous != visibility
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 8
+	}
+
+	if !opts.DryRun {
+		workpool.ForEach(ctx, len(report.Items), concurrency, func(ctx context.Context, i int) {
+			item := &report.Items[i]
+			if !item.Changed {
+				return
+			}
+			if _, err := s.UpdateVisibility(ctx, item.ImageID, components.UpdateVisibilityRequest{Visibility: visibility}, reqOpts...); err != nil {
+				item.Changed = false
+				item.Err = err
+			}
+		})
+
+		if opts.Verify {
+			s.verifyVisibility(ctx, sel.Prefix, visibility, report.Items, concurrency, reqOpts...)
+		}
+	}
+
+	for _, item := range report.Items {
+		switch {
+		case item.Err != nil:
+			report.Failed++
+		case item.Changed:
+			report.Changed++
+		default:
+			report.Unchanged++
+		}
+	}
+
+	return report, ctx.Err()
+}
+
+// selectVisibilityItems resolves the selector into items sorted by ID.
+func (s *Images) selectVisibilityItems(ctx context.Context, sel VisibilitySelector, opts *BulkVisibilityOptions, reqOpts ...operations.Option) ([]BulkVisibilityItem, error) {
+	byID := map[string]*BulkVisibilityItem{}
+	add := func(id string, previous components.Visibility) {
+		if item, ok := byID[id]; ok {
+			if item.Previous == "" {
+				item.Previous = previous
+			}
+			return
+		}
+		byID[id] = &BulkVisibilityItem{ImageID: id, Previous: previous}
+	}
+
+	if sel.Prefix != "" {
+		err := s.Walk(ctx, sel.Prefix, func(img components.ImageListItem) error {
+			add(img.ID, img.Visibility)
+			return nil
+		}, reqOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("error listing images: %w", err)
+		}
+	}
+
+	if sel.Query != "" {
+		limit := opts.SearchLimit
+		if limit <= 0 {
+			limit = DefaultBulkSearchLimit
+		}
+
+		res, err := s.Search(ctx, sel.Query, Int64(limit), reqOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("error searching images: %w", err)
+		}
+		if out := res.GetSearchResponse(); out != nil {
+			if out.Total > int64(len(out.Results)) {
+				return nil, fmt.Errorf("query %q matches %d images but only %d were returned; raise SearchLimit", sel.Query, out.Total, len(out.Results))
+			}
+			for _, r := range out.Results {
+				add(r.ID, r.Visibility)
+			}
+		}
+	}
+
+	for _, id := range sel.IDs {
+		add(id, "")
+	}
+
+	items := make([]BulkVisibilityItem, 0, len(byID))
+	for _, item := range byID {
+		items = append(items, *item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ImageID < items[j].ImageID })
+
+	return items, nil
+}
+
+// verifyVisibility re-reads the visibility of every item that did not fail.
+// Images under prefix are checked from a fresh listing, the others one by one.
+func (s *Images) verifyVisibility(ctx context.Context, prefix string, visibility components.Visibility, items []BulkVisibilityItem, concurrency int, opts ...operations.Option) {
+	listed := map[string]components.Visibility{}
+	if prefix != "" {
+		_ = s.Walk(ctx, prefix, func(img components.ImageListItem) error {
+			listed[img.ID] = img.Visibility
+			return nil
+		}, opts...)
+	}
+
+	workpool.ForEach(ctx, len(items), concurrency, func(ctx context.Context, i int) {
+		item := &items[i]
+		if item.Err != nil {
+			return
+		}
+
+		got, ok := listed[item.ImageID]
+		if !ok {
+			res, err := s.GetMetadata(ctx, item.ImageID, opts...)
+			if err != nil {
+				item.Err = fmt.Errorf("error verifying visibility: %w", err)
+				return
+			}
+			got = res.GetMetadataResponse().GetVisibility()
+		}
+
+		if got != visibility {
+			item.Err = fmt.Errorf("%w: got %s, want %s", ErrVisibilityMismatch, got, visibility)
+			return
+		}
+		item.Verified = true
+	})
+}
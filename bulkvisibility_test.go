@@ -0,0 +1,121 @@
+package sdkgo_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/internal/fakeapi"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newVisibilityLibrary(t *testing.T) (*fakeapi.Server, []string) {
+	t.Helper()
+	f := fakeapi.New(t)
+	ids := []string{
+		f.Add("a", "legal/a.png"),
+		f.Add("b", "legal/2024/b.png"),
+		f.Add("c", "legal/c.png"),
+		f.Add("d", "public/d.png"),
+		f.Add("e", "public/report-e.png"),
+	}
+	f.Update(ids[2], func(img *fakeapi.Image) { img.Visibility = "private" })
+	return f, ids
+}
+
+func TestImages_UpdateVisibilityBulk(t *testing.T) {
+	t.Parallel()
+
+	t.Run("dry run", func(t *testing.T) {
+		t.Parallel()
+		f, ids := newVisibilityLibrary(t)
+
+		report, err := f.SDK().Images.UpdateVisibilityBulk(context.Background(), sdkgo.VisibilitySelector{Prefix: "legal"}, components.VisibilityPrivate, &sdkgo.BulkVisibilityOptions{DryRun: true})
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.Changed)
+		assert.Equal(t, 1, report.Unchanged)
+		require.Len(t, report.Items, 3)
+		assert.Equal(t, components.VisibilityPublic, report.Items[0].Previous)
+		assert.Equal(t, "public", f.Image(ids[0]).Visibility)
+		assert.Zero(t, f.Count("PATCH /api/v1/images/{id}/visibility"))
+	})
+
+	t.Run("prefix, query and IDs with verify", func(t *testing.T) {
+		t.Parallel()
+		f, ids := newVisibilityLibrary(t)
+
+		report, err := f.SDK().Images.UpdateVisibilityBulk(context.Background(), sdkgo.VisibilitySelector{
+			Prefix: "legal/",
+			Query:  "report",
+			IDs:    []string{ids[0], ids[3]},
+		}, components.VisibilityPrivate, &sdkgo.BulkVisibilityOptions{Verify: true, Concurrency: 2})
+		require.NoError(t, err)
+		require.NoError(t, report.Err())
+		assert.Equal(t, 4, report.Changed)
+		assert.Equal(t, 1, report.Unchanged)
+		for _, item := range report.Items {
+			assert.True(t, item.Verified, item.ImageID)
+			assert.Equal(t, "private", f.Image(item.ImageID).Visibility)
+		}
+		assert.Equal(t, 4, f.Count("PATCH /api/v1/images/{id}/visibility"))
+		// Only the ID-selected image outside the prefix is read back individually.
+		assert.Equal(t, 2, f.Count("GET /api/v1/images/{id}"))
+	})
+
+	t.Run("records failures", func(t *testing.T) {
+		t.Parallel()
+		f, ids := newVisibilityLibrary(t)
+		f.Fail(func(r *http.Request) int {
+			if r.Method == http.MethodPatch && strings.Contains(r.URL.Path, ids[1]) {
+				return http.StatusForbidden
+			}
+			return 0
+		})
+
+		report, err := f.SDK().Images.UpdateVisibilityBulk(context.Background(), sdkgo.VisibilitySelector{Prefix: "legal"}, components.VisibilityPrivate, &sdkgo.BulkVisibilityOptions{Verify: true})
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Failed)
+		assert.Equal(t, 1, report.Changed)
+		require.Error(t, report.Err())
+		assert.Contains(t, report.Err().Error(), ids[1])
+	})
+
+	t.Run("verify detects mismatch", func(t *testing.T) {
+		t.Parallel()
+		f, ids := newVisibilityLibrary(t)
+		f.Fail(func(r *http.Request) int {
+			// Something reverts the change before it is read back.
+			if r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, ids[3]) {
+				f.Update(ids[3], func(img *fakeapi.Image) { img.Visibility = "public" })
+			}
+			return 0
+		})
+
+		report, err := f.SDK().Images.UpdateVisibilityBulk(context.Background(), sdkgo.VisibilitySelector{IDs: []string{ids[3]}}, components.VisibilityPrivate, &sdkgo.BulkVisibilityOptions{Verify: true})
+		require.NoError(t, err)
+		require.Len(t, report.Items, 1)
+		assert.False(t, report.Items[0].Verified)
+		assert.ErrorIs(t, report.Items[0].Err, sdkgo.ErrVisibilityMismatch)
+	})
+
+	t.Run("truncated search", func(t *testing.T) {
+		t.Parallel()
+		f, _ := newVisibilityLibrary(t)
+
+		_, err := f.SDK().Images.UpdateVisibilityBulk(context.Background(), sdkgo.VisibilitySelector{Query: "png"}, components.VisibilityPrivate, &sdkgo.BulkVisibilityOptions{SearchLimit: 2})
+		require.Error(t, err)
+		assert.Zero(t, f.Count("PATCH /api/v1/images/{id}/visibility"))
+	})
+
+	t.Run("empty selector", func(t *testing.T) {
+		t.Parallel()
+		f, _ := newVisibilityLibrary(t)
+
+		_, err := f.SDK().Images.UpdateVisibilityBulk(context.Background(), sdkgo.VisibilitySelector{}, components.VisibilityPrivate, nil)
+		require.Error(t, err)
+	})
+}
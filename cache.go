@@ -0,0 +1,17 @@
+package sdkgo
+
+import (
+	"github.com/img-src-io/sdk-go/cache"
+)
+
+// WithCache enables the response cache for Images.GetMetadata,
+// Presets.GetPreset, Presets.ListPresets, Settings.Get and Usage.Get. Expired
+// entries are revalidated with If-None-Match when the server returned an
+// ETag, and successful mutations invalidate the entries they affect. Send a
+// "Cache-Control: no-cache" header with operations.WithSetHeaders to force
+// revalidation of a single call.
+func WithCache(cfg cache.Config) SDKOption {
+	return func(sdk *Imgsrc) {
+		sdk.hooks.ConfigureCache(cfg)
+	}
+}
@@ -0,0 +1,67 @@
+// Package cache provides the storage used by the SDK's opt-in response cache
+// for read operations.
+package cache
+
+import (
+	"net/http"
+	"time"
+)
+
+// DefaultTTL is the time-to-live applied when Config.DefaultTTL is zero.
+const DefaultTTL = time.Minute
+
+// Config configures the response cache enabled with sdkgo.WithCache.
+//
+// Only idempotent reads are cached: getImage, getPreset, listPresets,
+// getSettings and getUsage. Mutating operations invalidate the entries they
+// affect once they succeed.
+type Config struct {
+	// Store holds cached responses. Defaults to an in-memory LRU of
+	// DefaultLRUSize entries.
+	Store Store
+	// DefaultTTL is the time-to-live of operations without an entry in TTLs.
+	// Defaults to DefaultTTL.
+	DefaultTTL time.Duration
+	// TTLs overrides the time-to-live per operation ID (e.g. "getImage"). A
+	// negative value disables caching for that operation.
+	TTLs map[string]time.Duration
+}
+
+// TTL returns the time-to-live configured for an operation ID. A negative
+// value means the operation is not cached.
+func (c Config) TTL(operationID string) time.Duration {
+	if ttl, ok := c.TTLs[operationID]; ok && ttl != 0 {
+		return ttl
+	}
+	if c.DefaultTTL != 0 {
+		return c.DefaultTTL
+	}
+	return DefaultTTL
+}
+
+// Entry is a cached HTTP response.
+type Entry struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// ETag is the entity tag used to revalidate the entry once it expires.
+	ETag      string
+	StoredAt  time.Time
+	ExpiresAt time.Time
+	// Versions records the invalidation generation of every tag the entry
+	// depends on. Entries whose versions are outdated are treated as misses.
+	Versions map[string]uint64
+}
+
+// Fresh reports whether the entry can be served without revalidation.
+func (e *Entry) Fresh(now time.Time) bool {
+	return e != nil && now.Before(e.ExpiresAt)
+}
+
+// Store is a pluggable storage backend for cached responses. Implementations
+// must be safe for concurrent use.
+type Store interface {
+	Get(key string) (*Entry, bool)
+	Set(key string, entry *Entry)
+	Delete(key string)
+}
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU_GetSetDelete(t *testing.T) {
+	t.Parallel()
+	l := NewLRU(2)
+
+	_, ok := l.Get("a")
+	assert.False(t, ok)
+
+	l.Set("a", &Entry{Body: []byte("a")})
+	got, ok := l.Get("a")
+	require.True(t, ok)
+	assert.Equal(t, []byte("a"), got.Body)
+
+	l.Set("a", &Entry{Body: []byte("a2")})
+	got, _ = l.Get("a")
+	assert.Equal(t, []byte("a2"), got.Body)
+	assert.Equal(t, 1, l.Len())
+
+	l.Delete("a")
+	_, ok = l.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, l.Len())
+}
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+	l := NewLRU(2)
+
+	l.Set("a", &Entry{})
+	l.Set("b", &Entry{})
+	_, _ = l.Get("a")
+	l.Set("c", &Entry{})
+
+	_, ok := l.Get("b")
+	assert.False(t, ok)
+	_, ok = l.Get("a")
+	assert.True(t, ok)
+	_, ok = l.Get("c")
+	assert.True(t, ok)
+
+	l.Purge()
+	assert.Equal(t, 0, l.Len())
+}
+
+func TestLRU_DefaultSize(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, DefaultLRUSize, NewLRU(0).size)
+}
+
+func TestConfig_TTL(t *testing.T) {
+	t.Parallel()
+	c := Config{
+		DefaultTTL: 5 * time.Minute,
+		TTLs: map[string]time.Duration{
+			"getUsage": 10 * time.Second,
+			"getImage": -1,
+		},
+	}
+	assert.Equal(t, 10*time.Second, c.TTL("getUsage"))
+	assert.Equal(t, time.Duration(-1), c.TTL("getImage"))
+	assert.Equal(t, 5*time.Minute, c.TTL("getSettings"))
+	assert.Equal(t, DefaultTTL, Config{}.TTL("getSettings"))
+}
+
+func TestEntry_Fresh(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	assert.True(t, (&Entry{ExpiresAt: now.Add(time.Second)}).Fresh(now))
+	assert.False(t, (&Entry{ExpiresAt: now}).Fresh(now))
+	assert.False(t, (*Entry)(nil).Fresh(now))
+}
@@ -0,0 +1,148 @@
+package sdkgo_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/cache"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/img-src-io/sdk-go/models/operations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const metadataJSON = `{"id":"abc123","metadata":{"hash":"h","original_filename":"a.png","size":1,"uploaded_at":"2026-01-01T00:00:00Z","mime_type":"image/png"},"urls":{"original":"","webp":"","avif":"","jpeg":"","png":"","jxl":""},"visibility":"public","_links":{}}`
+
+type cacheTestServer struct {
+	*httptest.Server
+	gets        atomic.Int32
+	revalidated atomic.Int32
+}
+
+func newCacheTestServer(t *testing.T, etag string) *cacheTestServer {
+	t.Helper()
+	s := &cacheTestServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/v1/images/"):
+			s.gets.Add(1)
+			if etag != "" {
+				if r.Header.Get("If-None-Match") == etag {
+					s.revalidated.Add(1)
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set("ETag", etag)
+			}
+			_, _ = w.Write([]byte(metadataJSON))
+		case r.Method == http.MethodPatch && strings.HasSuffix(r.URL.Path, "/visibility"):
+			_, _ = w.Write([]byte(`{"id":"abc123","visibility":"private","message":"ok"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestCache_ServesFreshEntries(t *testing.T) {
+	t.Parallel()
+	srv := newCacheTestServer(t, "")
+	s := sdkgo.New(
+		sdkgo.WithServerURL(srv.URL),
+		sdkgo.WithSecurity("imgsrc_test"),
+		sdkgo.WithCache(cache.Config{}),
+	)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		res, err := s.Images.GetMetadata(ctx, "abc123")
+		require.NoError(t, err)
+		require.NotNil(t, res.MetadataResponse)
+		assert.Equal(t, "abc123", res.MetadataResponse.ID)
+	}
+	assert.Equal(t, int32(1), srv.gets.Load())
+
+	// A different image is a different entry.
+	_, err := s.Images.GetMetadata(ctx, "def456")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), srv.gets.Load())
+
+	// no-cache forces a round trip.
+	_, err = s.Images.GetMetadata(ctx, "abc123", operations.WithSetHeaders(map[string]string{"Cache-Control": "no-cache"}))
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), srv.gets.Load())
+}
+
+func TestCache_RevalidatesWithETag(t *testing.T) {
+	t.Parallel()
+	srv := newCacheTestServer(t, `"v1"`)
+	s := sdkgo.New(
+		sdkgo.WithServerURL(srv.URL),
+		sdkgo.WithSecurity("imgsrc_test"),
+		sdkgo.WithCache(cache.Config{TTLs: map[string]time.Duration{"getImage": time.Nanosecond}}),
+	)
+
+	ctx := context.Background()
+	_, err := s.Images.GetMetadata(ctx, "abc123")
+	require.NoError(t, err)
+
+	time.Sleep(time.Millisecond)
+	res, err := s.Images.GetMetadata(ctx, "abc123")
+	require.NoError(t, err)
+	require.NotNil(t, res.MetadataResponse)
+	assert.Equal(t, "abc123", res.MetadataResponse.ID)
+	assert.Equal(t, int32(2), srv.gets.Load())
+	assert.Equal(t, int32(1), srv.revalidated.Load())
+}
+
+func TestCache_MutationInvalidates(t *testing.T) {
+	t.Parallel()
+	srv := newCacheTestServer(t, "")
+	store := cache.NewLRU(10)
+	s := sdkgo.New(
+		sdkgo.WithServerURL(srv.URL),
+		sdkgo.WithSecurity("imgsrc_test"),
+		sdkgo.WithCache(cache.Config{Store: store}),
+	)
+
+	ctx := context.Background()
+	_, err := s.Images.GetMetadata(ctx, "abc123")
+	require.NoError(t, err)
+	_, err = s.Images.GetMetadata(ctx, "def456")
+	require.NoError(t, err)
+	assert.Equal(t, 2, store.Len())
+
+	_, err = s.Images.UpdateVisibility(ctx, "abc123", components.UpdateVisibilityRequest{Visibility: components.VisibilityPrivate})
+	require.NoError(t, err)
+
+	_, err = s.Images.GetMetadata(ctx, "abc123")
+	require.NoError(t, err)
+	_, err = s.Images.GetMetadata(ctx, "def456")
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), srv.gets.Load())
+}
+
+func TestCache_DisabledOperation(t *testing.T) {
+	t.Parallel()
+	srv := newCacheTestServer(t, "")
+	s := sdkgo.New(
+		sdkgo.WithServerURL(srv.URL),
+		sdkgo.WithSecurity("imgsrc_test"),
+		sdkgo.WithCache(cache.Config{TTLs: map[string]time.Duration{"getImage": -1}}),
+	)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		_, err := s.Images.GetMetadata(ctx, "abc123")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), srv.gets.Load())
+}
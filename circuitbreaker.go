@@ -0,0 +1,30 @@
+package sdkgo
+
+import (
+	"github.com/img-src-io/sdk-go/retry"
+)
+
+// WithCircuitBreaker enables a circuit breaker for every operation of the client.
+// Each operation ID gets its own circuit, which opens after consecutive 5XX
+// responses or connection errors and rejects requests with retry.ErrCircuitOpen
+// until a half-open probe succeeds.
+func WithCircuitBreaker(cfg retry.CircuitBreakerConfig) SDKOption {
+	return func(sdk *Imgsrc) {
+		sdk.hooks.ConfigureCircuitBreaker("", cfg)
+	}
+}
+
+// WithOperationCircuitBreaker configures the circuit breaker of a single
+// operation ID (e.g. "getImage"), overriding the configuration provided with
+// WithCircuitBreaker.
+func WithOperationCircuitBreaker(operationID string, cfg retry.CircuitBreakerConfig) SDKOption {
+	return func(sdk *Imgsrc) {
+		sdk.hooks.ConfigureCircuitBreaker(operationID, cfg)
+	}
+}
+
+// CircuitState returns the state of the circuit breaker for an operation ID.
+// Operations without a circuit breaker are always reported as closed.
+func (s *Imgsrc) CircuitState(operationID string) retry.CircuitState {
+	return s.hooks.CircuitState(operationID)
+}
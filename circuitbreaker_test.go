@@ -0,0 +1,94 @@
+package sdkgo_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker_StopsRetryLoop(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	s := sdkgo.New(
+		sdkgo.WithServerURL(srv.URL),
+		sdkgo.WithSecurity("imgsrc_test"),
+		sdkgo.WithRetryConfig(retry.Config{
+			Strategy: "backoff",
+			Backoff: &retry.BackoffStrategy{
+				InitialInterval: 1,
+				MaxInterval:     5,
+				Exponent:        1.1,
+				MaxElapsedTime:  60000,
+			},
+			RetryConnectionErrors: true,
+		}),
+		sdkgo.WithCircuitBreaker(retry.CircuitBreakerConfig{
+			FailureThreshold: 3,
+			OpenTimeout:      time.Minute,
+		}),
+	)
+
+	ctx := context.Background()
+	_, err := s.Usage.Get(ctx)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, retry.ErrCircuitOpen))
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, retry.CircuitOpen, s.CircuitState("getUsage"))
+
+	// Further calls fail fast without reaching the server.
+	_, err = s.Usage.Get(ctx)
+	assert.True(t, errors.Is(err, retry.ErrCircuitOpen))
+	assert.Equal(t, int32(3), calls.Load())
+
+	// Circuits are kept per operation ID.
+	assert.Equal(t, retry.CircuitClosed, s.CircuitState("getSettings"))
+}
+
+func TestCircuitBreaker_PerOperationOnly(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	s := sdkgo.New(
+		sdkgo.WithServerURL(srv.URL),
+		sdkgo.WithSecurity("imgsrc_test"),
+		sdkgo.WithRetryConfig(retry.Config{Strategy: "none"}),
+		sdkgo.WithOperationCircuitBreaker("getUsage", retry.CircuitBreakerConfig{FailureThreshold: 1}),
+	)
+
+	ctx := context.Background()
+	_, err := s.Usage.Get(ctx)
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, retry.ErrCircuitOpen))
+
+	_, err = s.Usage.Get(ctx)
+	assert.True(t, errors.Is(err, retry.ErrCircuitOpen))
+
+	for i := 0; i < 3; i++ {
+		_, err = s.Settings.Get(ctx)
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, retry.ErrCircuitOpen))
+	}
+	assert.Equal(t, int32(4), calls.Load())
+}
@@ -0,0 +1,107 @@
+// Command imgsrc-audit reports the images of an img-src account that are
+// candidates for cleanup: exact duplicates, images without paths, very large
+// originals and images that appear unused.
+//
+// Usage:
+//
+//	imgsrc-audit [flags]
+//
+// The API key is read from IMGSRC_API_KEY; IMGSRC_SERVER_URL overrides the
+// API server.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/audit"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	err := runAudit(ctx, args, stdout, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return 1
+	}
+	return 0
+}
+
+func newClient() (*sdkgo.Imgsrc, error) {
+	apiKey := os.Getenv("IMGSRC_API_KEY")
+	if apiKey == "" {
+		return nil, errors.New("IMGSRC_API_KEY is not set")
+	}
+
+	opts := []sdkgo.SDKOption{sdkgo.WithSecurity(apiKey)}
+	if serverURL := os.Getenv("IMGSRC_SERVER_URL"); serverURL != "" {
+		opts = append(opts, sdkgo.WithServerURL(serverURL))
+	}
+	return sdkgo.New(opts...), nil
+}
+
+func runAudit(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("imgsrc-audit", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	folder := fs.String("folder", "", "only audit images under this folder")
+	format := fs.String("format", "csv", "output format: csv or json")
+	largeSize := fs.Int64("large-size", audit.DefaultLargeSize, "size in bytes from which originals are reported; negative to disable")
+	unusedAge := fs.Duration("unused-age", audit.DefaultUnusedAge, "age from which images without a signed URL are reported; negative to disable")
+	concurrency := fs.Int("concurrency", 4, "number of parallel requests")
+	quiet := fs.Bool("quiet", false, "do not print failures as they happen")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 || (*format != "csv" && *format != "json") {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	opts := &audit.Options{
+		Folder:      *folder,
+		LargeSize:   *largeSize,
+		UnusedAge:   *unusedAge,
+		Concurrency: *concurrency,
+	}
+	if !*quiet {
+		opts.OnImage = func(img audit.Image, err error) {
+			if err != nil {
+				fmt.Fprintf(stderr, "failed %s: %v\n", img.ID, err)
+			}
+		}
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	report, err := audit.Run(ctx, client, opts)
+	if err != nil {
+		return err
+	}
+	if *format == "json" {
+		err = report.WriteJSON(stdout)
+	} else {
+		err = report.WriteCSV(stdout)
+	}
+	if err != nil {
+		return err
+	}
+	return report.Err()
+}
@@ -0,0 +1,179 @@
+// Command imgsrc-backup exports an img-src account to a local directory or
+// archive and restores such an export into an account.
+//
+// Usage:
+//
+//	imgsrc-backup export [flags] DEST
+//	imgsrc-backup restore [flags] SRC
+//
+// DEST and SRC are directories, or archives when they end in .tar, .tar.gz,
+// .tgz or .zip. The API key is read from IMGSRC_API_KEY; IMGSRC_SERVER_URL
+// overrides the API server.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/backup"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "usage: imgsrc-backup export|restore [flags] PATH")
+		return 2
+	}
+
+	var err error
+	switch args[0] {
+	case "export":
+		err = runExport(ctx, args[1:], stdout, stderr)
+	case "restore":
+		err = runRestore(ctx, args[1:], stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n", args[0])
+		return 2
+	}
+
+	if errors.Is(err, flag.ErrHelp) {
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return 1
+	}
+	return 0
+}
+
+func newClient() (*sdkgo.Imgsrc, error) {
+	apiKey := os.Getenv("IMGSRC_API_KEY")
+	if apiKey == "" {
+		return nil, errors.New("IMGSRC_API_KEY is not set")
+	}
+
+	opts := []sdkgo.SDKOption{sdkgo.WithSecurity(apiKey)}
+	if serverURL := os.Getenv("IMGSRC_SERVER_URL"); serverURL != "" {
+		opts = append(opts, sdkgo.WithServerURL(serverURL))
+	}
+	return sdkgo.New(opts...), nil
+}
+
+func runExport(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	folder := fs.String("folder", "", "only export images under this folder")
+	since := fs.String("since", "", "only export images uploaded after this RFC 3339 time")
+	incremental := fs.String("incremental-from", "", "only export images uploaded after the newest image of this previous export, adding to it if it is the destination directory")
+	concurrency := fs.Int("concurrency", 4, "number of parallel downloads")
+	quiet := fs.Bool("quiet", false, "do not print progress")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	opts := &backup.ExportOptions{Folder: *folder, Concurrency: *concurrency}
+	switch {
+	case *since != "" && *incremental != "":
+		return errors.New("-since and -incremental-from are mutually exclusive")
+	case *since != "":
+		t, err := time.Parse(time.RFC3339, *since)
+		if err != nil {
+			return fmt.Errorf("invalid -since: %w", err)
+		}
+		opts.Since = t
+	case *incremental != "":
+		prev, err := backup.ReadManifest(*incremental)
+		if err != nil {
+			return err
+		}
+		opts.Since = prev.LatestUpload()
+	}
+	if !*quiet {
+		opts.OnImage = func(img backup.Image, err error) {
+			if err != nil {
+				fmt.Fprintf(stderr, "failed %s: %v\n", img.ID, err)
+				return
+			}
+			fmt.Fprintf(stdout, "exported %s\n", img.ID)
+		}
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	report, err := backup.Export(ctx, client, fs.Arg(0), opts)
+	if report != nil {
+		fmt.Fprintf(stdout, "%d images (%d downloaded, %d reused, %d failed), %d presets\n",
+			len(report.Manifest.Images), report.Downloaded, report.Reused, len(report.Failed), len(report.Manifest.Presets))
+	}
+	return err
+}
+
+func runRestore(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	conflict := fs.String("conflict", string(backup.ConflictSkip), "what to do with taken paths and preset names: skip, overwrite or rename")
+	mapping := fs.String("mapping", "id-map.json", "file receiving the old to new image ID mapping; empty to disable")
+	concurrency := fs.Int("concurrency", 4, "number of parallel uploads")
+	skipPresets := fs.Bool("skip-presets", false, "do not restore presets")
+	skipSettings := fs.Bool("skip-settings", false, "do not restore settings")
+	quiet := fs.Bool("quiet", false, "do not print progress")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	opts := &backup.RestoreOptions{
+		Conflict:     backup.ConflictPolicy(*conflict),
+		Concurrency:  *concurrency,
+		SkipPresets:  *skipPresets,
+		SkipSettings: *skipSettings,
+		MappingFile:  *mapping,
+	}
+	if !*quiet {
+		opts.OnImage = func(res backup.ImageResult) {
+			switch {
+			case res.Err != nil:
+				fmt.Fprintf(stderr, "failed %s: %v\n", res.OldID, res.Err)
+			case res.NewID == "":
+				fmt.Fprintf(stdout, "skipped %s\n", res.OldID)
+			default:
+				fmt.Fprintf(stdout, "restored %s as %s\n", res.OldID, res.NewID)
+			}
+		}
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	report, err := backup.Restore(ctx, client, fs.Arg(0), opts)
+	if report != nil {
+		fmt.Fprintf(stdout, "%d images (%d failed), presets: %d created, %d updated, %d skipped\n",
+			len(report.Images), report.Failed, report.PresetsCreated, report.PresetsUpdated, report.PresetsSkipped)
+	}
+	return err
+}
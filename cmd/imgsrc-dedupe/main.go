@@ -0,0 +1,139 @@
+// Command imgsrc-dedupe reports near-duplicate images of an img-src account,
+// such as resized or re-encoded copies of the same photo, and optionally
+// removes all but one image of every cluster.
+//
+// Usage:
+//
+//	imgsrc-dedupe [flags]
+//
+// The API key is read from IMGSRC_API_KEY; IMGSRC_SERVER_URL overrides the
+// API server.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/dedupe"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	err := runDedupe(ctx, args, stdout, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return 1
+	}
+	return 0
+}
+
+func newClient() (*sdkgo.Imgsrc, error) {
+	apiKey := os.Getenv("IMGSRC_API_KEY")
+	if apiKey == "" {
+		return nil, errors.New("IMGSRC_API_KEY is not set")
+	}
+
+	opts := []sdkgo.SDKOption{sdkgo.WithSecurity(apiKey)}
+	if serverURL := os.Getenv("IMGSRC_SERVER_URL"); serverURL != "" {
+		opts = append(opts, sdkgo.WithServerURL(serverURL))
+	}
+	return sdkgo.New(opts...), nil
+}
+
+func runDedupe(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("imgsrc-dedupe", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	folder := fs.String("folder", "", "only compare images under this folder")
+	algorithm := fs.String("algorithm", string(dedupe.AlgorithmPHash), "perceptual hash: phash or dhash")
+	threshold := fs.Int("threshold", dedupe.DefaultThreshold, "largest Hamming distance, out of 64 bits, between duplicates")
+	concurrency := fs.Int("concurrency", 4, "number of parallel requests")
+	jsonOut := fs.Bool("json", false, "print the report as JSON")
+	consolidate := fs.Bool("consolidate", false, "remove every image but the first of each cluster")
+	keepPaths := fs.Bool("keep-paths", false, "with -consolidate, add the paths of removed images to the kept image")
+	dryRun := fs.Bool("dry-run", false, "with -consolidate, only print what would be removed")
+	quiet := fs.Bool("quiet", false, "do not print progress")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	opts := &dedupe.Options{
+		Folder:      *folder,
+		Algorithm:   dedupe.Algorithm(*algorithm),
+		Threshold:   *threshold,
+		Concurrency: *concurrency,
+	}
+	if !*quiet {
+		opts.OnImage = func(img dedupe.Image, err error) {
+			if err != nil {
+				fmt.Fprintf(stderr, "failed %s: %v\n", img.ID, err)
+			}
+		}
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	report, err := dedupe.Find(ctx, client, opts)
+	if err != nil {
+		return err
+	}
+	if *jsonOut {
+		err = report.WriteJSON(stdout)
+	} else {
+		err = report.WriteText(stdout)
+	}
+	if err != nil || !*consolidate {
+		return err
+	}
+
+	res, err := dedupe.Consolidate(ctx, client, report.Clusters, &dedupe.ConsolidateOptions{
+		DryRun:      *dryRun,
+		KeepPaths:   *keepPaths,
+		Concurrency: *concurrency,
+	})
+	if err != nil {
+		return err
+	}
+	// Keep the JSON report alone on stdout.
+	out := stdout
+	if *jsonOut {
+		out = stderr
+	}
+	for _, item := range res.Items {
+		switch {
+		case item.Err != nil && item.RestoredID != "":
+			fmt.Fprintf(stderr, "failed %s, restored as %s: %v\n", item.ImageID, item.RestoredID, item.Err)
+		case item.Err != nil:
+			fmt.Fprintf(stderr, "failed %s: %v\n", item.ImageID, item.Err)
+		case *quiet:
+		case *dryRun:
+			fmt.Fprintf(out, "would remove %s in favour of %s\n", item.ImageID, item.KeptID)
+		default:
+			fmt.Fprintf(out, "removed %s in favour of %s\n", item.ImageID, item.KeptID)
+		}
+	}
+	fmt.Fprintf(out, "%d images deleted, %d paths removed, %d moved, %d failed\n",
+		res.ImagesDeleted, res.PathsRemoved, res.PathsMoved, res.Failed)
+	return res.Err()
+}
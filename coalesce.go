@@ -0,0 +1,12 @@
+package sdkgo
+
+// WithRequestCoalescing makes concurrent identical GET operations, such as
+// Images.GetMetadata for the same ID, share a single in-flight request. The
+// key is the operation ID plus the request URL and credentials. Each caller
+// decodes its own copy of the response body, so mutating a returned struct
+// never affects the other callers.
+func WithRequestCoalescing() SDKOption {
+	return func(sdk *Imgsrc) {
+		sdk.hooks.EnableRequestCoalescing()
+	}
+}
@@ -0,0 +1,40 @@
+package sdkgo
+
+import (
+	"context"
+
+	"github.com/img-src-io/sdk-go/internal/hooks"
+)
+
+// ContentTypeMismatchError is returned by Images.Upload under
+// WithStrictContentType, and passed to the WithContentTypeWarning callback,
+// when an uploaded file's contents contradict the type set by
+// WithUploadContentType or its extension, e.g. a "photo.jpg" that is really a
+// PNG.
+type ContentTypeMismatchError = hooks.ContentTypeMismatchError
+
+// WithUploadContentType returns a context overriding the Content-Type of the
+// file uploaded by Images.Upload with it, which is otherwise detected from
+// its contents or extension. The generated operations.File has no field for
+// it; UploadImageOptions and UploadFromURLOptions carry it as ContentType.
+func WithUploadContentType(ctx context.Context, contentType string) context.Context {
+	return hooks.WithFileContentType(ctx, contentType)
+}
+
+// WithContentTypeWarning calls warn for every uploaded file whose contents
+// contradict its declared type. The file is still uploaded, with the
+// detected type unless WithUploadContentType overrides it.
+func WithContentTypeWarning(warn func(err *ContentTypeMismatchError)) SDKOption {
+	return func(sdk *Imgsrc) {
+		sdk.hooks.ConfigureContentTypeWarning(warn)
+	}
+}
+
+// WithStrictContentType makes Images.Upload fail with a
+// *ContentTypeMismatchError, before sending anything, for files whose
+// contents contradict their declared type.
+func WithStrictContentType() SDKOption {
+	return func(sdk *Imgsrc) {
+		sdk.hooks.ConfigureStrictContentType()
+	}
+}
@@ -0,0 +1,55 @@
+package sdkgo_test
+
+import (
+	"context"
+	"image"
+	"testing"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/internal/fakeapi"
+	"github.com/img-src-io/sdk-go/models/operations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentTypeMismatch(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	body := func() *operations.UploadImageRequestBody {
+		return &operations.UploadImageRequestBody{File: &operations.File{FileName: "photo.jpg", Content: encodePNG(t, 4, 4)}}
+	}
+
+	var warnings []string
+	_, err := f.SDK(sdkgo.WithContentTypeWarning(func(err *sdkgo.ContentTypeMismatchError) {
+		warnings = append(warnings, err.Error())
+	})).Images.Upload(context.Background(), body())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"photo.jpg is declared as image/jpeg but contains image/png"}, warnings)
+	assert.Equal(t, 1, f.Len())
+
+	_, err = f.SDK(sdkgo.WithStrictContentType()).Images.Upload(sdkgo.WithUploadContentType(context.Background(), "image/png"), body())
+	require.NoError(t, err)
+	assert.Equal(t, 1, f.Len())
+
+	_, err = f.SDK(sdkgo.WithStrictContentType()).Images.Upload(context.Background(), body())
+	var mismatch *sdkgo.ContentTypeMismatchError
+	require.ErrorAs(t, err, &mismatch)
+	assert.Equal(t, "image/png", mismatch.Detected)
+	assert.Equal(t, 1, f.Len())
+}
+
+func TestUploadImage_ContentTypeOverride(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	images := f.SDK(sdkgo.WithStrictContentType()).Images
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+
+	_, err := images.UploadImage(context.Background(), img, sdkgo.ImageEncodingPNG, &sdkgo.UploadImageOptions{FileName: "chart.jpg"})
+	var mismatch *sdkgo.ContentTypeMismatchError
+	require.ErrorAs(t, err, &mismatch)
+	assert.Equal(t, "image/jpeg", mismatch.Declared)
+
+	_, err = images.UploadImage(context.Background(), img, sdkgo.ImageEncodingPNG, &sdkgo.UploadImageOptions{FileName: "chart.jpg", ContentType: "image/png"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, f.Len())
+}
@@ -0,0 +1,222 @@
+package dedupe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/internal/hooks"
+	"github.com/img-src-io/sdk-go/internal/workpool"
+	"github.com/img-src-io/sdk-go/models/operations"
+)
+
+// ConsolidateOptions configures Consolidate.
+type ConsolidateOptions struct {
+	// DryRun reports what would be removed without removing anything.
+	DryRun bool
+	// KeepPaths adds the paths of removed images to the kept image, so that
+	// their URLs keep working. A path is removed before it can be added; if
+	// adding fails, the path is restored by re-uploading the original of the
+	// removed image, downloaded beforehand, the remaining paths are left in
+	// place and the error is recorded on the item. Restoring the last path
+	// recreates the image under a new ID, recorded as RestoredID.
+	KeepPaths bool
+	// Concurrency is the number of clusters processed in parallel. Defaults
+	// to 4.
+	Concurrency int
+	// Username owning the paths. Defaults to the username returned by
+	// Settings.Get.
+	Username string
+}
+
+// ConsolidateItem is the outcome for a single removed image.
+type ConsolidateItem struct {
+	ImageID string `json:"image_id"`
+	// KeptID is the image of the cluster that is kept.
+	KeptID string `json:"kept_id"`
+	// RemovedPaths are the paths that were (or, for dry runs, would be)
+	// removed from the image.
+	RemovedPaths []string `json:"removed_paths"`
+	// MovedPaths are the removed paths added to the kept image.
+	MovedPaths []string `json:"moved_paths,omitempty"`
+	// ImageDeleted reports whether the image is (or would be) gone, which
+	// happens once its last path is removed.
+	ImageDeleted bool `json:"image_deleted"`
+	// RestoredID is the image recreated to restore a path that could not be
+	// moved after removing it had deleted the image.
+	RestoredID string `json:"restored_id,omitempty"`
+	Err        error  `json:"-"`
+}
+
+// ConsolidateReport summarizes a Consolidate run.
+type ConsolidateReport struct {
+	DryRun bool              `json:"dry_run"`
+	Items  []ConsolidateItem `json:"items"`
+	// ImagesDeleted, PathsRemoved and PathsMoved count what was done, or
+	// what would be done for dry runs.
+	ImagesDeleted int `json:"images_deleted"`
+	PathsRemoved  int `json:"paths_removed"`
+	PathsMoved    int `json:"paths_moved"`
+	Failed        int `json:"failed"`
+}
+
+// Err returns the errors of every failed item joined together, or nil.
+func (r *ConsolidateReport) Err() error {
+	if r == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, item := range r.Items {
+		if item.Err != nil {
+			errs = append(errs, fmt.Errorf("image %s: %w", item.ImageID, item.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Consolidate keeps the first image of every cluster and removes the others
+// by deleting their paths with DeletePath, which deletes an image once it
+// has no path left. Failures of individual images are recorded in the report
+// rather than aborting the run; the other images of a cluster are still
+// processed.
+func Consolidate(ctx context.Context, client *sdkgo.Imgsrc, clusters []Cluster, opts *ConsolidateOptions) (*ConsolidateReport, error) {
+	if opts == nil {
+		opts = &ConsolidateOptions{}
+	}
+
+	username := opts.Username
+	if username == "" && !opts.DryRun {
+		res, err := client.Settings.Get(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving username: %w", err)
+		}
+		username = res.GetSettingsResponse().GetSettings().Username
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	items := make([][]ConsolidateItem, len(clusters))
+	workpool.ForEach(ctx, len(clusters), concurrency, func(ctx context.Context, i int) {
+		c := clusters[i]
+		if len(c.Images) < 2 {
+			return
+		}
+		kept := c.Images[0]
+		for _, img := range c.Images[1:] {
+			item := ConsolidateItem{ImageID: img.ID, KeptID: kept.ID}
+			if opts.DryRun {
+				for _, p := range img.Paths {
+					item.RemovedPaths = append(item.RemovedPaths, strings.Trim(p, "/"))
+				}
+				if opts.KeepPaths {
+					item.MovedPaths = item.RemovedPaths
+				}
+				item.ImageDeleted = true
+			} else {
+				consolidateImage(ctx, client, username, kept, img, opts.KeepPaths, &item)
+			}
+			items[i] = append(items[i], item)
+		}
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	report := &ConsolidateReport{DryRun: opts.DryRun, Items: []ConsolidateItem{}}
+	for _, cluster := range items {
+		for _, item := range cluster {
+			report.Items = append(report.Items, item)
+			report.PathsRemoved += len(item.RemovedPaths)
+			report.PathsMoved += len(item.MovedPaths)
+			if item.ImageDeleted {
+				report.ImagesDeleted++
+			}
+			if item.Err != nil {
+				report.Failed++
+			}
+		}
+	}
+	return report, nil
+}
+
+// consolidateImage removes the paths of img one by one, adding each to kept
+// if keepPaths is set, and records the outcome in item.
+func consolidateImage(ctx context.Context, client *sdkgo.Imgsrc, username string, kept, img Image, keepPaths bool, item *ConsolidateItem) {
+	// Removing the last path deletes img, so keep what is needed to restore a
+	// path that cannot be moved.
+	var original *operations.UploadImageRequestBody
+	if keepPaths {
+		var err error
+		if original, err = restoreBody(ctx, client, img.ID); err != nil {
+			item.Err = err
+			return
+		}
+	}
+
+	for _, p := range img.Paths {
+		p = strings.Trim(p, "/")
+		res, err := client.Images.DeletePath(ctx, username, p)
+		if err != nil {
+			item.Err = fmt.Errorf("error removing path %s: %w", p, err)
+			return
+		}
+		item.RemovedPaths = append(item.RemovedPaths, p)
+		if out := res.GetPathDeleteResponse(); out != nil && out.ImageDeleted {
+			item.ImageDeleted = true
+		}
+
+		if keepPaths {
+			if _, err := client.Images.AddPath(ctx, kept.ID, p); err != nil {
+				item.Err = fmt.Errorf("error adding path %s to image %s: %w", p, kept.ID, err)
+				original.File.FileName = path.Base(p)
+				original.TargetPath = sdkgo.String(p)
+				res, rbErr := client.Images.Upload(hooks.WithExactUpload(ctx), original)
+				if rbErr != nil {
+					item.Err = fmt.Errorf("%w; restoring the path failed: %w", item.Err, rbErr)
+					return
+				}
+				if out := res.GetUploadResponse(); out != nil && out.ID != img.ID {
+					item.RestoredID = out.ID
+					return
+				}
+				item.RemovedPaths = item.RemovedPaths[:len(item.RemovedPaths)-1]
+				item.ImageDeleted = false
+				return
+			}
+			item.MovedPaths = append(item.MovedPaths, p)
+		}
+	}
+}
+
+// restoreBody downloads the original of the image id and returns an upload
+// body recreating it, to which the caller adds the path.
+func restoreBody(ctx context.Context, client *sdkgo.Imgsrc, id string) (*operations.UploadImageRequestBody, error) {
+	res, err := client.Images.GetMetadata(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting image: %w", err)
+	}
+	meta := res.GetMetadataResponse()
+
+	r, err := client.Images.OpenOriginal(ctx, meta)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading original: %w", err)
+	}
+
+	return &operations.UploadImageRequestBody{
+		File:       &operations.File{Content: data},
+		Visibility: &meta.Visibility,
+	}, nil
+}
@@ -0,0 +1,328 @@
+// Package dedupe finds near-duplicate images, such as resized or re-encoded
+// copies of the same photo, which the server stores separately because it
+// only deduplicates identical bytes. Images are compared by the perceptual
+// hash of a small variant fetched from the CDN.
+package dedupe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/imgproc"
+	"github.com/img-src-io/sdk-go/internal/workpool"
+	"github.com/img-src-io/sdk-go/models/components"
+)
+
+const (
+	// DefaultThreshold is used when Options.Threshold is zero.
+	DefaultThreshold = 8
+	// DefaultVariantSize is used when Options.VariantSize is zero.
+	DefaultVariantSize = 64
+)
+
+// Algorithm is the perceptual hash images are compared by.
+type Algorithm string
+
+const (
+	AlgorithmDHash Algorithm = "dhash"
+	AlgorithmPHash Algorithm = "phash"
+)
+
+func (a Algorithm) hashFunc() (func(image.Image) Hash, error) {
+	switch a {
+	case AlgorithmDHash:
+		return DHash, nil
+	case AlgorithmPHash:
+		return PHash, nil
+	}
+	return nil, fmt.Errorf("unknown algorithm %q", a)
+}
+
+// Options configures Find.
+type Options struct {
+	// Folder limits the search to images under it. Defaults to the whole
+	// library.
+	Folder string
+	// Algorithm defaults to AlgorithmPHash.
+	Algorithm Algorithm
+	// Threshold is the largest Hamming distance between the hashes of images
+	// considered duplicates, out of 64 bits. Defaults to DefaultThreshold; a
+	// negative value only groups identical hashes.
+	Threshold int
+	// VariantSize bounds the width and height of the variants hashed.
+	// Defaults to DefaultVariantSize.
+	VariantSize int64
+	// Concurrency is the number of images fetched in parallel. Defaults to 4.
+	Concurrency int
+	// OnImage, if set, is called after every image with its outcome. It may
+	// be called from several goroutines at once.
+	OnImage func(img Image, err error)
+}
+
+// Image is an image of the library with its perceptual hash.
+type Image struct {
+	ID         string                `json:"id"`
+	Paths      []string              `json:"paths"`
+	Visibility components.Visibility `json:"visibility"`
+	Width      int64                 `json:"width,omitempty"`
+	Height     int64                 `json:"height,omitempty"`
+	Size       int64                 `json:"size"`
+	UploadedAt time.Time             `json:"uploaded_at"`
+	Hash       Hash                  `json:"hash"`
+	// Distance is the Hamming distance to the hash of the first image of the
+	// cluster.
+	Distance int `json:"distance"`
+}
+
+// Cluster is a group of near-duplicates. The first image is the one to keep:
+// the one with the most pixels, then the largest file, then the oldest.
+type Cluster struct {
+	Images []Image `json:"images"`
+}
+
+// ImageError is the failure of a single image.
+type ImageError struct {
+	ImageID string
+	Err     error
+}
+
+func (e ImageError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"image_id": e.ImageID, "error": e.Err.Error()})
+}
+
+// Report is the result of Find.
+type Report struct {
+	Algorithm Algorithm `json:"algorithm"`
+	Threshold int       `json:"threshold"`
+	// Scanned is the number of images hashed.
+	Scanned  int          `json:"scanned"`
+	Clusters []Cluster    `json:"clusters"`
+	Failed   []ImageError `json:"failed,omitempty"`
+}
+
+// Err returns the errors of every failed image joined together, or nil.
+func (r *Report) Err() error {
+	if r == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, f := range r.Failed {
+		errs = append(errs, fmt.Errorf("image %s: %w", f.ImageID, f.Err))
+	}
+	return errors.Join(errs...)
+}
+
+// Duplicates returns the number of images that are not the first of their
+// cluster.
+func (r *Report) Duplicates() int {
+	var n int
+	for _, c := range r.Clusters {
+		n += len(c.Images) - 1
+	}
+	return n
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteText writes the report as a table per cluster.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%d images scanned, %d clusters, %d duplicates (%s, threshold %d)\n",
+		r.Scanned, len(r.Clusters), r.Duplicates(), r.Algorithm, r.Threshold)
+	for i, c := range r.Clusters {
+		fmt.Fprintf(tw, "\ncluster %d\n", i+1)
+		fmt.Fprintln(tw, "\tID\tDISTANCE\tSIZE\tDIMENSIONS\tPATHS")
+		for j, img := range c.Images {
+			mark := "keep"
+			if j > 0 {
+				mark = "dup"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%dx%d\t%s\n", mark, img.ID, img.Distance, img.Size, img.Width, img.Height, strings.Join(img.Paths, ", "))
+		}
+	}
+	for _, f := range r.Failed {
+		fmt.Fprintf(tw, "failed %s: %v\n", f.ImageID, f.Err)
+	}
+	return tw.Flush()
+}
+
+// Find walks the library, hashes a small variant of every image and groups
+// the images whose hashes are within the threshold of each other. Failures
+// of individual images are recorded in the report rather than aborting the
+// run.
+func Find(ctx context.Context, client *sdkgo.Imgsrc, opts *Options) (*Report, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	algorithm := opts.Algorithm
+	if algorithm == "" {
+		algorithm = AlgorithmPHash
+	}
+	hash, err := algorithm.hashFunc()
+	if err != nil {
+		return nil, err
+	}
+	threshold := opts.Threshold
+	switch {
+	case threshold == 0:
+		threshold = DefaultThreshold
+	case threshold < 0:
+		threshold = 0
+	}
+	size := opts.VariantSize
+	if size <= 0 {
+		size = DefaultVariantSize
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	var items []components.ImageListItem
+	err = client.Images.Walk(ctx, opts.Folder, func(img components.ImageListItem) error {
+		items = append(items, img)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing images: %w", err)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+
+	images := make([]Image, len(items))
+	errs := make([]error, len(items))
+	workpool.ForEach(ctx, len(items), concurrency, func(ctx context.Context, i int) {
+		images[i], errs[i] = hashImage(ctx, client, items[i], hash, size)
+		if opts.OnImage != nil {
+			opts.OnImage(images[i], errs[i])
+		}
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	report := &Report{Algorithm: algorithm, Threshold: threshold, Clusters: []Cluster{}}
+	var hashed []Image
+	for i, item := range items {
+		if errs[i] != nil {
+			report.Failed = append(report.Failed, ImageError{ImageID: item.ID, Err: errs[i]})
+			continue
+		}
+		hashed = append(hashed, images[i])
+	}
+	report.Scanned = len(hashed)
+	report.Clusters = cluster(hashed, threshold)
+	return report, nil
+}
+
+func hashImage(ctx context.Context, client *sdkgo.Imgsrc, item components.ImageListItem, hash func(image.Image) Hash, size int64) (Image, error) {
+	img := Image{ID: item.ID, Paths: item.Paths, Visibility: item.Visibility, Size: item.Size, UploadedAt: item.UploadedAt}
+
+	res, err := client.Images.GetMetadata(ctx, item.ID)
+	if err != nil {
+		return img, fmt.Errorf("error getting image: %w", err)
+	}
+	meta := res.GetMetadataResponse()
+	if meta == nil {
+		return img, errors.New("empty metadata response")
+	}
+	if w := meta.Metadata.Width; w != nil {
+		img.Width = *w
+	}
+	if h := meta.Metadata.Height; h != nil {
+		img.Height = *h
+	}
+
+	body, err := client.Images.OpenVariant(ctx, meta, components.Transformation{
+		Width:  &size,
+		Height: &size,
+		Fit:    components.FitContain.ToPointer(),
+		Format: components.FormatPng.ToPointer(),
+	})
+	if err != nil {
+		return img, err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return img, fmt.Errorf("error downloading variant: %w", err)
+	}
+
+	decoded, err := imgproc.Decode(data)
+	if err != nil {
+		return img, fmt.Errorf("error decoding variant: %w", err)
+	}
+	img.Hash = hash(decoded)
+	return img, nil
+}
+
+// cluster groups images linked by hashes within threshold of each other.
+// Clusters are sorted by their first image, which is the one to keep.
+func cluster(images []Image, threshold int) []Cluster {
+	parent := make([]int, len(images))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range images {
+		for j := i + 1; j < len(images); j++ {
+			if images[i].Hash.Distance(images[j].Hash) <= threshold {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	groups := map[int][]Image{}
+	for i, img := range images {
+		root := find(i)
+		groups[root] = append(groups[root], img)
+	}
+
+	clusters := []Cluster{}
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool { return keepFirst(group[i], group[j]) })
+		for i := range group {
+			group[i].Distance = group[0].Hash.Distance(group[i].Hash)
+		}
+		clusters = append(clusters, Cluster{Images: group})
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Images[0].ID < clusters[j].Images[0].ID })
+	return clusters
+}
+
+// keepFirst reports whether a is a better copy to keep than b.
+func keepFirst(a, b Image) bool {
+	if pa, pb := a.Width*a.Height, b.Width*b.Height; pa != pb {
+		return pa > pb
+	}
+	if a.Size != b.Size {
+		return a.Size > b.Size
+	}
+	if !a.UploadedAt.Equal(b.UploadedAt) {
+		return a.UploadedAt.Before(b.UploadedAt)
+	}
+	return a.ID < b.ID
+}
@@ -0,0 +1,221 @@
+package dedupe_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/img-src-io/sdk-go/dedupe"
+	"github.com/img-src-io/sdk-go/imgproc"
+	"github.com/img-src-io/sdk-go/internal/fakeapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scene returns a w×h image of smooth shapes; different seeds give unrelated
+// scenes.
+func scene(w, h int, seed float64) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			u, v := float64(x)/float64(w), float64(y)/float64(h)
+			r := 127 + 127*math.Sin(seed*3*u+2*v)
+			g := 127 + 127*math.Cos(seed*5*v-u)
+			b := 127 + 127*math.Sin(7*u*v+seed)
+			img.SetNRGBA(x, y, color.NRGBA{uint8(r), uint8(g), uint8(b), 255})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T, img image.Image, quality int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}))
+	return buf.Bytes()
+}
+
+func TestHashes(t *testing.T) {
+	t.Parallel()
+	original := scene(320, 240, 1)
+	copies := []image.Image{
+		imgproc.Thumbnail(original, 100, 0),
+	}
+	reencoded, err := jpeg.Decode(bytes.NewReader(encodeJPEG(t, original, 40)))
+	require.NoError(t, err)
+	copies = append(copies, reencoded)
+	other := scene(320, 240, 4)
+
+	for name, hash := range map[string]func(image.Image) dedupe.Hash{"dhash": dedupe.DHash, "phash": dedupe.PHash} {
+		h := hash(original)
+		for _, c := range copies {
+			assert.LessOrEqual(t, h.Distance(hash(c)), 4, name)
+		}
+		assert.Greater(t, h.Distance(hash(other)), 16, name)
+	}
+
+	var h dedupe.Hash
+	require.NoError(t, h.UnmarshalText([]byte("00ff00ff00ff00ff")))
+	assert.Equal(t, dedupe.Hash(0x00ff00ff00ff00ff), h)
+	assert.Equal(t, "00ff00ff00ff00ff", h.String())
+	assert.Equal(t, 64, h.Distance(^h))
+	assert.Error(t, h.UnmarshalText([]byte("xyz")))
+}
+
+func TestFindAndConsolidate(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	client := f.SDK()
+
+	original := scene(320, 240, 1)
+	large := f.Add(string(encodePNG(t, original)), "photos/a.png")
+	small := f.Add(string(encodePNG(t, imgproc.Thumbnail(original, 120, 0))), "thumbs/a.png", "old/a.png")
+	lossy := f.Add(string(encodeJPEG(t, original, 50)), "photos/a.jpg")
+	unrelated := f.Add(string(encodePNG(t, scene(320, 240, 4))), "photos/b.png")
+	broken := f.Add("not an image", "photos/c.png")
+
+	report, err := dedupe.Find(context.Background(), client, &dedupe.Options{Algorithm: dedupe.AlgorithmDHash})
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Scanned)
+	require.Len(t, report.Failed, 1)
+	assert.Equal(t, broken, report.Failed[0].ImageID)
+	assert.ErrorIs(t, report.Err(), image.ErrFormat)
+
+	require.Len(t, report.Clusters, 1)
+	var ids []string
+	for _, img := range report.Clusters[0].Images {
+		ids = append(ids, img.ID)
+	}
+	// The PNG of the original is the largest file.
+	assert.Equal(t, large, ids[0])
+	assert.ElementsMatch(t, []string{large, small, lossy}, ids)
+	assert.Zero(t, report.Clusters[0].Images[0].Distance)
+	assert.NotContains(t, ids, unrelated)
+	assert.Equal(t, 2, report.Duplicates())
+
+	var text strings.Builder
+	require.NoError(t, report.WriteText(&text))
+	assert.Contains(t, text.String(), "4 images scanned, 1 clusters, 2 duplicates (dhash, threshold 8)")
+	assert.Contains(t, text.String(), "thumbs/a.png, old/a.png")
+	var js strings.Builder
+	require.NoError(t, report.WriteJSON(&js))
+	var decoded struct{ Clusters []dedupe.Cluster }
+	require.NoError(t, json.Unmarshal([]byte(js.String()), &decoded))
+	assert.Equal(t, report.Clusters, decoded.Clusters)
+
+	dry, err := dedupe.Consolidate(context.Background(), client, report.Clusters, &dedupe.ConsolidateOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, dry.ImagesDeleted)
+	assert.Equal(t, 3, dry.PathsRemoved)
+	assert.Equal(t, 5, f.Len())
+
+	res, err := dedupe.Consolidate(context.Background(), client, report.Clusters, &dedupe.ConsolidateOptions{KeepPaths: true})
+	require.NoError(t, err)
+	require.NoError(t, res.Err())
+	assert.Equal(t, 2, res.ImagesDeleted)
+	assert.Equal(t, 3, res.PathsMoved)
+	assert.Nil(t, f.Image(small))
+	assert.Nil(t, f.Image(lossy))
+	assert.ElementsMatch(t, []string{"photos/a.png", "photos/a.jpg", "thumbs/a.png", "old/a.png"}, f.Image(large).Paths)
+	assert.NotNil(t, f.Image(unrelated))
+}
+
+func TestConsolidate_KeepPathsRestoresOnFailure(t *testing.T) {
+	t.Parallel()
+
+	// failUpload fails the nth upload, all of which come from AddPath or
+	// the restore.
+	failUpload := func(f *fakeapi.Server, n int32) {
+		var uploads atomic.Int32
+		f.Fail(func(r *http.Request) int {
+			if r.Method == http.MethodPost && r.URL.Path == "/api/v1/images" && uploads.Add(1) == n {
+				return http.StatusBadRequest
+			}
+			return 0
+		})
+	}
+
+	t.Run("onto the same image", func(t *testing.T) {
+		t.Parallel()
+		f := fakeapi.New(t)
+		kept := f.Add("kept", "kept.png")
+		dup := f.Add("duplicate", "dup/a.png", "dup/b.png")
+		clusters := []dedupe.Cluster{{Images: []dedupe.Image{
+			{ID: kept, Paths: []string{"kept.png"}},
+			{ID: dup, Paths: []string{"/dup/a.png", "dup/b.png"}},
+		}}}
+
+		dry, err := dedupe.Consolidate(context.Background(), f.SDK(), clusters, &dedupe.ConsolidateOptions{DryRun: true, KeepPaths: true})
+		require.NoError(t, err)
+		require.Len(t, dry.Items, 1)
+		assert.Equal(t, []string{"dup/a.png", "dup/b.png"}, dry.Items[0].RemovedPaths)
+		assert.Equal(t, []string{"dup/a.png", "dup/b.png"}, dry.Items[0].MovedPaths)
+
+		// Adding the first path to the kept image fails while the duplicate
+		// still has its second path.
+		failUpload(f, 1)
+		res, err := dedupe.Consolidate(context.Background(), f.SDK(), clusters, &dedupe.ConsolidateOptions{KeepPaths: true})
+		require.NoError(t, err)
+		require.Len(t, res.Items, 1)
+		item := res.Items[0]
+		require.Error(t, item.Err)
+		assert.Contains(t, item.Err.Error(), "error adding path dup/a.png")
+		assert.Empty(t, item.RemovedPaths)
+		assert.Empty(t, item.MovedPaths)
+		assert.False(t, item.ImageDeleted)
+		assert.Empty(t, item.RestoredID)
+		assert.Equal(t, 1, res.Failed)
+
+		assert.Equal(t, []string{"kept.png"}, f.Image(kept).Paths)
+		assert.ElementsMatch(t, []string{"dup/a.png", "dup/b.png"}, f.Image(dup).Paths)
+	})
+
+	t.Run("recreating a deleted image", func(t *testing.T) {
+		t.Parallel()
+		f := fakeapi.New(t)
+		kept := f.Add("kept", "kept.png")
+		dup := f.Add("duplicate", "dup/a.png")
+		clusters := []dedupe.Cluster{{Images: []dedupe.Image{
+			{ID: kept, Paths: []string{"kept.png"}},
+			{ID: dup, Paths: []string{"dup/a.png"}},
+		}}}
+
+		// Removing the only path deletes the duplicate before adding it to
+		// the kept image fails.
+		failUpload(f, 1)
+		res, err := dedupe.Consolidate(context.Background(), f.SDK(), clusters, &dedupe.ConsolidateOptions{KeepPaths: true})
+		require.NoError(t, err)
+		require.Len(t, res.Items, 1)
+		item := res.Items[0]
+		require.Error(t, item.Err)
+		assert.Equal(t, []string{"dup/a.png"}, item.RemovedPaths)
+		assert.Empty(t, item.MovedPaths)
+		assert.True(t, item.ImageDeleted)
+		assert.Equal(t, 1, res.ImagesDeleted)
+
+		assert.Nil(t, f.Image(dup))
+		require.NotEmpty(t, item.RestoredID)
+		assert.NotEqual(t, dup, item.RestoredID)
+		restored := f.Image(item.RestoredID)
+		require.NotNil(t, restored)
+		assert.Equal(t, "duplicate", string(restored.Data))
+		assert.Equal(t, []string{"dup/a.png"}, restored.Paths)
+		assert.Equal(t, []string{"kept.png"}, f.Image(kept).Paths)
+	})
+}
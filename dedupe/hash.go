@@ -0,0 +1,117 @@
+package dedupe
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"slices"
+	"strconv"
+
+	"github.com/img-src-io/sdk-go/imgproc"
+)
+
+// Hash is a 64-bit perceptual hash. Images that look alike have hashes that
+// differ in few bits.
+type Hash uint64
+
+// Distance returns the Hamming distance between h and o, the number of bits
+// they differ in.
+func (h Hash) Distance(o Hash) int {
+	return bits.OnesCount64(uint64(h ^ o))
+}
+
+func (h Hash) String() string {
+	return fmt.Sprintf("%016x", uint64(h))
+}
+
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+func (h *Hash) UnmarshalText(text []byte) error {
+	v, err := strconv.ParseUint(string(text), 16, 64)
+	if err != nil {
+		return fmt.Errorf("invalid hash %q", text)
+	}
+	*h = Hash(v)
+	return nil
+}
+
+// DHash returns the difference hash of img: whether each pixel of a 9×8
+// grayscale thumbnail is brighter than its left neighbour. It is cheap and
+// robust to scaling and re-encoding.
+func DHash(img image.Image) Hash {
+	px := luminance(img, 9, 8)
+	var h Hash
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			h <<= 1
+			if px[y*9+x+1] > px[y*9+x] {
+				h |= 1
+			}
+		}
+	}
+	return h
+}
+
+// PHash returns the DCT hash of img: whether each of the 8×8 lowest
+// frequencies of a 32×32 grayscale thumbnail is above their median. It is
+// more robust than DHash to changes of contrast and to small edits.
+func PHash(img image.Image) Hash {
+	const n, k = 32, 8
+	px := luminance(img, n, n)
+
+	// The DCT-II of the rows, then of the columns, keeping only the k lowest
+	// frequencies.
+	cos := make([]float64, k*n)
+	for u := 0; u < k; u++ {
+		for x := 0; x < n; x++ {
+			cos[u*n+x] = math.Cos(math.Pi * float64(u) * float64(2*x+1) / (2 * n))
+		}
+	}
+	rows := make([]float64, n*k)
+	for y := 0; y < n; y++ {
+		for u := 0; u < k; u++ {
+			var s float64
+			for x := 0; x < n; x++ {
+				s += px[y*n+x] * cos[u*n+x]
+			}
+			rows[y*k+u] = s
+		}
+	}
+	freq := make([]float64, k*k)
+	for v := 0; v < k; v++ {
+		for u := 0; u < k; u++ {
+			var s float64
+			for y := 0; y < n; y++ {
+				s += rows[y*k+u] * cos[v*n+y]
+			}
+			freq[v*k+u] = s
+		}
+	}
+
+	sorted := slices.Clone(freq)
+	slices.Sort(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var h Hash
+	for _, f := range freq {
+		h <<= 1
+		if f > median {
+			h |= 1
+		}
+	}
+	return h
+}
+
+// luminance returns the luma of the pixels of img scaled to w×h.
+func luminance(img image.Image, w, h int) []float64 {
+	small := imgproc.Resize(img, w, h)
+	px := make([]float64, w*h)
+	for i := range px {
+		p := small.Pix[i*4:]
+		px[i] = 0.299*float64(p[0]) + 0.587*float64(p[1]) + 0.114*float64(p[2])
+	}
+	return px
+}
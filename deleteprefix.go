@@ -0,0 +1,229 @@
+package sdkgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/img-src-io/sdk-go/internal/workpool"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/img-src-io/sdk-go/models/operations"
+)
+
+// DefaultMaxDeletions is the number of images DeletePrefix may touch when
+// DeletePrefixOptions.MaxDeletions is zero.
+const DefaultMaxDeletions = 1000
+
+// ErrTooManyDeletions is returned by DeletePrefix when more images match the
+// prefix than DeletePrefixOptions.MaxDeletions allows. Nothing is deleted.
+var ErrTooManyDeletions = errors.New("too many images match the prefix")
+
+// DeletePrefixOptions configures Images.DeletePrefix.
+type DeletePrefixOptions struct {
+	// DryRun reports what would be deleted without deleting anything.
+	DryRun bool
+	// MaxDeletions is the maximum number of images the run may touch.
+	// Defaults to DefaultMaxDeletions; a negative value removes the limit.
+	MaxDeletions int
+	// Concurrency is the number of images processed in parallel. Defaults to 4.
+	Concurrency int
+	// Username owning the paths. Defaults to the username returned by Settings.Get.
+	Username string
+}
+
+// DeletePrefixAction is what DeletePrefix does with a matching image.
+type DeletePrefixAction string
+
+const (
+	// DeletePrefixActionDeleteImage deletes the image because every one of its paths matches the prefix.
+	DeletePrefixActionDeleteImage DeletePrefixAction = "delete_image"
+	// DeletePrefixActionDeletePaths removes the matching paths and keeps the image for its other paths.
+	DeletePrefixActionDeletePaths DeletePrefixAction = "delete_paths"
+)
+
+// DeletePrefixItem is the outcome for a single image.
+type DeletePrefixItem struct {
+	ImageID string
+	Action  DeletePrefixAction
+	// MatchedPaths are the paths under the prefix.
+	MatchedPaths []string
+	// DeletedPaths are the matching paths that were (or, for dry runs, would
+	// be) removed. They differ from MatchedPaths only when the item failed.
+	DeletedPaths []string
+	// RemainingPaths are the paths the image keeps. For dry runs they are the
+	// expected paths, otherwise the ones reported by the API.
+	RemainingPaths []string
+	// ImageDeleted reports whether the image itself is (or would be) gone.
+	ImageDeleted bool
+	Err          error
+}
+
+// DeletePrefixReport summarizes a DeletePrefix run.
+type DeletePrefixReport struct {
+	Prefix string
+	DryRun bool
+	Items  []DeletePrefixItem
+	// ImagesDeleted and PathsDeleted count what was removed, or what would be
+	// removed for dry runs.
+	ImagesDeleted int
+	PathsDeleted  int
+	Failed        int
+}
+
+// Err returns the errors of every failed item joined together, or nil.
+func (r *DeletePrefixReport) Err() error {
+	if r == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, item := range r.Items {
+		if item.Err != nil {
+			errs = append(errs, fmt.Errorf("image %s: %w", item.ImageID, item.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DeletePrefix removes every path under prefix (e.g. "campaigns/2023/").
+// Images whose paths all live under the prefix are deleted; images that still
+// have other paths only lose the matching ones. Failures of individual images
+// are recorded in the report rather than aborting the run.
+func (s *Images) DeletePrefix(ctx context.Context, prefix string, opts *DeletePrefixOptions, reqOpts ...operations.Option) (*DeletePrefixReport, error) {
+	if opts == nil {
+		opts = &DeletePrefixOptions{}
+	}
+
+	folder := strings.Trim(prefix, "/")
+	if folder == "" {
+		return nil, errors.New("prefix must not be empty")
+	}
+
+	report := &DeletePrefixReport{Prefix: folder + "/", DryRun: opts.DryRun}
+
+	err := s.Walk(ctx, folder, func(img components.ImageListItem) error {
+		if item, ok := planDeletePrefix(img, report.Prefix); ok {
+			report.Items = append(report.Items, item)
+		}
+		return nil
+	}, reqOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing images: %w", err)
+	}
+
+	sort.Slice(report.Items, func(i, j int) bool {
+		return report.Items[i].ImageID < report.Items[j].ImageID
+	})
+
+	maxDeletions := opts.MaxDeletions
+	if maxDeletions == 0 {
+		maxDeletions = DefaultMaxDeletions
+	}
+	if maxDeletions > 0 && len(report.Items) > maxDeletions {
+		return report, fmt.Errorf("%w: %d images, limit is %d", ErrTooManyDeletions, len(report.Items), maxDeletions)
+	}
+
+	if !opts.DryRun && len(report.Items) > 0 {
+		username := opts.Username
+		if hasAction(report.Items, DeletePrefixActionDeletePaths) {
+			var err error
+			if username, err = s.resolveUsername(ctx, username, reqOpts...); err != nil {
+				return nil, err
+			}
+		}
+
+		concurrency := opts.Concurrency
+		if concurrency <= 0 {
+			concurrency = 4
+		}
+
+		workpool.ForEach(ctx, len(report.Items), concurrency, func(ctx context.Context, i int) {
+			s.applyDeletePrefix(ctx, username, &report.Items[i], reqOpts...)
+		})
+	}
+
+	for _, item := range report.Items {
+		if item.Err != nil {
+			report.Failed++
+		}
+		if item.ImageDeleted {
+			report.ImagesDeleted++
+		}
+		report.PathsDeleted += len(item.DeletedPaths)
+	}
+
+	return report, ctx.Err()
+}
+
+func hasAction(items []DeletePrefixItem, action DeletePrefixAction) bool {
+	for _, item := range items {
+		if item.Action == action {
+			return true
+		}
+	}
+	return false
+}
+
+// planDeletePrefix decides what to do with an image given the prefix (which
+// always ends with a slash).
+func planDeletePrefix(img components.ImageListItem, prefix string) (DeletePrefixItem, bool) {
+	item := DeletePrefixItem{ImageID: img.ID}
+	for _, p := range img.Paths {
+		if strings.HasPrefix(strings.TrimPrefix(p, "/"), prefix) {
+			item.MatchedPaths = append(item.MatchedPaths, p)
+		} else {
+			item.RemainingPaths = append(item.RemainingPaths, p)
+		}
+	}
+
+	if len(item.MatchedPaths) == 0 {
+		return item, false
+	}
+
+	if len(item.RemainingPaths) == 0 {
+		item.Action = DeletePrefixActionDeleteImage
+		item.ImageDeleted = true
+	} else {
+		item.Action = DeletePrefixActionDeletePaths
+	}
+	item.DeletedPaths = item.MatchedPaths
+
+	return item, true
+}
+
+func (s *Images) applyDeletePrefix(ctx context.Context, username string, item *DeletePrefixItem, opts ...operations.Option) {
+	item.DeletedPaths = nil
+	item.ImageDeleted = false
+
+	if err := ctx.Err(); err != nil {
+		item.Err = err
+		return
+	}
+
+	if item.Action == DeletePrefixActionDeleteImage {
+		if _, err := s.Delete(ctx, item.ImageID, opts...); err != nil {
+			item.Err = err
+			return
+		}
+		item.DeletedPaths = item.MatchedPaths
+		item.RemainingPaths = nil
+		item.ImageDeleted = true
+		return
+	}
+
+	for _, p := range item.MatchedPaths {
+		res, err := s.DeletePath(ctx, username, strings.TrimPrefix(p, "/"), opts...)
+		if err != nil {
+			item.Err = err
+			return
+		}
+
+		item.DeletedPaths = append(item.DeletedPaths, p)
+		if out := res.GetPathDeleteResponse(); out != nil {
+			item.RemainingPaths = out.RemainingPaths
+			item.ImageDeleted = out.ImageDeleted
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package sdkgo_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/internal/fakeapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDeletePrefixLibrary(t *testing.T) (*fakeapi.Server, map[string]string) {
+	t.Helper()
+	f := fakeapi.New(t)
+	ids := map[string]string{
+		"only":   f.Add("a", "campaigns/2023/a.png"),
+		"nested": f.Add("b", "campaigns/2023/q4/b.png"),
+		"shared": f.Add("c", "campaigns/2023/c.png", "keep/c.png"),
+		"other":  f.Add("d", "campaigns/2024/d.png"),
+		"root":   f.Add("e", "e.png"),
+	}
+	return f, ids
+}
+
+func TestImages_DeletePrefix(t *testing.T) {
+	t.Parallel()
+
+	t.Run("dry run", func(t *testing.T) {
+		t.Parallel()
+		f, ids := newDeletePrefixLibrary(t)
+
+		report, err := f.SDK().Images.DeletePrefix(context.Background(), "/campaigns/2023", &sdkgo.DeletePrefixOptions{DryRun: true})
+		require.NoError(t, err)
+		require.NoError(t, report.Err())
+
+		assert.Equal(t, "campaigns/2023/", report.Prefix)
+		assert.Equal(t, 2, report.ImagesDeleted)
+		assert.Equal(t, 3, report.PathsDeleted)
+		require.Len(t, report.Items, 3)
+
+		byID := map[string]sdkgo.DeletePrefixItem{}
+		for _, item := range report.Items {
+			byID[item.ImageID] = item
+		}
+		assert.Equal(t, sdkgo.DeletePrefixActionDeleteImage, byID[ids["only"]].Action)
+		assert.Equal(t, sdkgo.DeletePrefixActionDeleteImage, byID[ids["nested"]].Action)
+		assert.Equal(t, sdkgo.DeletePrefixActionDeletePaths, byID[ids["shared"]].Action)
+		assert.Equal(t, []string{"keep/c.png"}, byID[ids["shared"]].RemainingPaths)
+
+		assert.Equal(t, 5, f.Len())
+		assert.Zero(t, f.Count("DELETE /api/v1/images/{id}"))
+	})
+
+	t.Run("deletes images and paths", func(t *testing.T) {
+		t.Parallel()
+		f, ids := newDeletePrefixLibrary(t)
+
+		report, err := f.SDK().Images.DeletePrefix(context.Background(), "campaigns/2023/", nil)
+		require.NoError(t, err)
+		require.NoError(t, report.Err())
+		assert.Equal(t, 2, report.ImagesDeleted)
+		assert.Equal(t, 3, report.PathsDeleted)
+
+		assert.Nil(t, f.Image(ids["only"]))
+		assert.Nil(t, f.Image(ids["nested"]))
+		require.NotNil(t, f.Image(ids["shared"]))
+		assert.Equal(t, []string{"keep/c.png"}, f.Image(ids["shared"]).Paths)
+		assert.NotNil(t, f.Image(ids["other"]))
+		assert.NotNil(t, f.Image(ids["root"]))
+		assert.Equal(t, 1, f.Count("GET /api/v1/settings"))
+	})
+
+	t.Run("max deletions", func(t *testing.T) {
+		t.Parallel()
+		f, _ := newDeletePrefixLibrary(t)
+
+		report, err := f.SDK().Images.DeletePrefix(context.Background(), "campaigns", &sdkgo.DeletePrefixOptions{MaxDeletions: 3})
+		require.ErrorIs(t, err, sdkgo.ErrTooManyDeletions)
+		require.NotNil(t, report)
+		assert.Len(t, report.Items, 4)
+		assert.Equal(t, 5, f.Len())
+	})
+
+	t.Run("records failures", func(t *testing.T) {
+		t.Parallel()
+		f, ids := newDeletePrefixLibrary(t)
+		f.Fail(func(r *http.Request) int {
+			if r.Method == http.MethodDelete && strings.HasSuffix(r.URL.Path, ids["only"]) {
+				return http.StatusForbidden
+			}
+			return 0
+		})
+
+		report, err := f.SDK().Images.DeletePrefix(context.Background(), "campaigns/2023", &sdkgo.DeletePrefixOptions{Username: "alice", Concurrency: 1})
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Failed)
+		assert.Equal(t, 1, report.ImagesDeleted)
+		assert.Equal(t, 2, report.PathsDeleted)
+		require.Error(t, report.Err())
+		assert.Contains(t, report.Err().Error(), ids["only"])
+		assert.NotNil(t, f.Image(ids["only"]))
+		assert.Zero(t, f.Count("GET /api/v1/settings"))
+	})
+
+	t.Run("empty prefix", func(t *testing.T) {
+		t.Parallel()
+		f, _ := newDeletePrefixLibrary(t)
+
+		_, err := f.SDK().Images.DeletePrefix(context.Background(), "/", nil)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,8 @@
+package sdkgo
+
+import "time"
+
+// SetSignedURLClock replaces the clock of a SignedURLManager.
+func SetSignedURLClock(m *SignedURLManager, now func() time.Time) {
+	m.now = now
+}
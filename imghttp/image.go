@@ -0,0 +1,319 @@
+package imghttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/img-src-io/sdk-go/models/operations"
+)
+
+const (
+	// DefaultMaxDimension is used when ImageHandler.MaxDimension is zero.
+	DefaultMaxDimension = 4096
+	// DefaultLookupTTL is used when ImageHandler.LookupTTL is zero.
+	DefaultLookupTTL = time.Minute
+	// DefaultNotFoundTTL is used when ImageHandler.NotFoundTTL is zero.
+	DefaultNotFoundTTL = 10 * time.Second
+	// DefaultMaxLookups is used when ImageHandler.MaxLookups is zero.
+	DefaultMaxLookups = 10000
+	// DefaultRedirectMaxAge is used when ImageHandler.RedirectMaxAge is zero.
+	DefaultRedirectMaxAge = time.Hour
+)
+
+// ImageHandler serves images by path by redirecting to the CDN, keeping
+// img-src URLs out of the HTML of the site. Mounted at "/img/", a request for
+//
+//	/img/blog/photo.jpg?w=800&h=600&fit=cover&q=75&fmt=webp
+//
+// is redirected to the CDN URL of the image stored at "blog/photo.jpg" with
+// that transformation. Without fmt, the format is picked from the Accept
+// header. Private images are redirected to a signed URL, which is cached.
+//
+// The parameters are validated against the Transformation rules and the size
+// allowlists. Invalid requests are rejected with 400, unknown paths with 404.
+type ImageHandler struct {
+	Client *sdkgo.Imgsrc
+	// Prefix is stripped from the request path, e.g. "/img/".
+	Prefix string
+	// Widths and Heights, if set, are the only sizes that may be requested.
+	Widths  []int64
+	Heights []int64
+	// MaxDimension bounds widths and heights when no allowlist is set.
+	// Defaults to DefaultMaxDimension.
+	MaxDimension int64
+	// Formats are the formats served, in order of preference, as negotiated
+	// by NegotiateFormat. Defaults to AVIF, WebP and JPEG.
+	Formats []components.Format
+	// SignedURLs mints the URLs of private images. Defaults to a manager with
+	// the default configuration.
+	SignedURLs *sdkgo.SignedURLManager
+	// LookupTTL is how long the image stored at a path is remembered.
+	// Defaults to DefaultLookupTTL.
+	LookupTTL time.Duration
+	// NotFoundTTL is how long a path no image is stored at is remembered, so
+	// that requests for missing paths do not each list a folder. Defaults to
+	// DefaultNotFoundTTL.
+	NotFoundTTL time.Duration
+	// MaxLookups bounds the number of paths remembered. Defaults to
+	// DefaultMaxLookups.
+	MaxLookups int
+	// RedirectMaxAge is the max-age of redirects to public images. Defaults to
+	// DefaultRedirectMaxAge.
+	RedirectMaxAge time.Duration
+	// Options are passed to the API calls.
+	Options []operations.Option
+
+	once        sync.Once
+	signer      *sdkgo.SignedURLManager
+	lookupsOnce sync.Once
+	lookups     *lookupCache
+}
+
+var _ http.Handler = (*ImageHandler)(nil)
+
+var defaultFormats = []components.Format{components.FormatAvif, components.FormatWebp, components.FormatJpeg}
+
+func (h *ImageHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, r, &Error{Status: http.StatusMethodNotAllowed, Code: "METHOD_NOT_ALLOWED", Message: "images must be fetched with GET"})
+		return
+	}
+
+	p, err := h.imagePath(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	t, err := h.transformation(r.URL.Query())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if t.Format == nil {
+		w.Header().Add("Vary", "Accept")
+		f := NegotiateFormat(r.Header.Get("Accept"), h.formats())
+		t.Format = &f
+	}
+
+	item, err := h.lookup(r, p)
+	if errors.Is(err, sdkgo.ErrPathNotFound) {
+		writeError(w, r, &Error{Status: http.StatusNotFound, Code: "NOT_FOUND", Message: fmt.Sprintf("no image at %s", p)})
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if item.Visibility == components.VisibilityPrivate {
+		h.once.Do(func() {
+			h.signer = h.SignedURLs
+			if h.signer == nil {
+				h.signer = sdkgo.NewSignedURLManager(h.Client.Images, sdkgo.SignedURLConfig{})
+			}
+		})
+		signed, err := h.signer.Get(r.Context(), item.ID, t, h.Options...)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		// Browsers may reuse the redirect, but never past the signed URL's
+		// safety margin.
+		w.Header().Set("Cache-Control", "private, max-age=60")
+		http.Redirect(w, r, signed.URL, http.StatusFound)
+		return
+	}
+
+	target, err := cdnURL(item, p, t)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	maxAge := h.RedirectMaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultRedirectMaxAge
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int64(maxAge/time.Second)))
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+func (h *ImageHandler) imagePath(r *http.Request) (string, error) {
+	p := strings.TrimPrefix(r.URL.Path, h.Prefix)
+	if p == "" || strings.HasSuffix(p, "/") {
+		return "", &Error{Status: http.StatusNotFound, Code: "NOT_FOUND", Message: "missing image path"}
+	}
+	for _, seg := range strings.Split(p, "/") {
+		if seg == ".." || seg == "." {
+			return "", &Error{Status: http.StatusBadRequest, Code: "VALIDATION_ERROR", Message: "invalid image path"}
+		}
+	}
+	return strings.Trim(p, "/"), nil
+}
+
+func (h *ImageHandler) transformation(q url.Values) (*components.Transformation, error) {
+	t := &components.Transformation{}
+	var err error
+
+	if t.Width, err = h.dimension(q, "w", h.Widths); err != nil {
+		return nil, err
+	}
+	if t.Height, err = h.dimension(q, "h", h.Heights); err != nil {
+		return nil, err
+	}
+	if v := q.Get("q"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 1 || n > 100 {
+			return nil, invalidParam("q", v, "must be between 1 and 100")
+		}
+		t.Quality = &n
+	}
+	if v := q.Get("fit"); v != "" {
+		switch fit := components.Fit(v); fit {
+		case components.FitCover, components.FitContain, components.FitFill, components.FitScaleDown:
+			t.Fit = &fit
+		default:
+			return nil, invalidParam("fit", v, "must be cover, contain, fill or scale-down")
+		}
+	}
+	if v := q.Get("fmt"); v != "" {
+		f := parseFormat(v)
+		if !slices.Contains(h.formats(), f) {
+			return nil, invalidParam("fmt", v, "is not served")
+		}
+		t.Format = &f
+	}
+	return t, nil
+}
+
+func (h *ImageHandler) dimension(q url.Values, name string, allowed []int64) (*int64, error) {
+	v := q.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 1 {
+		return nil, invalidParam(name, v, "must be a positive integer")
+	}
+	if len(allowed) > 0 {
+		if !slices.Contains(allowed, n) {
+			return nil, invalidParam(name, v, fmt.Sprintf("must be one of %v", allowed))
+		}
+		return &n, nil
+	}
+
+	maxDimension := h.MaxDimension
+	if maxDimension <= 0 {
+		maxDimension = DefaultMaxDimension
+	}
+	if n > maxDimension {
+		return nil, invalidParam(name, v, fmt.Sprintf("must not exceed %d", maxDimension))
+	}
+	return &n, nil
+}
+
+func invalidParam(name, value, reason string) *Error {
+	return &Error{Status: http.StatusBadRequest, Code: "VALIDATION_ERROR", Message: fmt.Sprintf("%s=%s %s", name, value, reason)}
+}
+
+func (h *ImageHandler) formats() []components.Format {
+	if len(h.Formats) > 0 {
+		return h.Formats
+	}
+	return defaultFormats
+}
+
+// lookup returns the image stored at p, remembering it for LookupTTL, or
+// that there is none for NotFoundTTL.
+func (h *ImageHandler) lookup(r *http.Request, p string) (*components.ImageListItem, error) {
+	h.lookupsOnce.Do(func() {
+		max := h.MaxLookups
+		if max <= 0 {
+			max = DefaultMaxLookups
+		}
+		h.lookups = newLookupCache(max)
+	})
+
+	now := time.Now()
+	if cached, ok := h.lookups.get(p, now); ok {
+		if cached.item == nil {
+			return nil, fmt.Errorf("%w: %s", sdkgo.ErrPathNotFound, p)
+		}
+		return cached.item, nil
+	}
+
+	item, err := h.Client.Images.FindPath(r.Context(), p, h.Options...)
+	if errors.Is(err, sdkgo.ErrPathNotFound) {
+		ttl := h.NotFoundTTL
+		if ttl <= 0 {
+			ttl = DefaultNotFoundTTL
+		}
+		h.lookups.set(&lookupEntry{path: p, expires: now.Add(ttl)})
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := h.LookupTTL
+	if ttl <= 0 {
+		ttl = DefaultLookupTTL
+	}
+	h.lookups.set(&lookupEntry{path: p, item: item, expires: now.Add(ttl)})
+	return item, nil
+}
+
+// cdnURL returns the CDN URL of the image at path p in the format and with
+// the transformation of t. The host and username are taken from the image's
+// CDN URL, the extension names the format and the remaining parameters are
+// passed as w, h, fit and q.
+func cdnURL(item *components.ImageListItem, p string, t *components.Transformation) (string, error) {
+	if item.CdnURL == nil || *item.CdnURL == "" {
+		return "", fmt.Errorf("image %s has no CDN URL", item.ID)
+	}
+	u, err := url.Parse(*item.CdnURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid CDN URL of image %s: %w", item.ID, err)
+	}
+
+	username, _, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	if t.Format != nil {
+		p = strings.TrimSuffix(p, path.Ext(p)) + formatExt(*t.Format)
+	}
+	u.Path = "/" + username + "/" + p
+	u.RawPath = ""
+
+	q := url.Values{}
+	if t.Width != nil {
+		q.Set("w", strconv.FormatInt(*t.Width, 10))
+	}
+	if t.Height != nil {
+		q.Set("h", strconv.FormatInt(*t.Height, 10))
+	}
+	if t.Fit != nil {
+		q.Set("fit", string(*t.Fit))
+	}
+	if t.Quality != nil {
+		q.Set("q", strconv.FormatInt(*t.Quality, 10))
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func formatExt(f components.Format) string {
+	if f == components.FormatJpeg {
+		return ".jpg"
+	}
+	return "." + string(f)
+}
@@ -0,0 +1,141 @@
+package imghttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/img-src-io/sdk-go/imghttp"
+	"github.com/img-src-io/sdk-go/internal/fakeapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func get(h http.Handler, target, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestImageHandler_Public(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	f.Add("photo", "blog/photo.png", "home/photo.png")
+	h := &imghttp.ImageHandler{Client: f.SDK(), Prefix: "/img/"}
+
+	const chrome = "image/avif,image/webp,image/apng,image/svg+xml,image/*,*/*;q=0.8"
+	tests := []struct {
+		name, target, accept, location string
+	}{
+		{"negotiated", "/img/blog/photo.png?w=800&fit=cover", chrome, "/alice/blog/photo.avif?fit=cover&w=800"},
+		{"second path", "/img/home/photo.png?h=100&q=70", "image/webp,*/*", "/alice/home/photo.webp?h=100&q=70"},
+		{"explicit format", "/img/blog/photo.png?fmt=jpg", chrome, "/alice/blog/photo.jpg"},
+		{"old client", "/img/blog/photo.png", "*/*", "/alice/blog/photo.jpg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rec := get(h, tt.target, tt.accept)
+			require.Equal(t, http.StatusFound, rec.Code, rec.Body.String())
+			assert.Equal(t, fakeapi.CDNBaseURL+tt.location, rec.Header().Get("Location"))
+			assert.Equal(t, "public, max-age=3600", rec.Header().Get("Cache-Control"))
+			assert.Equal(t, !strings.Contains(tt.target, "fmt="), rec.Header().Get("Vary") == "Accept")
+		})
+	}
+}
+
+func TestImageHandler_Validation(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	f.Add("photo", "blog/photo.png")
+	h := &imghttp.ImageHandler{Client: f.SDK(), Prefix: "/img/", Widths: []int64{320, 640}}
+
+	tests := []struct {
+		target string
+		status int
+		msg    string
+	}{
+		{"/img/blog/photo.png?w=500", http.StatusBadRequest, "w=500 must be one of [320 640]"},
+		{"/img/blog/photo.png?h=5000", http.StatusBadRequest, "h=5000 must not exceed 4096"},
+		{"/img/blog/photo.png?h=-1", http.StatusBadRequest, "must be a positive integer"},
+		{"/img/blog/photo.png?q=0", http.StatusBadRequest, "q=0 must be between 1 and 100"},
+		{"/img/blog/photo.png?fit=stretch", http.StatusBadRequest, "fit=stretch"},
+		{"/img/blog/photo.png?fmt=png", http.StatusBadRequest, "fmt=png is not served"},
+		{"/img/blog/../secret.png", http.StatusBadRequest, "invalid image path"},
+		{"/img/blog/missing.png", http.StatusNotFound, "no image at blog/missing.png"},
+		{"/img/", http.StatusNotFound, "missing image path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			t.Parallel()
+			rec := get(h, tt.target, "")
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.msg)
+		})
+	}
+
+	rec := get(h, "/img/blog/photo.png?w=640", "")
+	assert.Equal(t, http.StatusFound, rec.Code)
+
+	req := httptest.NewRequest(http.MethodPost, "/img/blog/photo.png", nil)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestImageHandler_LookupCache(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	f.Add("a", "a.png")
+	f.Add("b", "b.png")
+	h := &imghttp.ImageHandler{Client: f.SDK(), Prefix: "/img/", MaxLookups: 2}
+
+	// Missing paths are remembered too.
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusNotFound, get(h, "/img/missing.png", "").Code)
+	}
+	assert.Equal(t, 1, f.Count("GET /api/v1/images"))
+
+	// The least recently used path is evicted beyond MaxLookups.
+	assert.Equal(t, http.StatusFound, get(h, "/img/a.png", "").Code)
+	assert.Equal(t, http.StatusFound, get(h, "/img/b.png", "").Code)
+	assert.Equal(t, http.StatusFound, get(h, "/img/a.png", "").Code)
+	assert.Equal(t, 3, f.Count("GET /api/v1/images"))
+	assert.Equal(t, http.StatusNotFound, get(h, "/img/missing.png", "").Code)
+	assert.Equal(t, 4, f.Count("GET /api/v1/images"))
+	assert.Equal(t, http.StatusFound, get(h, "/img/a.png", "").Code)
+	assert.Equal(t, 4, f.Count("GET /api/v1/images"))
+}
+
+func TestImageHandler_Private(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	id := f.Add("secret", "private/doc.png")
+	f.Update(id, func(img *fakeapi.Image) { img.Visibility = "private" })
+	h := &imghttp.ImageHandler{Client: f.SDK(), Prefix: "/img/"}
+
+	rec := get(h, "/img/private/doc.png?w=320", "image/webp")
+	require.Equal(t, http.StatusFound, rec.Code, rec.Body.String())
+	location := rec.Header().Get("Location")
+	assert.True(t, strings.HasPrefix(location, f.URL+"/cdn/"+id+"?"), location)
+	assert.Contains(t, location, "format=webp")
+	assert.Contains(t, location, "width=320")
+	assert.Equal(t, "private, max-age=60", rec.Header().Get("Cache-Control"))
+
+	// The signed URL and the path lookup are cached.
+	rec = get(h, "/img/private/doc.png?w=320", "image/webp")
+	assert.Equal(t, location, rec.Header().Get("Location"))
+	assert.Equal(t, 1, f.Count("POST /api/v1/images/{id}/signed-url"))
+	assert.Equal(t, 1, f.Count("GET /api/v1/images"))
+
+	// The signed URL is served by the CDN.
+	res, err := http.Get(location)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
@@ -0,0 +1,66 @@
+package imghttp
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/img-src-io/sdk-go/models/components"
+)
+
+// lookupCache remembers the image stored at a path, or that none is, for a
+// bounded number of paths. The least recently used path is evicted first, so
+// expired entries that are never requested again age out without scans.
+type lookupCache struct {
+	mu      sync.Mutex
+	max     int
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type lookupEntry struct {
+	path string
+	// item is nil for paths no image is stored at.
+	item    *components.ImageListItem
+	expires time.Time
+}
+
+func newLookupCache(max int) *lookupCache {
+	return &lookupCache{max: max, entries: map[string]*list.Element{}, order: list.New()}
+}
+
+// get returns the entry for p unless it is missing or expired.
+func (c *lookupCache) get(p string, now time.Time) (*lookupEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[p]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*lookupEntry)
+	if !now.Before(entry.expires) {
+		c.order.Remove(el)
+		delete(c.entries, p)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return entry, true
+}
+
+func (c *lookupCache) set(entry *lookupEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[entry.path]; ok {
+		el.Value = entry
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[entry.path] = c.order.PushFront(entry)
+	for c.order.Len() > c.max {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lookupEntry).path)
+	}
+}
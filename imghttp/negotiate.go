@@ -0,0 +1,208 @@
+package imghttp
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/img-src-io/sdk-go/models/components"
+)
+
+// DefaultFormatPreference is the order formats are preferred in when the
+// client accepts several equally.
+var DefaultFormatPreference = []components.Format{
+	components.FormatAvif, components.FormatJxl, components.FormatWebp, components.FormatJpeg, components.FormatPng,
+}
+
+// FormatURLs maps formats to the URLs of an image in that format.
+type FormatURLs map[components.Format]string
+
+// CdnFormatURLs returns the format URLs of a MetadataResponse.
+func CdnFormatURLs(u components.CdnUrls) FormatURLs {
+	return FormatURLs{
+		components.FormatAvif: u.Avif,
+		components.FormatJxl:  u.Jxl,
+		components.FormatWebp: u.Webp,
+		components.FormatJpeg: u.Jpeg,
+		components.FormatPng:  u.Png,
+	}
+}
+
+// AvailableFormatURLs returns the format URLs of an UploadResponse.
+func AvailableFormatURLs(a components.AvailableFormats) FormatURLs {
+	return FormatURLs{
+		components.FormatAvif: a.Avif,
+		components.FormatJxl:  a.Jxl,
+		components.FormatWebp: a.Webp,
+		components.FormatJpeg: a.Jpeg,
+		components.FormatPng:  a.Png,
+	}
+}
+
+// NegotiateOptions configures Negotiate.
+type NegotiateOptions struct {
+	// DeliveryFormats restricts the candidates to the formats enabled for the
+	// account, as in UserSettings.DeliveryFormats. Empty allows all.
+	DeliveryFormats []string
+	// Preference orders the candidates. Defaults to DefaultFormatPreference.
+	Preference []components.Format
+}
+
+// Negotiate picks the format to serve for an Accept header among those urls
+// has a URL for, and returns it with its URL. It returns an empty format if
+// urls is empty.
+//
+// If no enabled format is acceptable, JPEG or PNG is served even if it is not
+// a delivery format, since every client can display them.
+func Negotiate(accept string, urls FormatURLs, opts *NegotiateOptions) (components.Format, string) {
+	if opts == nil {
+		opts = &NegotiateOptions{}
+	}
+	preference := opts.Preference
+	if len(preference) == 0 {
+		preference = DefaultFormatPreference
+	}
+
+	var candidates, available []components.Format
+	for _, f := range preference {
+		if urls[f] == "" {
+			continue
+		}
+		available = append(available, f)
+		if len(opts.DeliveryFormats) == 0 || slices.ContainsFunc(opts.DeliveryFormats, func(d string) bool { return parseFormat(d) == f }) {
+			candidates = append(candidates, f)
+		}
+	}
+	if len(available) == 0 {
+		return "", ""
+	}
+
+	if f, ok := negotiate(accept, candidates); ok {
+		return f, urls[f]
+	}
+	for _, f := range available {
+		if isBaseline(f) {
+			return f, urls[f]
+		}
+	}
+	return available[0], urls[available[0]]
+}
+
+// NegotiateFormat returns the best of formats, given in order of preference,
+// for an Accept header. AVIF, JPEG XL and WebP are only picked when the
+// client names them, as browsers send wildcards they cannot honour for them.
+// JPEG and PNG are interchangeable for any client, so they share the best
+// quality value either gets and the preference order decides between them.
+// If nothing is acceptable, the first baseline format or else the last format
+// is returned.
+func NegotiateFormat(accept string, formats []components.Format) components.Format {
+	if f, ok := negotiate(accept, formats); ok {
+		return f
+	}
+	for _, f := range formats {
+		if isBaseline(f) {
+			return f
+		}
+	}
+	if len(formats) == 0 {
+		return ""
+	}
+	return formats[len(formats)-1]
+}
+
+func negotiate(accept string, formats []components.Format) (components.Format, bool) {
+	ranges := parseAccept(accept)
+
+	baselineQ := 0.0
+	for _, f := range formats {
+		if isBaseline(f) {
+			baselineQ = max(baselineQ, quality(ranges, f))
+		}
+	}
+
+	var best components.Format
+	bestQ := 0.0
+	for _, f := range formats {
+		q := quality(ranges, f)
+		if isBaseline(f) && q > 0 {
+			q = baselineQ
+		}
+		if q > bestQ {
+			best, bestQ = f, q
+		}
+	}
+	return best, bestQ > 0
+}
+
+type mediaRange struct {
+	typ, subtype string
+	q            float64
+}
+
+// parseAccept parses an Accept header. An empty header accepts everything.
+func parseAccept(accept string) []mediaRange {
+	if strings.TrimSpace(accept) == "" {
+		return []mediaRange{{"*", "*", 1}}
+	}
+
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(params[0])), "/")
+		if !ok {
+			continue
+		}
+
+		r := mediaRange{typ: strings.TrimSpace(typ), subtype: strings.TrimSpace(subtype), q: 1}
+		for _, p := range params[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if strings.EqualFold(strings.TrimSpace(name), "q") {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && q >= 0 && q <= 1 {
+					r.q = q
+				}
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// quality returns the quality value of the most specific range matching f.
+// Wildcards only match the baseline formats.
+func quality(ranges []mediaRange, f components.Format) float64 {
+	subtypes := []string{string(f)}
+	if f == components.FormatJpeg {
+		subtypes = append(subtypes, "jpg", "pjpeg")
+	}
+
+	q, specificity := 0.0, 0
+	for _, r := range ranges {
+		var s int
+		switch {
+		case r.typ == "image" && slices.Contains(subtypes, r.subtype):
+			s = 3
+		case r.typ == "image" && r.subtype == "*" && isBaseline(f):
+			s = 2
+		case r.typ == "*" && r.subtype == "*" && isBaseline(f):
+			s = 1
+		default:
+			continue
+		}
+		if s > specificity || (s == specificity && r.q > q) {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
+
+func isBaseline(f components.Format) bool {
+	return f == components.FormatJpeg || f == components.FormatPng
+}
+
+func parseFormat(s string) components.Format {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "jpg" {
+		return components.FormatJpeg
+	}
+	return components.Format(s)
+}
@@ -0,0 +1,109 @@
+package imghttp_test
+
+import (
+	"testing"
+
+	"github.com/img-src-io/sdk-go/imghttp"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/stretchr/testify/assert"
+)
+
+// Accept headers browsers send for <img> requests.
+const (
+	chrome120    = "image/avif,image/webp,image/apng,image/svg+xml,image/*,*/*;q=0.8"
+	chrome85     = "image/webp,image/apng,image/*,*/*;q=0.8"
+	edge18       = "image/webp,image/apng,image/*,*/*;q=0.8"
+	firefox128   = "image/avif,image/webp,image/png,image/svg+xml,image/*;q=0.8,*/*;q=0.5"
+	firefox90    = "image/webp,*/*"
+	firefox60    = "*/*"
+	safari17     = "image/webp,image/avif,image/jxl,image/heic,image/heic-sequence,video/*;q=0.8,image/png,image/svg+xml,image/*;q=0.8,*/*;q=0.5"
+	safari16     = "image/webp,image/avif,video/*;q=0.8,image/png,image/svg+xml,image/*;q=0.8,*/*;q=0.5"
+	safari13     = "image/png,image/svg+xml,image/*;q=0.8,video/*;q=0.8,*/*;q=0.5"
+	ie11         = "image/png, image/svg+xml, image/jxr, image/*;q=0.8, */*;q=0.5"
+	samsung23    = "image/avif,image/webp,image/apng,image/svg+xml,image/*,*/*;q=0.8"
+	opera12      = "text/html, application/xml;q=0.9, application/xhtml+xml, image/png, image/webp, image/jpeg, image/gif, image/x-xbitmap, */*;q=0.1"
+	curl         = "*/*"
+	noAccept     = ""
+	textOnly     = "text/html"
+	webpRejected = "image/webp;q=0,image/*"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	t.Parallel()
+
+	all := imghttp.DefaultFormatPreference
+	noJXL := []components.Format{components.FormatAvif, components.FormatWebp, components.FormatJpeg, components.FormatPng}
+	tests := []struct {
+		name    string
+		accept  string
+		formats []components.Format
+		want    components.Format
+	}{
+		{"chrome 120", chrome120, all, components.FormatAvif},
+		{"chrome 85", chrome85, all, components.FormatWebp},
+		{"edge 18", edge18, all, components.FormatWebp},
+		{"firefox 128", firefox128, all, components.FormatAvif},
+		{"firefox 90", firefox90, all, components.FormatWebp},
+		{"firefox 60", firefox60, all, components.FormatJpeg},
+		{"safari 17", safari17, all, components.FormatAvif},
+		{"safari 17 prefers jxl over webp", safari17, []components.Format{components.FormatJxl, components.FormatWebp, components.FormatJpeg}, components.FormatJxl},
+		{"safari 16", safari16, noJXL, components.FormatAvif},
+		{"safari 13", safari13, all, components.FormatJpeg},
+		{"ie 11 gets jpeg despite explicit png", ie11, all, components.FormatJpeg},
+		{"ie 11 png preferred", ie11, []components.Format{components.FormatWebp, components.FormatPng, components.FormatJpeg}, components.FormatPng},
+		{"samsung internet", samsung23, all, components.FormatAvif},
+		{"opera presto", opera12, all, components.FormatWebp},
+		{"curl", curl, all, components.FormatJpeg},
+		{"no accept header", noAccept, all, components.FormatJpeg},
+		{"accepts no image", textOnly, all, components.FormatJpeg},
+		{"explicit rejection", webpRejected, []components.Format{components.FormatWebp, components.FormatPng}, components.FormatPng},
+		{"higher q wins over preference", "image/avif;q=0.5,image/webp", all, components.FormatWebp},
+		{"modern only, unsupported", curl, []components.Format{components.FormatAvif, components.FormatWebp}, components.FormatWebp},
+		{"no formats", chrome120, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, imghttp.NegotiateFormat(tt.accept, tt.formats))
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	t.Parallel()
+
+	urls := imghttp.CdnFormatURLs(components.CdnUrls{
+		Original: "https://cdn.img-src.io/john/photo.jpg",
+		Webp:     "https://cdn.img-src.io/john/photo.webp",
+		Avif:     "https://cdn.img-src.io/john/photo.avif",
+		Jpeg:     "https://cdn.img-src.io/john/photo.jpg",
+		Png:      "https://cdn.img-src.io/john/photo.png",
+		Jxl:      "https://cdn.img-src.io/john/photo.jxl",
+	})
+	settings := &components.UserSettings{DeliveryFormats: []string{"webp", "jpg"}}
+
+	tests := []struct {
+		name   string
+		accept string
+		urls   imghttp.FormatURLs
+		opts   *imghttp.NegotiateOptions
+		format components.Format
+		url    string
+	}{
+		{"all formats", chrome120, urls, nil, components.FormatAvif, "https://cdn.img-src.io/john/photo.avif"},
+		{"delivery formats", chrome120, urls, &imghttp.NegotiateOptions{DeliveryFormats: settings.DeliveryFormats}, components.FormatWebp, "https://cdn.img-src.io/john/photo.webp"},
+		{"old client with delivery formats", safari13, urls, &imghttp.NegotiateOptions{DeliveryFormats: settings.DeliveryFormats}, components.FormatJpeg, "https://cdn.img-src.io/john/photo.jpg"},
+		{"preference", safari17, urls, &imghttp.NegotiateOptions{Preference: []components.Format{components.FormatWebp, components.FormatAvif}}, components.FormatWebp, "https://cdn.img-src.io/john/photo.webp"},
+		{"falls back outside delivery formats", curl, urls, &imghttp.NegotiateOptions{DeliveryFormats: []string{"avif"}}, components.FormatJpeg, "https://cdn.img-src.io/john/photo.jpg"},
+		{"missing variants", chrome120, imghttp.AvailableFormatURLs(components.AvailableFormats{Webp: "w", Png: "p"}), nil, components.FormatWebp, "w"},
+		{"no variants", chrome120, imghttp.FormatURLs{}, nil, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			format, url := imghttp.Negotiate(tt.accept, tt.urls, tt.opts)
+			assert.Equal(t, tt.format, format)
+			assert.Equal(t, tt.url, url)
+		})
+	}
+}
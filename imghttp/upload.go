@@ -0,0 +1,299 @@
+// Package imghttp provides net/http handlers that put img-src behind a Go
+// backend.
+package imghttp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/internal/limit"
+	"github.com/img-src-io/sdk-go/internal/sniff"
+	"github.com/img-src-io/sdk-go/models/apierrors"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/img-src-io/sdk-go/models/operations"
+)
+
+// DefaultMaxUploadSize is used when UploadHandler.MaxSize is zero.
+const DefaultMaxUploadSize = 20 << 20
+
+// maxFieldSize bounds the text fields of an upload form.
+const maxFieldSize = 64 << 10
+
+// maxFormOverhead bounds what an upload form may carry besides the file: part
+// headers, boundaries and text fields.
+const maxFormOverhead = 1 << 20
+
+// DefaultAllowedTypes are the MIME types accepted when
+// UploadHandler.AllowedTypes is empty.
+var DefaultAllowedTypes = []string{
+	"image/jpeg", "image/png", "image/gif", "image/webp", "image/avif", "image/jxl", "image/heic", "image/heif",
+}
+
+// ErrUnauthorized can be returned by UploadHandler.Authorize to respond with
+// 401 instead of 403.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// Error is an error with the HTTP status and error code it is reported with.
+// The hooks of the handlers may return it to control the response.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// UploadHandler accepts multipart/form-data uploads from browsers and streams
+// the file into Images.Upload, responding with the UploadResponse JSON.
+//
+// The form carries the image in the "file" field. The optional
+// "target_path" and "visibility" fields, or query parameters of the same
+// name, must precede the file, since the file is forwarded while it is still
+// being received. Errors are reported in the API's error format.
+type UploadHandler struct {
+	Client *sdkgo.Imgsrc
+	// MaxSize is the largest file accepted, in bytes; larger files, and
+	// bodies carrying more than 1 MiB besides the file, are rejected with
+	// 413. Defaults to DefaultMaxUploadSize.
+	MaxSize int64
+	// AllowedTypes are the accepted MIME types, detected from the file
+	// contents rather than trusted from the browser. Other files are rejected
+	// with 415. Defaults to DefaultAllowedTypes.
+	AllowedTypes []string
+	// Authorize is called before the body is read. An error rejects the
+	// request with 403, 401 if it is ErrUnauthorized, or the status of an
+	// *Error.
+	Authorize func(r *http.Request) error
+	// TargetPath decides where the image is stored, given the requested
+	// target path (possibly empty) and the file name. An error rejects the
+	// request with 400 or the status of an *Error. If nil, the requested path
+	// is used when set and the file name otherwise.
+	TargetPath func(r *http.Request, requested, filename string) (string, error)
+	// Visibility, if set, overrides the visibility requested by the form.
+	Visibility *components.Visibility
+	// Options are passed to Images.Upload.
+	Options []operations.Option
+}
+
+var _ http.Handler = (*UploadHandler)(nil)
+
+func (h *UploadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, r, &Error{Status: http.StatusMethodNotAllowed, Code: "METHOD_NOT_ALLOWED", Message: "uploads must be POSTed"})
+		return
+	}
+
+	if h.Authorize != nil {
+		if err := h.Authorize(r); err != nil {
+			status, code := http.StatusForbidden, "FORBIDDEN"
+			if errors.Is(err, ErrUnauthorized) {
+				status, code = http.StatusUnauthorized, "UNAUTHORIZED"
+			}
+			writeError(w, r, asError(err, status, code))
+			return
+		}
+	}
+
+	res, err := h.upload(w, r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+func (h *UploadHandler) upload(w http.ResponseWriter, r *http.Request) (*components.UploadResponse, error) {
+	maxSize := h.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxUploadSize
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+maxFormOverhead)
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, &Error{Status: http.StatusBadRequest, Code: "VALIDATION_ERROR", Message: "expected a multipart/form-data body"}
+	}
+
+	requested := r.URL.Query().Get("target_path")
+	visibility := r.URL.Query().Get("visibility")
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, &Error{Status: http.StatusBadRequest, Code: "VALIDATION_ERROR", Message: "missing file field"}
+		}
+		if err != nil {
+			return nil, malformed(err, maxSize)
+		}
+
+		switch part.FormName() {
+		case "target_path", "visibility":
+			value, err := io.ReadAll(io.LimitReader(part, maxFieldSize))
+			if err != nil {
+				return nil, malformed(err, maxSize)
+			}
+			if part.FormName() == "target_path" {
+				requested = string(value)
+			} else {
+				visibility = string(value)
+			}
+		case "file":
+			return h.forward(r, part, requested, visibility, maxSize)
+		default:
+			if part.FileName() != "" {
+				return nil, &Error{Status: http.StatusBadRequest, Code: "VALIDATION_ERROR", Message: fmt.Sprintf("unexpected file in field %q", part.FormName())}
+			}
+		}
+		part.Close()
+	}
+}
+
+func (h *UploadHandler) forward(r *http.Request, part io.Reader, requested, visibility string, maxSize int64) (*components.UploadResponse, error) {
+	filename := "upload"
+	if p, ok := part.(interface{ FileName() string }); ok && p.FileName() != "" {
+		filename = path.Base(strings.ReplaceAll(p.FileName(), `\`, "/"))
+	}
+
+	target := strings.Trim(requested, "/")
+	if h.TargetPath != nil {
+		var err error
+		if target, err = h.TargetPath(r, requested, filename); err != nil {
+			return nil, asError(err, http.StatusBadRequest, "VALIDATION_ERROR")
+		}
+	} else if target == "" {
+		target = filename
+	}
+
+	body := &operations.UploadImageRequestBody{TargetPath: sdkgo.String(target), Visibility: h.Visibility}
+	if body.Visibility == nil && visibility != "" {
+		v := components.Visibility(visibility)
+		if v != components.VisibilityPublic && v != components.VisibilityPrivate {
+			return nil, &Error{Status: http.StatusBadRequest, Code: "VALIDATION_ERROR", Message: fmt.Sprintf("invalid visibility %q", visibility)}
+		}
+		body.Visibility = &v
+	}
+
+	limited := limit.NewReader(part, maxSize, errors.New("file too large"))
+	br := bufio.NewReaderSize(limited, sniff.HeaderSize)
+	head, err := br.Peek(sniff.HeaderSize)
+	if limited.Exceeded() {
+		return nil, tooLarge(maxSize)
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, &Error{Status: http.StatusBadRequest, Code: "VALIDATION_ERROR", Message: fmt.Sprintf("error reading file: %v", err)}
+	}
+	if len(head) == 0 {
+		return nil, &Error{Status: http.StatusBadRequest, Code: "VALIDATION_ERROR", Message: "empty file"}
+	}
+	if contentType := sniff.ContentType(head); !h.allowed(contentType) {
+		return nil, &Error{Status: http.StatusUnsupportedMediaType, Code: "UNSUPPORTED_MEDIA_TYPE", Message: fmt.Sprintf("file type %s is not allowed", contentType)}
+	}
+
+	body.File = &operations.File{FileName: filename, Content: br}
+	res, err := h.Client.Images.Upload(r.Context(), body, h.Options...)
+	var bodyErr *http.MaxBytesError
+	if limited.Exceeded() || errors.As(err, &bodyErr) {
+		return nil, tooLarge(maxSize)
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := res.GetUploadResponse()
+	if out == nil {
+		return nil, errors.New("empty upload response")
+	}
+	return out, nil
+}
+
+func (h *UploadHandler) allowed(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	allowed := h.AllowedTypes
+	if len(allowed) == 0 {
+		allowed = DefaultAllowedTypes
+	}
+	for _, t := range allowed {
+		if strings.EqualFold(t, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// malformed reports an error reading the form, which is 413 once the body
+// exceeded its limit.
+func malformed(err error, maxSize int64) *Error {
+	var bodyErr *http.MaxBytesError
+	if errors.As(err, &bodyErr) {
+		return tooLarge(maxSize)
+	}
+	return &Error{Status: http.StatusBadRequest, Code: "VALIDATION_ERROR", Message: fmt.Sprintf("malformed multipart body: %v", err)}
+}
+
+func tooLarge(maxSize int64) *Error {
+	return &Error{Status: http.StatusRequestEntityTooLarge, Code: "PAYLOAD_TOO_LARGE", Message: fmt.Sprintf("file exceeds %d bytes", maxSize)}
+}
+
+// asError returns err as an *Error, using status and code unless it already
+// is one.
+func asError(err error, status int, code string) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return &Error{Status: status, Code: code, Message: err.Error()}
+}
+
+// writeError reports err in the API's error format. Errors returned by the
+// API keep their status, except for authentication failures and server
+// errors, which are the backend's problem and become 502.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	detail := components.ErrorDetail{Status: http.StatusBadGateway, Code: "UPSTREAM_ERROR", Message: err.Error()}
+
+	var e *Error
+	var errRes *apierrors.ErrorResponse
+	var apiErr *apierrors.APIError
+	switch {
+	case errors.As(err, &e):
+		detail = components.ErrorDetail{Status: int64(e.Status), Code: e.Code, Message: e.Message}
+	case errors.As(err, &errRes):
+		if passThrough(int(errRes.Error_.Status)) {
+			detail = errRes.Error_
+		} else {
+			detail.Message = errRes.Error_.Message
+		}
+	case errors.As(err, &apiErr):
+		if passThrough(apiErr.StatusCode) {
+			detail.Status = int64(apiErr.StatusCode)
+			detail.Code = strings.ToUpper(strings.ReplaceAll(http.StatusText(apiErr.StatusCode), " ", "_"))
+		}
+	case r.Context().Err() != nil:
+		// The client went away; there is nobody to report to.
+		return
+	}
+	detail.Path = sdkgo.String(r.URL.Path)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(int(detail.Status))
+	_ = json.NewEncoder(w).Encode(apierrors.ErrorResponse{Error_: detail})
+}
+
+func passThrough(status int) bool {
+	return status >= 400 && status < 500 && status != http.StatusUnauthorized && status != http.StatusForbidden
+}
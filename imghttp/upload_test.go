@@ -0,0 +1,233 @@
+package imghttp_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/img-src-io/sdk-go/imghttp"
+	"github.com/img-src-io/sdk-go/internal/fakeapi"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pngData = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+
+// form builds a multipart upload; fields are written before the file.
+func form(t *testing.T, filename string, data []byte, fields ...string) (*bytes.Buffer, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for i := 0; i+1 < len(fields); i += 2 {
+		require.NoError(t, mw.WriteField(fields[i], fields[i+1]))
+	}
+	fw, err := mw.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = fw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+	return &buf, mw.FormDataContentType()
+}
+
+func post(t *testing.T, h http.Handler, target string, body *bytes.Buffer, contentType string) (*httptest.ResponseRecorder, components.ErrorDetail) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, target, body)
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var errRes struct {
+		Error components.ErrorDetail `json:"error"`
+	}
+	if rec.Code >= 400 {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &errRes), rec.Body.String())
+		assert.EqualValues(t, rec.Code, errRes.Error.Status)
+	}
+	return rec, errRes.Error
+}
+
+func TestUploadHandler(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	h := &imghttp.UploadHandler{Client: f.SDK()}
+
+	body, ct := form(t, `C:\Users\bob\pixel.png`, pngData, "target_path", "avatars/bob.png", "visibility", "private")
+	rec, _ := post(t, h, "/upload", body, ct)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var res components.UploadResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, []string{"avatars/bob.png"}, res.Paths)
+	img := f.Image(res.ID)
+	require.NotNil(t, img)
+	assert.Equal(t, pngData, img.Data)
+	assert.Equal(t, "pixel.png", img.Filename)
+	assert.Equal(t, "private", img.Visibility)
+
+	// Without a target path the file name is used; fields may also come
+	// from the query.
+	body, ct = form(t, "other.png", append([]byte(nil), append(pngData, 1)...))
+	rec, _ = post(t, h, "/upload?visibility=public", body, ct)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"other.png"`)
+}
+
+func TestUploadHandler_Rejections(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	f.Add("taken", "taken.png")
+
+	h := &imghttp.UploadHandler{
+		Client:  f.SDK(),
+		MaxSize: 64,
+		Authorize: func(r *http.Request) error {
+			switch r.Header.Get("Authorization") {
+			case "":
+				return imghttp.ErrUnauthorized
+			case "Bearer guest":
+				return errors.New("guests may not upload")
+			}
+			return nil
+		},
+		TargetPath: func(r *http.Request, requested, filename string) (string, error) {
+			if strings.Contains(requested, "..") {
+				return "", &imghttp.Error{Status: http.StatusForbidden, Code: "FORBIDDEN", Message: "path escapes the user folder"}
+			}
+			if requested == "" {
+				requested = filename
+			}
+			return "users/bob/" + requested, nil
+		},
+	}
+	send := func(t *testing.T, auth, target string, body *bytes.Buffer, ct string) (int, components.ErrorDetail) {
+		req := httptest.NewRequest(http.MethodPost, target, body)
+		req.Header.Set("Content-Type", ct)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		var errRes struct {
+			Error components.ErrorDetail `json:"error"`
+		}
+		if rec.Code >= 400 {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &errRes), rec.Body.String())
+		}
+		return rec.Code, errRes.Error
+	}
+
+	tests := []struct {
+		name   string
+		auth   string
+		data   []byte
+		fields []string
+		status int
+		code   string
+	}{
+		{"unauthenticated", "", pngData, nil, http.StatusUnauthorized, "UNAUTHORIZED"},
+		{"forbidden", "Bearer guest", pngData, nil, http.StatusForbidden, "FORBIDDEN"},
+		{"too large", "Bearer bob", append(append([]byte(nil), pngData...), make([]byte, 64)...), nil, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE"},
+		{"not an image", "Bearer bob", []byte("#!/bin/sh\nrm -rf /\n"), nil, http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE"},
+		{"path policy", "Bearer bob", pngData, []string{"target_path", "../alice/x.png"}, http.StatusForbidden, "FORBIDDEN"},
+		{"bad visibility", "Bearer bob", pngData, []string{"visibility", "secret"}, http.StatusBadRequest, "VALIDATION_ERROR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, ct := form(t, "x.png", tt.data, tt.fields...)
+			status, detail := send(t, tt.auth, "/upload", body, ct)
+			assert.Equal(t, tt.status, status)
+			assert.Equal(t, tt.code, detail.Code)
+			require.NotNil(t, detail.Path)
+			assert.Equal(t, "/upload", *detail.Path)
+		})
+	}
+	assert.Zero(t, f.Count("POST /api/v1/images"))
+
+	// API errors are passed through.
+	f.Add("squatter", "users/bob/taken.png")
+	body, ct := form(t, "taken.png", pngData)
+	status, detail := send(t, "Bearer bob", "/upload", body, ct)
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Equal(t, "path already exists", detail.Message)
+
+	status, _ = send(t, "Bearer bob", "/upload", &bytes.Buffer{}, "text/plain")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	req := httptest.NewRequest(http.MethodGet, "/upload", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, http.MethodPost, rec.Header().Get("Allow"))
+}
+
+func TestUploadHandler_UpstreamAuthFailure(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	f.Fail(func(r *http.Request) int { return http.StatusUnauthorized })
+
+	body, ct := form(t, "x.png", pngData)
+	rec, detail := post(t, &imghttp.UploadHandler{Client: f.SDK()}, "/upload", body, ct)
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+	assert.Equal(t, "UPSTREAM_ERROR", detail.Code)
+}
+
+func TestUploadHandler_TooLargeWhileStreaming(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+
+	// The limit is hit after the type was sniffed, while forwarding.
+	data := append(append([]byte(nil), pngData...), make([]byte, 2000)...)
+	body, ct := form(t, "big.png", data)
+	rec, detail := post(t, &imghttp.UploadHandler{Client: f.SDK(), MaxSize: 1000}, "/upload", body, ct)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Equal(t, "PAYLOAD_TOO_LARGE", detail.Code)
+	assert.Zero(t, f.Len())
+}
+
+func TestUploadHandler_BoundsWholeBody(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	h := &imghttp.UploadHandler{Client: f.SDK(), MaxSize: 64}
+
+	// A large text field ahead of the file is not the file, but still counts.
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, err := mw.CreatePart(textproto.MIMEHeader{"Content-Disposition": {`form-data; name="comment"`}})
+	require.NoError(t, err)
+	_, err = fw.Write(make([]byte, 2<<20))
+	require.NoError(t, err)
+	fw, err = mw.CreateFormFile("file", "x.png")
+	require.NoError(t, err)
+	_, err = fw.Write(pngData)
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	rec, detail := post(t, h, "/upload", &buf, mw.FormDataContentType())
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Equal(t, "PAYLOAD_TOO_LARGE", detail.Code)
+
+	// Files are only accepted in the file field.
+	buf.Reset()
+	mw = multipart.NewWriter(&buf)
+	fw, err = mw.CreateFormFile("extra", "y.png")
+	require.NoError(t, err)
+	_, err = fw.Write(pngData)
+	require.NoError(t, err)
+	fw, err = mw.CreateFormFile("file", "x.png")
+	require.NoError(t, err)
+	_, err = fw.Write(pngData)
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	rec, detail = post(t, h, "/upload", &buf, mw.FormDataContentType())
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "VALIDATION_ERROR", detail.Code)
+	assert.Zero(t, f.Count("POST /api/v1/images"))
+}
@@ -0,0 +1,53 @@
+package imgproc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// Decode decodes a JPEG, PNG or GIF and applies its EXIF orientation, so
+// that the image is upright. Other formats fail with image.ErrFormat.
+func Decode(data []byte) (image.Image, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if errors.Is(err, image.ErrFormat) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+
+	orientation := 1
+	switch format {
+	case "jpeg":
+		meta, err := readJPEGMeta(data)
+		if err != nil {
+			return nil, err
+		}
+		orientation = meta.orientation
+	case "png":
+		meta, err := readPNGMeta(data)
+		if err != nil {
+			return nil, err
+		}
+		orientation = meta.orientation
+	}
+	if orientation == 1 {
+		return img, nil
+	}
+	return orient(img, orientation), nil
+}
+
+// Thumbnail returns img downscaled to fit maxWidth×maxHeight, keeping its
+// aspect ratio, or img itself if it fits. A zero bound is no bound.
+func Thumbnail(img image.Image, maxWidth, maxHeight int) image.Image {
+	w, h, ok := fit(img.Bounds().Dx(), img.Bounds().Dy(), maxWidth, maxHeight)
+	if !ok {
+		return img
+	}
+	return Resize(img, w, h)
+}
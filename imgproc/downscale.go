@@ -0,0 +1,206 @@
+package imgproc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/models/operations"
+)
+
+// DownscaleOptions configures Downscale and UploadDownscaled.
+type DownscaleOptions struct {
+	// MaxWidth and MaxHeight bound the displayed size of images. Zero means no
+	// bound on that axis.
+	MaxWidth  int
+	MaxHeight int
+	// Quality is the JPEG quality of downscaled JPEGs. Defaults to
+	// DefaultQuality.
+	Quality int
+	// OnResult, if set, is called by the Downscale transform for every file.
+	OnResult func(fileName string, res DownscaleResult)
+}
+
+// DownscaleResult describes what Downscale did to a file.
+type DownscaleResult struct {
+	// Format is "jpeg" or "png", or empty for files that were passed through
+	// because they cannot be decoded.
+	Format string
+	// Resized is false for files that were small enough already.
+	Resized bool
+	// OriginalWidth and OriginalHeight are the stored dimensions of the file,
+	// zero if it could not be decoded. Width and Height are those of the
+	// result, upright if it was resized.
+	OriginalWidth  int
+	OriginalHeight int
+	Width          int
+	Height         int
+	// OriginalSize and Size are the file sizes in bytes before and after.
+	OriginalSize int64
+	Size         int64
+}
+
+// DownscaleOptionsFromSettings returns options bounding images to the
+// account's UserSettings.DefaultMaxWidth and DefaultMaxHeight, so that
+// nothing larger than is ever served is stored.
+func DownscaleOptionsFromSettings(ctx context.Context, client *sdkgo.Imgsrc, quality int, opts ...operations.Option) (DownscaleOptions, error) {
+	res, err := client.Settings.Get(ctx, opts...)
+	if err != nil {
+		return DownscaleOptions{}, fmt.Errorf("error getting settings: %w", err)
+	}
+	settings := res.GetSettingsResponse().GetSettings()
+
+	out := DownscaleOptions{Quality: quality}
+	if w := settings.GetDefaultMaxWidth(); w != nil {
+		out.MaxWidth = int(*w)
+	}
+	if h := settings.GetDefaultMaxHeight(); h != nil {
+		out.MaxHeight = int(*h)
+	}
+	return out, nil
+}
+
+// Downscale returns a transform that shrinks JPEG and PNG files larger than
+// the bounds of opts to fit them, keeping their aspect ratio. Resized files
+// are re-encoded in their format, applying their EXIF orientation and
+// keeping their color profile but no other metadata. Files within the bounds
+// and files in other formats pass through unchanged.
+func Downscale(opts DownscaleOptions) sdkgo.UploadTransform {
+	return func(fileName string, r io.Reader) (io.Reader, error) {
+		data, err := io.ReadAll(bufio.NewReader(r))
+		if err != nil {
+			return nil, err
+		}
+		out, res, err := downscale(data, opts)
+		if err != nil {
+			return nil, err
+		}
+		if opts.OnResult != nil {
+			opts.OnResult(fileName, res)
+		}
+		return bytes.NewReader(out), nil
+	}
+}
+
+func downscale(data []byte, opts DownscaleOptions) ([]byte, DownscaleResult, error) {
+	res := DownscaleResult{OriginalSize: int64(len(data)), Size: int64(len(data))}
+	isJPEG := bytes.HasPrefix(data, []byte{0xff, 0xd8})
+	if !isJPEG && !bytes.HasPrefix(data, pngSignature) {
+		return data, res, nil
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, res, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	res.Format = format
+	res.OriginalWidth, res.OriginalHeight = cfg.Width, cfg.Height
+	res.Width, res.Height = cfg.Width, cfg.Height
+
+	var jm jpegMeta
+	var pm pngMeta
+	orientation := 1
+	if isJPEG {
+		jm, err = readJPEGMeta(data)
+		orientation = jm.orientation
+	} else {
+		pm, err = readPNGMeta(data)
+		orientation = pm.orientation
+	}
+	if err != nil {
+		return nil, res, err
+	}
+
+	// The bounds apply to the image as displayed.
+	w, h := cfg.Width, cfg.Height
+	if orientation >= 5 {
+		w, h = h, w
+	}
+	dw, dh, ok := fit(w, h, opts.MaxWidth, opts.MaxHeight)
+	if !ok {
+		return data, res, nil
+	}
+	if orientation >= 5 {
+		dw, dh = dh, dw
+	}
+
+	var img image.Image
+	if isJPEG {
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	} else {
+		img, err = png.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, res, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	img = orient(Resize(img, dw, dh), orientation)
+
+	var out []byte
+	if isJPEG {
+		quality := opts.Quality
+		if quality <= 0 {
+			quality = DefaultQuality
+		}
+		out, err = jm.encode(img, quality)
+	} else {
+		out, err = pm.encode(img)
+	}
+	if err != nil {
+		return nil, res, err
+	}
+
+	res.Resized = true
+	res.Width, res.Height = img.Bounds().Dx(), img.Bounds().Dy()
+	res.Size = int64(len(out))
+	return out, res, nil
+}
+
+// DownscaleUpload is the result of UploadDownscaled.
+type DownscaleUpload struct {
+	Response *operations.UploadImageResponse
+	DownscaleResult
+}
+
+// UploadDownscaled downscales the file of an upload request as Downscale
+// does and uploads the result, reporting the dimensions and size of what
+// was sent along with the response.
+func UploadDownscaled(ctx context.Context, client *sdkgo.Imgsrc, request *operations.UploadImageRequestBody, opts DownscaleOptions, reqOpts ...operations.Option) (*DownscaleUpload, error) {
+	if request == nil || request.File == nil {
+		return nil, errors.New("missing file")
+	}
+
+	var data []byte
+	switch content := request.File.Content.(type) {
+	case []byte:
+		data = content
+	case io.Reader:
+		var err error
+		if data, err = io.ReadAll(content); err != nil {
+			return nil, fmt.Errorf("error reading file: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported file content %T", content)
+	}
+
+	out, res, err := downscale(data, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	file := *request.File
+	file.Content = out
+	req := *request
+	req.File = &file
+	upload, err := client.Images.Upload(ctx, &req, reqOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &DownscaleUpload{Response: upload, DownscaleResult: res}, nil
+}
@@ -0,0 +1,194 @@
+package imgproc_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"sync"
+	"testing"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/imgproc"
+	"github.com/img-src-io/sdk-go/internal/fakeapi"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/img-src-io/sdk-go/models/operations"
+	"github.com/img-src-io/sdk-go/optionalnullable"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// downscaled runs the Downscale transform and returns its output and result.
+func downscaled(t *testing.T, opts imgproc.DownscaleOptions, data []byte) ([]byte, imgproc.DownscaleResult) {
+	t.Helper()
+	var res imgproc.DownscaleResult
+	opts.OnResult = func(fileName string, r imgproc.DownscaleResult) {
+		assert.Equal(t, "file", fileName)
+		res = r
+	}
+	return transform(t, imgproc.Downscale(opts), data), res
+}
+
+func TestDownscaleJPEG(t *testing.T) {
+	t.Parallel()
+	fixture := jpegFixture(t, quadrants(400, 200), 1)
+
+	out, res := downscaled(t, imgproc.DownscaleOptions{MaxWidth: 100, MaxHeight: 100, Quality: 70}, fixture)
+	assert.Equal(t, imgproc.DownscaleResult{
+		Format: "jpeg", Resized: true,
+		OriginalWidth: 400, OriginalHeight: 200, Width: 100, Height: 50,
+		OriginalSize: int64(len(fixture)), Size: int64(len(out)),
+	}, res)
+	assert.Less(t, res.Size, res.OriginalSize)
+
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(out))
+	require.NoError(t, err)
+	assert.Equal(t, 100, cfg.Width)
+	assert.Equal(t, 50, cfg.Height)
+	assertNoMetadata(t, out)
+	assert.Contains(t, string(out), string(iccData))
+}
+
+func TestDownscaleAppliesOrientation(t *testing.T) {
+	t.Parallel()
+	// Stored landscape, displayed portrait.
+	fixture := jpegFixture(t, quadrants(400, 200), 6)
+
+	out, res := downscaled(t, imgproc.DownscaleOptions{MaxHeight: 100}, fixture)
+	assert.True(t, res.Resized)
+	assert.Equal(t, 50, res.Width)
+	assert.Equal(t, 100, res.Height)
+
+	img, err := jpeg.Decode(bytes.NewReader(out))
+	require.NoError(t, err)
+	assert.Equal(t, image.Pt(50, 100), img.Bounds().Size())
+	// Rotated clockwise, red is at the top right.
+	r, g, b, _ := img.At(40, 20).RGBA()
+	assert.Greater(t, r>>8, uint32(200))
+	assert.Less(t, g>>8, uint32(60))
+	assert.Less(t, b>>8, uint32(60))
+}
+
+func TestDownscaleSkipsSmallImages(t *testing.T) {
+	t.Parallel()
+	fixture := jpegFixture(t, quadrants(80, 40), 6)
+
+	out, res := downscaled(t, imgproc.DownscaleOptions{MaxWidth: 100, MaxHeight: 100}, fixture)
+	assert.Equal(t, fixture, out)
+	assert.Equal(t, imgproc.DownscaleResult{
+		Format: "jpeg", OriginalWidth: 80, OriginalHeight: 40, Width: 80, Height: 40,
+		OriginalSize: int64(len(fixture)), Size: int64(len(fixture)),
+	}, res)
+
+	gif := []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;")
+	out, res = downscaled(t, imgproc.DownscaleOptions{MaxWidth: 1}, gif)
+	assert.Equal(t, gif, out)
+	assert.Empty(t, res.Format)
+	assert.False(t, res.Resized)
+}
+
+func TestDownscalePNGAveragesArea(t *testing.T) {
+	t.Parallel()
+	// A checkerboard of opaque white and transparent pixels averages to half
+	// transparent white, not grey.
+	src := image.NewNRGBA(image.Rect(0, 0, 64, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 64; x++ {
+			if (x+y)%2 == 0 {
+				src.Set(x, y, color.NRGBA{255, 255, 255, 255})
+			}
+		}
+	}
+	fixture := pngFixture(t, src, 1)
+
+	out, res := downscaled(t, imgproc.DownscaleOptions{MaxWidth: 16}, fixture)
+	assert.Equal(t, "png", res.Format)
+	assert.Equal(t, 16, res.Width)
+	assert.Equal(t, 8, res.Height)
+	assertNoMetadata(t, out)
+	assert.Contains(t, string(out), string(iccData))
+
+	img, err := png.Decode(bytes.NewReader(out))
+	require.NoError(t, err)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 16; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			assert.InDelta(t, 255, int(c.R), 1)
+			assert.InDelta(t, 128, int(c.A), 1)
+		}
+	}
+}
+
+func TestUploadDownscaled(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	client := f.SDK()
+
+	width := int64(120)
+	_, err := client.Settings.Update(context.Background(), &components.UpdateSettingsRequest{
+		DefaultMaxWidth: optionalnullable.From(&width),
+	})
+	require.NoError(t, err)
+
+	opts, err := imgproc.DownscaleOptionsFromSettings(context.Background(), client, 80)
+	require.NoError(t, err)
+	assert.Equal(t, imgproc.DownscaleOptions{MaxWidth: 120, Quality: 80}, opts)
+
+	fixture := jpegFixture(t, quadrants(480, 240), 1)
+	up, err := imgproc.UploadDownscaled(context.Background(), client, &operations.UploadImageRequestBody{
+		File:       &operations.File{FileName: "big.jpg", Content: bytes.NewReader(fixture)},
+		TargetPath: sdkgo.String("photos/big.jpg"),
+	}, opts)
+	require.NoError(t, err)
+	assert.True(t, up.Resized)
+	assert.Equal(t, 120, up.Width)
+	assert.Equal(t, 60, up.Height)
+
+	stored := f.Image(up.Response.GetUploadResponse().ID).Data
+	assert.Equal(t, up.Size, int64(len(stored)))
+	assert.Equal(t, up.Size, up.Response.GetUploadResponse().Size)
+}
+
+func TestDownscaleTransformOnUpload(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+
+	var mu sync.Mutex
+	results := map[string]imgproc.DownscaleResult{}
+	sdk := f.SDK(sdkgo.WithUploadTransforms(imgproc.Downscale(imgproc.DownscaleOptions{
+		MaxWidth: 64, MaxHeight: 64,
+		OnResult: func(fileName string, res imgproc.DownscaleResult) {
+			mu.Lock()
+			defer mu.Unlock()
+			results[fileName] = res
+		},
+	})))
+
+	res, err := sdk.Images.Upload(context.Background(), &operations.UploadImageRequestBody{
+		File: &operations.File{FileName: "a.png", Content: pngFixture(t, gradient(256, 128), 1)},
+	})
+	require.NoError(t, err)
+	cfg, err := png.DecodeConfig(bytes.NewReader(f.Image(res.GetUploadResponse().ID).Data))
+	require.NoError(t, err)
+	assert.Equal(t, 64, cfg.Width)
+	assert.Equal(t, 32, cfg.Height)
+	assert.True(t, results["a.png"].Resized)
+}
+
+func TestDecodeAndThumbnail(t *testing.T) {
+	t.Parallel()
+	img, err := imgproc.Decode(pngFixture(t, quadrants(40, 20), 6))
+	require.NoError(t, err)
+	assert.Equal(t, image.Pt(20, 40), img.Bounds().Size())
+
+	thumb := imgproc.Thumbnail(img, 10, 10)
+	assert.Equal(t, image.Pt(5, 10), thumb.Bounds().Size())
+	assert.Same(t, img, imgproc.Thumbnail(img, 100, 0))
+
+	_, err = imgproc.Decode([]byte("RIFF\x00\x00\x00\x00WEBPVP8 "))
+	assert.ErrorIs(t, err, image.ErrFormat)
+	_, err = imgproc.Decode([]byte("\x89PNG\r\n\x1a\n\x00\x00"))
+	assert.ErrorIs(t, err, imgproc.ErrMalformed)
+}
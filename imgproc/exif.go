@@ -0,0 +1,50 @@
+package imgproc
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+const tagOrientation = 0x0112
+
+var exifHeader = []byte("Exif\x00\x00")
+
+// exifOrientation returns the orientation tag of a TIFF structure, as found
+// in a JPEG APP1 segment after the Exif header or in a PNG eXIf chunk. It
+// returns 1, the identity, when the tag is missing or invalid.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch {
+	case bytes.HasPrefix(tiff, []byte("II*\x00")):
+		order = binary.LittleEndian
+	case bytes.HasPrefix(tiff, []byte("MM\x00*")):
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	n := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < n; i++ {
+		entry := ifd + 2 + 12*i
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) != tagOrientation {
+			continue
+		}
+		// A SHORT value is stored left-aligned in the value field.
+		if v := int(order.Uint16(tiff[entry+8:])); v >= 1 && v <= 8 {
+			return v
+		}
+		return 1
+	}
+	return 1
+}
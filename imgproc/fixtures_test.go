@@ -0,0 +1,125 @@
+package imgproc_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The fixtures are built from encoded images with metadata spliced in, the
+// way cameras and editors write it.
+
+var (
+	gpsMarker = []byte("GPS 52.5200N 13.4050E")
+	xmpPacket = []byte(`<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:Description exif:GPSLatitude="52,31.2N"/></x:xmpmeta>`)
+	iptcBlock = []byte("8BIM\x04\x04\x00\x00\x00\x00\x00\x0c\x1c\x02\x50\x00\x06Author")
+	iccData   = []byte("fake color profile")
+)
+
+// exifTIFF returns an EXIF TIFF structure with an orientation and a make tag
+// standing in for the GPS data.
+func exifTIFF(orientation int) []byte {
+	tiff := []byte("II*\x00\x08\x00\x00\x00")
+	tiff = binary.LittleEndian.AppendUint16(tiff, 2)
+	// Orientation, SHORT, count 1, inline value.
+	tiff = binary.LittleEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3)
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, uint16(orientation))
+	tiff = append(tiff, 0, 0)
+	// Make, ASCII, pointing past the IFD.
+	tiff = binary.LittleEndian.AppendUint16(tiff, 0x010f)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 2)
+	tiff = binary.LittleEndian.AppendUint32(tiff, uint32(len(gpsMarker)))
+	tiff = binary.LittleEndian.AppendUint32(tiff, uint32(len(tiff)+8))
+	tiff = binary.LittleEndian.AppendUint32(tiff, 0)
+	return append(tiff, gpsMarker...)
+}
+
+func jpegSegment(marker byte, payload []byte) []byte {
+	seg := []byte{0xff, marker}
+	seg = binary.BigEndian.AppendUint16(seg, uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+func pngChunk(typ string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, typ...)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+// gradient returns an image whose pixels all differ, with a red top-left and
+// a green top-right corner.
+func gradient(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(10 + 20*x), G: uint8(10 + 20*y), B: 128, A: 255})
+		}
+	}
+	img.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	img.Set(w-1, 0, color.NRGBA{G: 255, A: 255})
+	return img
+}
+
+// quadrants returns an image with a red top-left quadrant, a green top-right
+// quadrant and a blue bottom half, which survives JPEG compression.
+func quadrants(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			switch {
+			case y >= h/2:
+				img.Set(x, y, color.RGBA{B: 255, A: 255})
+			case x < w/2:
+				img.Set(x, y, color.RGBA{R: 255, A: 255})
+			default:
+				img.Set(x, y, color.RGBA{G: 255, A: 255})
+			}
+		}
+	}
+	return img
+}
+
+// jpegFixture returns a JPEG of img carrying EXIF with the orientation, XMP,
+// IPTC, a comment and an ICC profile.
+func jpegFixture(t *testing.T, img image.Image, orientation int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}))
+	data := buf.Bytes()
+
+	out := append([]byte(nil), data[:2]...)
+	out = append(out, jpegSegment(0xe1, append([]byte("Exif\x00\x00"), exifTIFF(orientation)...))...)
+	out = append(out, jpegSegment(0xe1, append([]byte("http://ns.adobe.com/xap/1.0/\x00"), xmpPacket...))...)
+	out = append(out, jpegSegment(0xe2, append([]byte("ICC_PROFILE\x00\x01\x01"), iccData...))...)
+	out = append(out, jpegSegment(0xed, append([]byte("Photoshop 3.0\x00"), iptcBlock...))...)
+	out = append(out, jpegSegment(0xfe, []byte("shot at home"))...)
+	return append(out, data[2:]...)
+}
+
+// pngFixture returns a PNG of img carrying eXIf with the orientation, XMP in
+// iTXt, text, a modification time and a color profile.
+func pngFixture(t *testing.T, img image.Image, orientation int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	data := buf.Bytes()
+
+	ihdrEnd := 8 + 25
+	out := append([]byte(nil), data[:ihdrEnd]...)
+	out = append(out, pngChunk("iCCP", append([]byte("profile\x00\x00"), iccData...))...)
+	out = append(out, pngChunk("eXIf", exifTIFF(orientation))...)
+	out = append(out, pngChunk("iTXt", append([]byte("XML:com.adobe.xmp\x00\x00\x00\x00\x00"), xmpPacket...))...)
+	out = append(out, pngChunk("tEXt", []byte("Author\x00someone"))...)
+	out = append(out, pngChunk("tIME", []byte{0x07, 0xe8, 1, 2, 3, 4, 5})...)
+	return append(out, data[ihdrEnd:]...)
+}
@@ -0,0 +1,213 @@
+package imgproc_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/imgproc"
+	"github.com/img-src-io/sdk-go/internal/fakeapi"
+	"github.com/img-src-io/sdk-go/models/operations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func transform(t *testing.T, tr sdkgo.UploadTransform, data []byte) []byte {
+	t.Helper()
+	r, err := tr("file", bytes.NewReader(data))
+	require.NoError(t, err)
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return out
+}
+
+func assertNoMetadata(t *testing.T, data []byte) {
+	t.Helper()
+	for _, secret := range [][]byte{gpsMarker, xmpPacket, iptcBlock, []byte("shot at home"), []byte("someone"), []byte("tIME")} {
+		assert.NotContains(t, string(data), string(secret))
+	}
+}
+
+func TestStripMetadataJPEG(t *testing.T) {
+	t.Parallel()
+	img := gradient(16, 8)
+	fixture := jpegFixture(t, img, 1)
+
+	out := transform(t, imgproc.StripMetadata(), fixture)
+	assertNoMetadata(t, out)
+	assert.Contains(t, string(out), string(iccData))
+	assert.Less(t, len(out), len(fixture))
+
+	// The image data is copied, not re-encoded.
+	var plain bytes.Buffer
+	require.NoError(t, jpeg.Encode(&plain, img, &jpeg.Options{Quality: 95}))
+	assert.True(t, bytes.HasSuffix(out, plain.Bytes()[2:]))
+	_, err := jpeg.Decode(bytes.NewReader(out))
+	assert.NoError(t, err)
+}
+
+func TestStripMetadataPNG(t *testing.T) {
+	t.Parallel()
+	img := gradient(16, 8)
+	fixture := pngFixture(t, img, 1)
+
+	out := transform(t, imgproc.StripMetadata(), fixture)
+	assertNoMetadata(t, out)
+	assert.Contains(t, string(out), string(iccData))
+
+	decoded, err := png.Decode(bytes.NewReader(out))
+	require.NoError(t, err)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 16; x++ {
+			assert.Equal(t, img.At(x, y), color.NRGBAModel.Convert(decoded.At(x, y)))
+		}
+	}
+}
+
+func TestStripMetadataPassesOtherFiles(t *testing.T) {
+	t.Parallel()
+	gif := []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;")
+	assert.Equal(t, gif, transform(t, imgproc.StripMetadata(), gif))
+}
+
+func TestStripMetadataMalformed(t *testing.T) {
+	t.Parallel()
+	for _, data := range [][]byte{
+		jpegFixture(t, gradient(4, 4), 1)[:40],
+		pngFixture(t, gradient(4, 4), 1)[:60],
+	} {
+		r, err := imgproc.StripMetadata()("file", bytes.NewReader(data))
+		require.NoError(t, err)
+		_, err = io.ReadAll(r)
+		assert.ErrorIs(t, err, imgproc.ErrMalformed)
+	}
+}
+
+func TestNormalizeOrientationPNG(t *testing.T) {
+	t.Parallel()
+	const w, h = 4, 3
+	red, green := color.NRGBA{R: 255, A: 255}, color.NRGBA{G: 255, A: 255}
+
+	// Where the top-left and top-right corners of the stored image end up.
+	tests := []struct {
+		orientation       int
+		topLeft, topRight image.Point
+	}{
+		{1, image.Pt(0, 0), image.Pt(w-1, 0)},
+		{2, image.Pt(w-1, 0), image.Pt(0, 0)},
+		{3, image.Pt(w-1, h-1), image.Pt(0, h-1)},
+		{4, image.Pt(0, h-1), image.Pt(w-1, h-1)},
+		{5, image.Pt(0, 0), image.Pt(0, w-1)},
+		{6, image.Pt(h-1, 0), image.Pt(h-1, w-1)},
+		{7, image.Pt(h-1, w-1), image.Pt(h-1, 0)},
+		{8, image.Pt(0, w-1), image.Pt(0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(string(rune('0'+tt.orientation)), func(t *testing.T) {
+			t.Parallel()
+			fixture := pngFixture(t, gradient(w, h), tt.orientation)
+
+			out := transform(t, imgproc.NormalizeOrientation(0), fixture)
+			if tt.orientation == 1 {
+				assert.Equal(t, fixture, out)
+				return
+			}
+
+			img, err := png.Decode(bytes.NewReader(out))
+			require.NoError(t, err)
+			size := image.Pt(w, h)
+			if tt.orientation >= 5 {
+				size = image.Pt(h, w)
+			}
+			assert.Equal(t, size, img.Bounds().Size())
+			assert.Equal(t, red, color.NRGBAModel.Convert(img.At(tt.topLeft.X, tt.topLeft.Y)))
+			assert.Equal(t, green, color.NRGBAModel.Convert(img.At(tt.topRight.X, tt.topRight.Y)))
+
+			assert.NotContains(t, string(out), "eXIf")
+			assert.Contains(t, string(out), string(iccData))
+		})
+	}
+}
+
+func TestNormalizeOrientationJPEG(t *testing.T) {
+	t.Parallel()
+	fixture := jpegFixture(t, quadrants(64, 32), 6)
+
+	out := transform(t, imgproc.NormalizeOrientation(80), fixture)
+	assertNoMetadata(t, out)
+	assert.Contains(t, string(out), string(iccData))
+
+	img, err := jpeg.Decode(bytes.NewReader(out))
+	require.NoError(t, err)
+	assert.Equal(t, image.Pt(32, 64), img.Bounds().Size())
+
+	// Rotated clockwise, the blue bottom half is on the left, red is top
+	// right and green bottom right.
+	at := func(x, y int) color.RGBA {
+		r, g, b, _ := img.At(x, y).RGBA()
+		return color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8)}
+	}
+	dominant := func(c color.RGBA) string {
+		switch {
+		case c.R > 200 && c.G < 60 && c.B < 60:
+			return "red"
+		case c.G > 200 && c.R < 60 && c.B < 60:
+			return "green"
+		case c.B > 200 && c.R < 60 && c.G < 60:
+			return "blue"
+		}
+		return "mixed"
+	}
+	assert.Equal(t, "blue", dominant(at(8, 32)))
+	assert.Equal(t, "red", dominant(at(24, 16)))
+	assert.Equal(t, "green", dominant(at(24, 48)))
+}
+
+func TestNormalizeOrientationUpright(t *testing.T) {
+	t.Parallel()
+	fixture := jpegFixture(t, quadrants(16, 16), 1)
+	assert.Equal(t, fixture, transform(t, imgproc.NormalizeOrientation(0), fixture))
+}
+
+func TestUploadTransforms(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+
+	var seen []string
+	custom := func(fileName string, r io.Reader) (io.Reader, error) {
+		seen = append(seen, fileName)
+		return io.MultiReader(r, strings.NewReader("")), nil
+	}
+	sdk := f.SDK(sdkgo.WithUploadTransforms(imgproc.NormalizeOrientation(0), imgproc.StripMetadata()), sdkgo.WithUploadTransforms(custom))
+
+	fixture := jpegFixture(t, quadrants(64, 32), 6)
+	res, err := sdk.Images.Upload(context.Background(), &operations.UploadImageRequestBody{
+		File: &operations.File{FileName: "photo.jpg", Content: bytes.NewReader(fixture)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"photo.jpg"}, seen)
+
+	stored := f.Image(res.GetUploadResponse().ID).Data
+	assertNoMetadata(t, stored)
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(stored))
+	require.NoError(t, err)
+	assert.Equal(t, 32, cfg.Width)
+	assert.Equal(t, 64, cfg.Height)
+
+	failing := f.SDK(sdkgo.WithUploadTransforms(func(string, io.Reader) (io.Reader, error) {
+		return nil, errors.New("rejected")
+	}))
+	_, err = failing.Images.Upload(context.Background(), &operations.UploadImageRequestBody{
+		File: &operations.File{FileName: "photo.jpg", Content: fixture},
+	})
+	assert.ErrorContains(t, err, "rejected")
+	assert.Equal(t, 1, f.Len())
+}
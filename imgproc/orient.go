@@ -0,0 +1,231 @@
+package imgproc
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"slices"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+)
+
+// DefaultQuality is the JPEG quality used when re-encoding with quality 0.
+const DefaultQuality = 90
+
+// pngColorChunks describe the color space of a PNG and are carried over when
+// it is re-encoded.
+var pngColorChunks = map[string]bool{"iCCP": true, "sRGB": true, "gAMA": true, "cHRM": true}
+
+// NormalizeOrientation returns a transform that rotates and flips JPEG and
+// PNG files as their EXIF orientation says, so that they display upright
+// without it. Such files are decoded and re-encoded, JPEGs with quality, and
+// keep their color profile but no other metadata. Files without an
+// orientation other than the identity pass through unchanged.
+func NormalizeOrientation(quality int) sdkgo.UploadTransform {
+	if quality <= 0 {
+		quality = DefaultQuality
+	}
+
+	return func(_ string, r io.Reader) (io.Reader, error) {
+		br := bufio.NewReader(r)
+		head, _ := br.Peek(len(pngSignature))
+		isJPEG := bytes.HasPrefix(head, []byte{0xff, 0xd8})
+		if !isJPEG && !bytes.Equal(head, pngSignature) {
+			return br, nil
+		}
+
+		data, err := io.ReadAll(br)
+		if err != nil {
+			return nil, err
+		}
+		if isJPEG {
+			return orientJPEG(data, quality)
+		}
+		return orientPNG(data)
+	}
+}
+
+func orientJPEG(data []byte, quality int) (io.Reader, error) {
+	meta, err := readJPEGMeta(data)
+	if err != nil || meta.orientation == 1 {
+		return bytes.NewReader(data), err
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	out, err := meta.encode(orient(img, meta.orientation), quality)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(out), nil
+}
+
+func orientPNG(data []byte) (io.Reader, error) {
+	meta, err := readPNGMeta(data)
+	if err != nil || meta.orientation == 1 {
+		return bytes.NewReader(data), err
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	out, err := meta.encode(orient(img, meta.orientation))
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(out), nil
+}
+
+// jpegMeta is the metadata of a JPEG that survives re-encoding.
+type jpegMeta struct {
+	orientation int
+	icc         [][]byte
+}
+
+func readJPEGMeta(data []byte) (jpegMeta, error) {
+	meta := jpegMeta{orientation: 1}
+	err := eachJPEGSegment(bufio.NewReader(bytes.NewReader(data)), func(marker byte, segment []byte) error {
+		switch {
+		case marker == 0xe1 && bytes.HasPrefix(segment[4:], exifHeader):
+			meta.orientation = exifOrientation(segment[4+len(exifHeader):])
+		case marker == 0xe2 && bytes.HasPrefix(segment[4:], []byte("ICC_PROFILE\x00")):
+			meta.icc = append(meta.icc, segment)
+		}
+		return nil
+	}, func(io.Reader) error { return nil })
+	return meta, err
+}
+
+// encode encodes img as a JPEG with the color profile of the original right
+// after the start of image marker.
+func (m jpegMeta) encode(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	out := buf.Bytes()
+	return slices.Concat(out[:2], bytes.Join(m.icc, nil), out[2:]), nil
+}
+
+// pngMeta is the metadata of a PNG that survives re-encoding.
+type pngMeta struct {
+	orientation int
+	color       []byte
+}
+
+func readPNGMeta(data []byte) (pngMeta, error) {
+	meta := pngMeta{orientation: 1}
+	err := eachPNGChunk(bytes.NewReader(data), func(typ string, header []byte, body io.Reader) error {
+		switch {
+		case typ == "eXIf":
+			tiff, err := io.ReadAll(body)
+			if err != nil {
+				return err
+			}
+			meta.orientation = exifOrientation(tiff[:max(len(tiff)-4, 0)])
+		case pngColorChunks[typ]:
+			rest, err := io.ReadAll(body)
+			if err != nil {
+				return err
+			}
+			meta.color = append(append(meta.color, header...), rest...)
+		}
+		return nil
+	})
+	return meta, err
+}
+
+// encode encodes img as a PNG with the color chunks of the original right
+// after IHDR, which the encoder writes first.
+func (m pngMeta) encode(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	out := buf.Bytes()
+	ihdrEnd := len(pngSignature) + 25
+	return slices.Concat(out[:ihdrEnd], m.color, out[ihdrEnd:]), nil
+}
+
+// orient returns img transformed as EXIF orientation o says.
+func orient(img image.Image, o int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if o >= 5 {
+		dw, dh = h, w
+	}
+
+	// source maps a pixel of the result to the pixel of img it shows.
+	source := func(x, y int) (int, int) {
+		switch o {
+		case 2:
+			return w - 1 - x, y
+		case 3:
+			return w - 1 - x, h - 1 - y
+		case 4:
+			return x, h - 1 - y
+		case 5:
+			return y, x
+		case 6:
+			return y, h - 1 - x
+		case 7:
+			return w - 1 - y, h - 1 - x
+		case 8:
+			return w - 1 - y, x
+		}
+		return x, y
+	}
+
+	switch src := img.(type) {
+	case *image.NRGBA:
+		dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+		copyPixels(dst.Pix, dst.Stride, src.Pix, src.Stride, 4, dw, dh, source)
+		return dst
+	case *image.Gray:
+		dst := image.NewGray(image.Rect(0, 0, dw, dh))
+		copyPixels(dst.Pix, dst.Stride, src.Pix, src.Stride, 1, dw, dh, source)
+		return dst
+	case *image.Paletted:
+		dst := image.NewPaletted(image.Rect(0, 0, dw, dh), src.Palette)
+		copyPixels(dst.Pix, dst.Stride, src.Pix, src.Stride, 1, dw, dh, source)
+		return dst
+	case *image.RGBA64, *image.NRGBA64, *image.Gray16:
+		dst := image.NewNRGBA64(image.Rect(0, 0, dw, dh))
+		for y := 0; y < dh; y++ {
+			for x := 0; x < dw; x++ {
+				sx, sy := source(x, y)
+				dst.Set(x, y, img.At(b.Min.X+sx, b.Min.Y+sy))
+			}
+		}
+		return dst
+	}
+
+	// Everything else, notably the YCbCr images of JPEGs, is converted to
+	// RGBA first.
+	rgba, ok := img.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(image.Rect(0, 0, w, h))
+		draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	copyPixels(dst.Pix, dst.Stride, rgba.Pix, rgba.Stride, 4, dw, dh, source)
+	return dst
+}
+
+func copyPixels(dst []byte, dstStride int, src []byte, srcStride, size, dw, dh int, source func(x, y int) (int, int)) {
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			sx, sy := source(x, y)
+			copy(dst[y*dstStride+x*size:][:size], src[sy*srcStride+sx*size:][:size])
+		}
+	}
+}
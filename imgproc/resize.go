@@ -0,0 +1,91 @@
+package imgproc
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// fit returns the largest size with the aspect ratio of w×h within
+// maxWidth×maxHeight, where a zero bound is no bound, and whether it is
+// smaller than w×h.
+func fit(w, h, maxWidth, maxHeight int) (int, int, bool) {
+	ratio := 1.0
+	if maxWidth > 0 && w > maxWidth {
+		ratio = float64(maxWidth) / float64(w)
+	}
+	if maxHeight > 0 && h > maxHeight {
+		ratio = min(ratio, float64(maxHeight)/float64(h))
+	}
+	if ratio == 1 {
+		return w, h, false
+	}
+	return max(1, int(math.Round(float64(w)*ratio))), max(1, int(math.Round(float64(h)*ratio))), true
+}
+
+type contribution struct {
+	index  int
+	weight float32
+}
+
+// areaWeights returns, for every pixel of a row of length dst, the pixels of
+// a row of length src it covers and how much of it each covers.
+func areaWeights(src, dst int) [][]contribution {
+	scale := float64(src) / float64(dst)
+	weights := make([][]contribution, dst)
+	for i := range weights {
+		lo, hi := float64(i)*scale, float64(i+1)*scale
+		for j := int(lo); j < src && float64(j) < hi; j++ {
+			if w := min(hi, float64(j+1)) - max(lo, float64(j)); w > 0 {
+				weights[i] = append(weights[i], contribution{j, float32(w / scale)})
+			}
+		}
+	}
+	return weights
+}
+
+// Resize scales img to w×h, downscaling by averaging the area every pixel
+// covers, which keeps fine detail without aliasing. The source is converted
+// to premultiplied RGBA first so that transparent pixels do not bleed. Use
+// Thumbnail to keep the aspect ratio.
+func Resize(img image.Image, w, h int) *image.RGBA {
+	src, ok := img.(*image.RGBA)
+	if !ok || src.Bounds().Min != (image.Point{}) {
+		b := img.Bounds()
+		src = image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	}
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+
+	xs, ys := areaWeights(sw, w), areaWeights(sh, h)
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	row := make([]float32, 4*w)
+	acc := make([]float32, 4*w)
+
+	for y := 0; y < h; y++ {
+		clear(acc)
+		for _, cy := range ys[y] {
+			srow := src.Pix[cy.index*src.Stride:]
+			for x, cxs := range xs {
+				var r, g, b, a float32
+				for _, cx := range cxs {
+					p := srow[4*cx.index:]
+					r += cx.weight * float32(p[0])
+					g += cx.weight * float32(p[1])
+					b += cx.weight * float32(p[2])
+					a += cx.weight * float32(p[3])
+				}
+				row[4*x], row[4*x+1], row[4*x+2], row[4*x+3] = r, g, b, a
+			}
+			for i, v := range row {
+				acc[i] += cy.weight * v
+			}
+		}
+
+		drow := dst.Pix[y*dst.Stride:]
+		for i, v := range acc {
+			drow[i] = uint8(min(255, max(0, v+0.5)))
+		}
+	}
+	return dst
+}
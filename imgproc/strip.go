@@ -0,0 +1,207 @@
+// Package imgproc provides upload transforms for use with
+// sdkgo.WithUploadTransforms, processing images on the client before they
+// are uploaded, and helpers to decode and downscale images.
+package imgproc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// ErrMalformed is returned by transforms for JPEG and PNG files whose
+// structure cannot be parsed.
+var ErrMalformed = errors.New("malformed image")
+
+// StripMetadata returns a transform that removes EXIF, XMP and IPTC metadata,
+// such as the GPS position, camera and author, from JPEG and PNG files
+// without re-encoding them. Color profiles are kept. Other files pass
+// through unchanged.
+//
+// The orientation is metadata too, so put NormalizeOrientation first in the
+// chain to keep photos upright.
+func StripMetadata() sdkgo.UploadTransform {
+	return func(_ string, r io.Reader) (io.Reader, error) {
+		br := bufio.NewReader(r)
+		head, _ := br.Peek(len(pngSignature))
+		switch {
+		case bytes.HasPrefix(head, []byte{0xff, 0xd8}):
+			return pipe(func(w io.Writer) error { return stripJPEG(w, br) }), nil
+		case bytes.Equal(head, pngSignature):
+			return pipe(func(w io.Writer) error { return stripPNG(w, br) }), nil
+		}
+		return br, nil
+	}
+}
+
+// pipe returns a reader streaming what fn writes. Closing the reader stops
+// fn.
+func pipe(fn func(w io.Writer) error) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(fn(pw))
+	}()
+	return pr
+}
+
+// strippedJPEGSegment reports whether a JPEG segment carries metadata:
+// APP1 holds EXIF and XMP, APP13 holds IPTC and COM holds free text.
+func strippedJPEGSegment(marker byte) bool {
+	return marker == 0xe1 || marker == 0xed || marker == 0xfe
+}
+
+// stripJPEG copies a JPEG file without its metadata segments. Everything from
+// the first scan on is copied verbatim.
+func stripJPEG(w io.Writer, r *bufio.Reader) error {
+	return eachJPEGSegment(r, func(marker byte, segment []byte) error {
+		if strippedJPEGSegment(marker) {
+			return nil
+		}
+		_, err := w.Write(segment)
+		return err
+	}, func(rest io.Reader) error {
+		_, err := io.Copy(w, rest)
+		return err
+	})
+}
+
+// eachJPEGSegment calls fn with every marker segment before the first scan,
+// including its marker and length, and then scan with the reader positioned
+// at the start of the scan segment.
+func eachJPEGSegment(r *bufio.Reader, fn func(marker byte, segment []byte) error, scan func(rest io.Reader) error) error {
+	var soi [2]byte
+	if _, err := io.ReadFull(r, soi[:]); err != nil || soi != [2]byte{0xff, 0xd8} {
+		return fmt.Errorf("%w: missing JPEG start of image", ErrMalformed)
+	}
+	if err := fn(0xd8, soi[:]); err != nil {
+		return err
+	}
+
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return fmt.Errorf("%w: truncated JPEG: %v", ErrMalformed, err)
+		}
+		if b != 0xff {
+			return fmt.Errorf("%w: expected JPEG marker, got %#x", ErrMalformed, b)
+		}
+		marker := byte(0xff)
+		for marker == 0xff {
+			if marker, err = r.ReadByte(); err != nil {
+				return fmt.Errorf("%w: truncated JPEG: %v", ErrMalformed, err)
+			}
+		}
+
+		switch {
+		case marker == 0xda:
+			return scan(io.MultiReader(bytes.NewReader([]byte{0xff, 0xda}), r))
+		case marker == 0xd9:
+			return fn(marker, []byte{0xff, 0xd9})
+		case marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7):
+			if err := fn(marker, []byte{0xff, marker}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var length [2]byte
+		if _, err := io.ReadFull(r, length[:]); err != nil {
+			return fmt.Errorf("%w: truncated JPEG: %v", ErrMalformed, err)
+		}
+		n := int(binary.BigEndian.Uint16(length[:]))
+		if n < 2 {
+			return fmt.Errorf("%w: invalid JPEG segment length %d", ErrMalformed, n)
+		}
+		segment := make([]byte, 2+n)
+		segment[0], segment[1] = 0xff, marker
+		copy(segment[2:], length[:])
+		if _, err := io.ReadFull(r, segment[4:]); err != nil {
+			return fmt.Errorf("%w: truncated JPEG: %v", ErrMalformed, err)
+		}
+		if err := fn(marker, segment); err != nil {
+			return err
+		}
+	}
+}
+
+// strippedPNGChunk reports whether a PNG chunk carries metadata: eXIf holds
+// EXIF, the text chunks hold XMP, IPTC and free text, and tIME the
+// modification time.
+func strippedPNGChunk(typ string) bool {
+	switch typ {
+	case "eXIf", "tEXt", "zTXt", "iTXt", "tIME":
+		return true
+	}
+	return false
+}
+
+// stripPNG copies a PNG file without its metadata chunks.
+func stripPNG(w io.Writer, r io.Reader) error {
+	if _, err := w.Write(pngSignature); err != nil {
+		return err
+	}
+	return eachPNGChunk(r, func(typ string, header []byte, body io.Reader) error {
+		if strippedPNGChunk(typ) {
+			return nil
+		}
+		if _, err := w.Write(header); err != nil {
+			return err
+		}
+		_, err := io.Copy(w, body)
+		return err
+	})
+}
+
+// eachPNGChunk calls fn with every chunk of a PNG file up to and including
+// IEND, passing its length and type as header and its data and CRC as body.
+// Whatever fn does not read of body is skipped.
+func eachPNGChunk(r io.Reader, fn func(typ string, header []byte, body io.Reader) error) error {
+	var sig [8]byte
+	if _, err := io.ReadFull(r, sig[:]); err != nil || !bytes.Equal(sig[:], pngSignature) {
+		return fmt.Errorf("%w: missing PNG signature", ErrMalformed)
+	}
+
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return fmt.Errorf("%w: truncated PNG: %v", ErrMalformed, err)
+		}
+		n := int64(binary.BigEndian.Uint32(header[:4]))
+		if n > 1<<31-1 {
+			return fmt.Errorf("%w: invalid PNG chunk length %d", ErrMalformed, n)
+		}
+
+		typ := string(header[4:])
+		body := &countingReader{r: io.LimitReader(r, n+4)}
+		if err := fn(typ, header[:], body); err != nil {
+			return err
+		}
+		if _, err := io.Copy(io.Discard, body); err != nil {
+			return err
+		}
+		if body.n != n+4 {
+			return fmt.Errorf("%w: truncated PNG", ErrMalformed)
+		}
+		if typ == "IEND" {
+			return nil
+		}
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,255 @@
+package sdkgo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/img-src-io/sdk-go/internal/sniff"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/img-src-io/sdk-go/models/operations"
+)
+
+// inspectHeaderSize is how much of a file Inspect keeps to read its header.
+const inspectHeaderSize = 1 << 20
+
+// inspectTailSize is how much of the end of a file Inspect keeps to check
+// that it is complete.
+const inspectTailSize = 1 << 10
+
+var (
+	// ErrUnknownImageFormat is returned for files that are not a supported
+	// image format.
+	ErrUnknownImageFormat = errors.New("unknown image format")
+	// ErrCorruptImage is returned for images with a malformed header or a
+	// missing end marker.
+	ErrCorruptImage = errors.New("corrupt image")
+	// ErrImageTooLarge is returned for files larger than UploadLimits.MaxSize.
+	ErrImageTooLarge = errors.New("image file too large")
+	// ErrImageDimensions is returned for images exceeding the dimension limits.
+	ErrImageDimensions = errors.New("image dimensions exceed the limit")
+	// ErrImageFormatNotAllowed is returned for formats not in
+	// UploadLimits.AllowedFormats.
+	ErrImageFormatNotAllowed = errors.New("image format not allowed")
+	// ErrStorageLimit is returned when an upload would exceed the storage of
+	// the plan.
+	ErrStorageLimit = errors.New("upload would exceed the storage limit")
+	// ErrUploadLimit is returned when the monthly uploads of the plan are used
+	// up.
+	ErrUploadLimit = errors.New("monthly upload limit reached")
+)
+
+// ImageInfo describes an image file.
+type ImageInfo struct {
+	// Format is "jpeg", "png", "gif", "webp", "avif", "jxl", "heic" or
+	// "heif".
+	Format   string
+	MIMEType string
+	Width    int
+	Height   int
+	// Size is the file size in bytes.
+	Size int64
+}
+
+// Inspect reads an image file and reports its format, dimensions and size. It
+// parses headers only, without decoding pixels, and checks that JPEG, PNG and
+// GIF files are complete. The reader is consumed.
+func (s *Images) Inspect(r io.Reader) (*ImageInfo, error) {
+	head := make([]byte, inspectHeaderSize)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	head = head[:n]
+	if n == 0 {
+		return nil, fmt.Errorf("%w: empty file", ErrUnknownImageFormat)
+	}
+
+	tail := &tailWriter{buf: make([]byte, 0, inspectTailSize)}
+	_, _ = tail.Write(head)
+	rest, err := io.Copy(tail, r)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ImageInfo{MIMEType: sniff.ContentType(head), Size: int64(n) + rest}
+	info.Format = strings.TrimPrefix(info.MIMEType, "image/")
+
+	switch info.Format {
+	case "jpeg", "png", "gif":
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(head))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorruptImage, err)
+		}
+		info.Width, info.Height = cfg.Width, cfg.Height
+		if !complete(info.Format, tail.buf) {
+			return nil, fmt.Errorf("%w: truncated %s file", ErrCorruptImage, info.Format)
+		}
+	case "webp":
+		info.Width, info.Height, err = sniff.WebPSize(head)
+	case "avif", "heic", "heif":
+		info.Width, info.Height, err = sniff.HEIFSize(head)
+	case "jxl":
+		info.Width, info.Height, err = sniff.JXLSize(head)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownImageFormat, info.MIMEType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptImage, err)
+	}
+
+	return info, nil
+}
+
+// complete reports whether a JPEG, PNG or GIF file ends with its end marker.
+// JPEG files may carry trailing padding after it.
+func complete(format string, tail []byte) bool {
+	switch format {
+	case "jpeg":
+		return bytes.Contains(tail, []byte{0xff, 0xd9})
+	case "png":
+		return bytes.HasSuffix(tail, []byte("IEND\xaeB`\x82"))
+	case "gif":
+		return bytes.HasSuffix(tail, []byte{0x3b})
+	}
+	return true
+}
+
+// tailWriter keeps the last cap(buf) bytes written to it.
+type tailWriter struct {
+	buf []byte
+}
+
+func (t *tailWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(p) >= cap(t.buf) {
+		t.buf = append(t.buf[:0], p[len(p)-cap(t.buf):]...)
+		return n, nil
+	}
+	if over := len(t.buf) + len(p) - cap(t.buf); over > 0 {
+		t.buf = append(t.buf[:0], t.buf[over:]...)
+	}
+	t.buf = append(t.buf, p...)
+	return n, nil
+}
+
+// UploadLimits are the checks UploadValidated runs before uploading.
+type UploadLimits struct {
+	// MaxSize is the largest file size in bytes. Zero means no limit.
+	MaxSize int64
+	// MaxWidth and MaxHeight bound the dimensions. Zero means no limit.
+	MaxWidth  int
+	MaxHeight int
+	// MaxPixels bounds width times height. Zero means no limit.
+	MaxPixels int64
+	// AllowedFormats lists the accepted ImageInfo.Format values. Empty
+	// accepts every format Inspect recognizes.
+	AllowedFormats []string
+	// Usage, if set, is checked against its PlanLimits: the upload must fit
+	// in the remaining storage and the monthly uploads must not be used up.
+	Usage *components.UsageResponse
+}
+
+// ValidationError is returned by Validate and UploadValidated when a file is
+// rejected. It wraps one of the ErrImage*, ErrStorageLimit or ErrUploadLimit
+// sentinels.
+type ValidationError struct {
+	Err error
+	// Info is nil when the file could not be inspected.
+	Info   *ImageInfo
+	Detail string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Detail == "" {
+		return e.Err.Error()
+	}
+	return e.Err.Error() + ": " + e.Detail
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Validate checks an inspected image against the limits.
+func (l UploadLimits) Validate(info *ImageInfo) error {
+	reject := func(err error, format string, args ...any) error {
+		return &ValidationError{Err: err, Info: info, Detail: fmt.Sprintf(format, args...)}
+	}
+
+	if len(l.AllowedFormats) > 0 && !slices.Contains(l.AllowedFormats, info.Format) {
+		return reject(ErrImageFormatNotAllowed, "%s is not one of %s", info.Format, strings.Join(l.AllowedFormats, ", "))
+	}
+	if l.MaxSize > 0 && info.Size > l.MaxSize {
+		return reject(ErrImageTooLarge, "%d bytes, limit is %d", info.Size, l.MaxSize)
+	}
+	if (l.MaxWidth > 0 && info.Width > l.MaxWidth) || (l.MaxHeight > 0 && info.Height > l.MaxHeight) {
+		return reject(ErrImageDimensions, "%dx%d, limit is %dx%d", info.Width, info.Height, l.MaxWidth, l.MaxHeight)
+	}
+	if l.MaxPixels > 0 && int64(info.Width)*int64(info.Height) > l.MaxPixels {
+		return reject(ErrImageDimensions, "%d pixels, limit is %d", int64(info.Width)*int64(info.Height), l.MaxPixels)
+	}
+
+	if u := l.Usage; u != nil {
+		if max := u.PlanLimits.MaxStorageBytes; max != nil && u.StorageUsedBytes+info.Size > *max {
+			return reject(ErrStorageLimit, "%d of %d bytes used", u.StorageUsedBytes, *max)
+		}
+		if max := u.PlanLimits.MaxUploadsPerMonth; max != nil && u.CurrentPeriod.Uploads >= *max {
+			return reject(ErrUploadLimit, "%d of %d uploads used", u.CurrentPeriod.Uploads, *max)
+		}
+	}
+	return nil
+}
+
+// UploadValidated inspects the file of an upload request and validates it
+// against limits before calling Upload, so that files the API would reject
+// or the plan cannot hold are never sent. The file content is buffered in
+// memory.
+func (s *Images) UploadValidated(ctx context.Context, request *operations.UploadImageRequestBody, limits UploadLimits, opts ...operations.Option) (*operations.UploadImageResponse, error) {
+	if request == nil || request.File == nil {
+		return nil, errors.New("missing file")
+	}
+
+	var data []byte
+	switch content := request.File.Content.(type) {
+	case []byte:
+		data = content
+	case io.Reader:
+		var buf bytes.Buffer
+		r := content
+		if limits.MaxSize > 0 {
+			r = io.LimitReader(content, limits.MaxSize+1)
+		}
+		if _, err := buf.ReadFrom(r); err != nil {
+			return nil, fmt.Errorf("error reading file: %w", err)
+		}
+		data = buf.Bytes()
+	default:
+		return nil, fmt.Errorf("unsupported file content %T", content)
+	}
+	if limits.MaxSize > 0 && int64(len(data)) > limits.MaxSize {
+		return nil, &ValidationError{Err: ErrImageTooLarge, Detail: fmt.Sprintf("more than %d bytes", limits.MaxSize)}
+	}
+
+	info, err := s.Inspect(bytes.NewReader(data))
+	if err != nil {
+		return nil, &ValidationError{Err: errors.Unwrap(err), Detail: err.Error()}
+	}
+	if err := limits.Validate(info); err != nil {
+		return nil, err
+	}
+
+	file := *request.File
+	file.Content = data
+	req := *request
+	req.File = &file
+	return s.Upload(ctx, &req, opts...)
+}
@@ -0,0 +1,180 @@
+package sdkgo_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/internal/fakeapi"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/img-src-io/sdk-go/models/operations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testImage(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		img.Set(x, x%h, color.RGBA{R: uint8(x), A: 255})
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, testImage(w, h)))
+	return buf.Bytes()
+}
+
+// lossyWebP returns the header of a lossy WebP image of the given size.
+func lossyWebP(w, h int) []byte {
+	frame := []byte{0x10, 0x02, 0x00, 0x9d, 0x01, 0x2a, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint16(frame[6:], uint16(w))
+	binary.LittleEndian.PutUint16(frame[8:], uint16(h))
+	chunk := append([]byte("VP8 \x00\x00\x00\x00"), frame...)
+	binary.LittleEndian.PutUint32(chunk[4:], uint32(len(frame)))
+	data := append([]byte("RIFF\x00\x00\x00\x00WEBP"), chunk...)
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)-8))
+	return data
+}
+
+func TestInspect(t *testing.T) {
+	t.Parallel()
+	images := sdkgo.New().Images
+
+	var jpg, gf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&jpg, testImage(40, 30), nil))
+	require.NoError(t, gif.Encode(&gf, testImage(16, 8), nil))
+	pngData := encodePNG(t, 300, 200)
+	webp := lossyWebP(320, 240)
+
+	tests := []struct {
+		name   string
+		data   []byte
+		format string
+		w, h   int
+	}{
+		{"png", pngData, "png", 300, 200},
+		{"jpeg", jpg.Bytes(), "jpeg", 40, 30},
+		{"jpeg with padding", append(append([]byte{}, jpg.Bytes()...), 0, 0, 0), "jpeg", 40, 30},
+		{"gif", gf.Bytes(), "gif", 16, 8},
+		{"webp", webp, "webp", 320, 240},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			info, err := images.Inspect(bytes.NewReader(tt.data))
+			require.NoError(t, err)
+			assert.Equal(t, tt.format, info.Format)
+			assert.Equal(t, "image/"+tt.format, info.MIMEType)
+			assert.Equal(t, tt.w, info.Width)
+			assert.Equal(t, tt.h, info.Height)
+			assert.Equal(t, int64(len(tt.data)), info.Size)
+		})
+	}
+
+	t.Run("truncated", func(t *testing.T) {
+		t.Parallel()
+		for _, data := range [][]byte{pngData[:len(pngData)-20], jpg.Bytes()[:jpg.Len()-50], webp[:20]} {
+			_, err := images.Inspect(bytes.NewReader(data))
+			assert.ErrorIs(t, err, sdkgo.ErrCorruptImage)
+		}
+	})
+
+	t.Run("not an image", func(t *testing.T) {
+		t.Parallel()
+		_, err := images.Inspect(bytes.NewReader([]byte("%PDF-1.7\n")))
+		assert.ErrorIs(t, err, sdkgo.ErrUnknownImageFormat)
+		_, err = images.Inspect(bytes.NewReader(nil))
+		assert.ErrorIs(t, err, sdkgo.ErrUnknownImageFormat)
+	})
+}
+
+func TestUploadLimitsValidate(t *testing.T) {
+	t.Parallel()
+	info := &sdkgo.ImageInfo{Format: "png", MIMEType: "image/png", Width: 3000, Height: 2000, Size: 5 << 20}
+
+	tests := []struct {
+		name   string
+		limits sdkgo.UploadLimits
+		want   error
+	}{
+		{"no limits", sdkgo.UploadLimits{}, nil},
+		{"within limits", sdkgo.UploadLimits{MaxSize: 10 << 20, MaxWidth: 4000, MaxHeight: 4000, MaxPixels: 6_000_000, AllowedFormats: []string{"jpeg", "png"}}, nil},
+		{"format", sdkgo.UploadLimits{AllowedFormats: []string{"jpeg", "webp"}}, sdkgo.ErrImageFormatNotAllowed},
+		{"size", sdkgo.UploadLimits{MaxSize: 1 << 20}, sdkgo.ErrImageTooLarge},
+		{"width", sdkgo.UploadLimits{MaxWidth: 2048}, sdkgo.ErrImageDimensions},
+		{"height", sdkgo.UploadLimits{MaxHeight: 1080}, sdkgo.ErrImageDimensions},
+		{"pixels", sdkgo.UploadLimits{MaxPixels: 4_000_000}, sdkgo.ErrImageDimensions},
+		{"storage", sdkgo.UploadLimits{Usage: &components.UsageResponse{
+			StorageUsedBytes: 98 << 20,
+			PlanLimits:       components.PlanLimits{MaxStorageBytes: sdkgo.Int64(100 << 20)},
+		}}, sdkgo.ErrStorageLimit},
+		{"uploads", sdkgo.UploadLimits{Usage: &components.UsageResponse{
+			PlanLimits:    components.PlanLimits{MaxUploadsPerMonth: sdkgo.Int64(100)},
+			CurrentPeriod: components.CurrentPeriod{Uploads: 100},
+		}}, sdkgo.ErrUploadLimit},
+		{"unlimited plan", sdkgo.UploadLimits{Usage: &components.UsageResponse{
+			StorageUsedBytes: 1 << 40,
+			CurrentPeriod:    components.CurrentPeriod{Uploads: 1 << 20},
+		}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.limits.Validate(info)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tt.want)
+			var verr *sdkgo.ValidationError
+			require.ErrorAs(t, err, &verr)
+			assert.Same(t, info, verr.Info)
+		})
+	}
+}
+
+func TestUploadValidated(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	images := f.SDK().Images
+	limits := sdkgo.UploadLimits{MaxSize: 1 << 20, MaxWidth: 500, AllowedFormats: []string{"png", "webp"}}
+
+	res, err := images.UploadValidated(context.Background(), &operations.UploadImageRequestBody{
+		File:       &operations.File{FileName: "small.png", Content: bytes.NewReader(encodePNG(t, 100, 50))},
+		TargetPath: sdkgo.String("small.png"),
+	}, limits)
+	require.NoError(t, err)
+	require.NotNil(t, res.GetUploadResponse())
+	assert.Equal(t, 1, f.Len())
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"too wide", encodePNG(t, 600, 10), sdkgo.ErrImageDimensions},
+		{"corrupt", encodePNG(t, 100, 50)[:60], sdkgo.ErrCorruptImage},
+		{"not an image", []byte("hello"), sdkgo.ErrUnknownImageFormat},
+		{"too large", make([]byte, 2<<20), sdkgo.ErrImageTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := images.UploadValidated(context.Background(), &operations.UploadImageRequestBody{
+				File: &operations.File{FileName: tt.name + ".png", Content: tt.data},
+			}, limits)
+			require.ErrorIs(t, err, tt.want)
+			var verr *sdkgo.ValidationError
+			assert.ErrorAs(t, err, &verr)
+		})
+	}
+	assert.Equal(t, 1, f.Len())
+}
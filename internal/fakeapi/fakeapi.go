@@ -0,0 +1,721 @@
+// Package fakeapi is an in-memory stand-in for the img-src API used by the
+// tests of the library helpers. It covers the image, preset and settings
+// endpoints and serves originals from a fake CDN.
+package fakeapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+)
+
+// CDNBaseURL is the host of the CDN URLs of listed images. Unlike the
+// originals under /cdn/, they are not served.
+const CDNBaseURL = "https://cdn.example.test"
+
+// Image is an image stored by the server.
+type Image struct {
+	ID         string
+	Filename   string
+	Data       []byte
+	Hash       string
+	Paths      []string
+	Visibility string
+	UploadedAt time.Time
+	// ActiveSignedURL is the last signed URL minted, listed until it expires.
+	ActiveSignedURL    string
+	ActiveSignedURLExp int64
+}
+
+// Server is the fake API server.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	username string
+	images   map[string]*Image
+	presets  []map[string]any
+	settings map[string]any
+	nextID   int
+	signed   int
+	calls    map[string]int
+	fail     func(r *http.Request) int
+	// retryAfter is the Retry-After header of injected 429 responses.
+	retryAfter string
+}
+
+// New starts a server for the duration of the test.
+func New(t testing.TB) *Server {
+	t.Helper()
+	f := &Server{
+		username: "alice",
+		images:   map[string]*Image{},
+		calls:    map[string]int{},
+		settings: map[string]any{
+			"delivery_formats": []any{"avif", "webp", "jpeg"},
+			"default_quality":  80,
+			"default_fit_mode": "cover",
+			"theme":            "system",
+			"language":         "en",
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/images", f.list)
+	mux.HandleFunc("POST /api/v1/images", f.upload)
+	mux.HandleFunc("GET /api/v1/images/search", f.search)
+	mux.HandleFunc("GET /api/v1/images/{id}", f.get)
+	mux.HandleFunc("DELETE /api/v1/images/{id}", f.delete)
+	mux.HandleFunc("PATCH /api/v1/images/{id}/visibility", f.visibility)
+	mux.HandleFunc("POST /api/v1/images/{id}/signed-url", f.signedURL)
+	mux.HandleFunc("DELETE /api/v1/images/path/{username}/{filepath...}", f.deletePath)
+	mux.HandleFunc("GET /api/v1/settings", f.getSettings)
+	mux.HandleFunc("PUT /api/v1/settings", f.updateSettings)
+	mux.HandleFunc("GET /api/v1/settings/presets", f.listPresets)
+	mux.HandleFunc("POST /api/v1/settings/presets", f.createPreset)
+	mux.HandleFunc("PUT /api/v1/settings/presets/{id}", f.updatePreset)
+	mux.HandleFunc("GET /cdn/{id}", f.original)
+
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := mux.Handler(r)
+		f.mu.Lock()
+		f.calls[pattern]++
+		fail, retryAfter := f.fail, f.retryAfter
+		f.mu.Unlock()
+
+		if fail != nil {
+			if status := fail(r); status != 0 {
+				if status == http.StatusTooManyRequests && retryAfter != "" {
+					w.Header().Set("Retry-After", retryAfter)
+				}
+				writeError(w, status, "injected failure")
+				return
+			}
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// SDK returns a client talking to the server.
+func (f *Server) SDK(opts ...sdkgo.SDKOption) *sdkgo.Imgsrc {
+	return sdkgo.New(append([]sdkgo.SDKOption{
+		sdkgo.WithServerURL(f.URL),
+		sdkgo.WithSecurity("imgsrc_test"),
+	}, opts...)...)
+}
+
+// Username is the account's username.
+func (f *Server) Username() string {
+	return f.username
+}
+
+// Fail installs fn to inspect every request before it is served. A non-zero
+// status code returned by fn fails the request with that status.
+func (f *Server) Fail(fn func(r *http.Request) int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fail = fn
+}
+
+// SetRetryAfter sets the Retry-After header of the 429 responses injected
+// with Fail. It defaults to zero seconds.
+func (f *Server) SetRetryAfter(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.retryAfter = strconv.Itoa(int(d / time.Second))
+}
+
+// Add stores an image directly, bypassing the upload endpoint, and returns
+// its ID. Identical data is deduplicated like uploads are.
+func (f *Server) Add(data string, paths ...string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	img := f.store([]byte(data), path.Base(paths[0]))
+	img.Paths = append(img.Paths, paths...)
+	return img.ID
+}
+
+// AddPreset stores a preset and returns its ID.
+func (f *Server) AddPreset(name string, params map[string]any) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.addPreset(name, nil, params)["id"].(string)
+}
+
+// Image returns a copy of the image, or nil if it does not exist.
+func (f *Server) Image(id string) *Image {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	img, ok := f.images[id]
+	if !ok {
+		return nil
+	}
+	cp := *img
+	cp.Paths = append([]string(nil), img.Paths...)
+	return &cp
+}
+
+// Images returns copies of every image sorted by ID.
+func (f *Server) Images() []*Image {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := []*Image{}
+	for _, img := range f.sorted() {
+		cp := *img
+		cp.Paths = append([]string(nil), img.Paths...)
+		out = append(out, &cp)
+	}
+	return out
+}
+
+// Update calls fn with the stored image.
+func (f *Server) Update(id string, fn func(img *Image)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fn(f.images[id])
+}
+
+// Presets returns the stored presets.
+func (f *Server) Presets() []map[string]any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]map[string]any(nil), f.presets...)
+}
+
+// Setting returns a stored setting.
+func (f *Server) Setting(name string) any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.settings[name]
+}
+
+// Count returns how many requests matched the route pattern, e.g.
+// "GET /api/v1/images/{id}".
+func (f *Server) Count(pattern string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[pattern]
+}
+
+// Len returns the number of stored images.
+func (f *Server) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.images)
+}
+
+// store must be called with f.mu held.
+func (f *Server) store(data []byte, filename string) *Image {
+	if img := f.findHash(data); img != nil {
+		return img
+	}
+
+	sum := sha256.Sum256(data)
+	f.nextID++
+	img := &Image{
+		ID:         fmt.Sprintf("img%03d", f.nextID),
+		Filename:   filename,
+		Data:       data,
+		Hash:       hex.EncodeToString(sum[:]),
+		Visibility: "public",
+		UploadedAt: time.Date(2026, 1, 1, 0, 0, f.nextID, 0, time.UTC),
+	}
+	f.images[img.ID] = img
+	return img
+}
+
+// findHash must be called with f.mu held.
+func (f *Server) findHash(data []byte) *Image {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	for _, img := range f.images {
+		if img.Hash == hash {
+			return img
+		}
+	}
+	return nil
+}
+
+// owner returns the image holding p. It must be called with f.mu held.
+func (f *Server) owner(p string) *Image {
+	for _, img := range f.images {
+		for _, q := range img.Paths {
+			if q == p {
+				return img
+			}
+		}
+	}
+	return nil
+}
+
+// sorted must be called with f.mu held.
+func (f *Server) sorted() []*Image {
+	out := make([]*Image, 0, len(f.images))
+	for _, img := range f.images {
+		out = append(out, img)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func (f *Server) list(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	folder := strings.Trim(r.URL.Query().Get("path"), "/")
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = 50
+	}
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	var images []map[string]any
+	folders := map[string]int{}
+	for _, img := range f.sorted() {
+		// Images without paths are listed at the root.
+		listed := folder == "" && len(img.Paths) == 0
+		for _, p := range img.Paths {
+			dir := path.Dir(p)
+			if dir == "." {
+				dir = ""
+			}
+			switch {
+			case dir == folder:
+				listed = true
+			case folder == "" || strings.HasPrefix(dir, folder+"/"):
+				rest := strings.TrimPrefix(strings.TrimPrefix(dir, folder), "/")
+				folders[strings.SplitN(rest, "/", 2)[0]]++
+			}
+		}
+		if listed {
+			images = append(images, f.listItem(img))
+		}
+	}
+
+	total := len(images)
+	if offset > total {
+		offset = total
+	}
+	end := min(offset+limit, total)
+
+	folderItems := []map[string]any{}
+	for name, n := range folders {
+		folderItems = append(folderItems, map[string]any{"name": name, "image_count": n})
+	}
+	sort.Slice(folderItems, func(i, j int) bool { return folderItems[i]["name"].(string) < folderItems[j]["name"].(string) })
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"images":   append([]map[string]any{}, images[offset:end]...),
+		"folders":  folderItems,
+		"total":    total,
+		"limit":    limit,
+		"offset":   offset,
+		"has_more": end < total,
+	})
+}
+
+func (f *Server) search(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	q := r.URL.Query().Get("q")
+	results := []map[string]any{}
+	for _, img := range f.sorted() {
+		match := strings.Contains(img.Filename, q)
+		for _, p := range img.Paths {
+			match = match || strings.Contains(p, q)
+		}
+		if match {
+			results = append(results, f.listItem(img))
+		}
+	}
+
+	total := len(results)
+	if limit, _ := strconv.Atoi(r.URL.Query().Get("limit")); limit > 0 && limit < total {
+		results = results[:limit]
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"results": results, "total": total, "query": q})
+}
+
+func (f *Server) get(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	img, ok := f.images[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "image not found")
+		return
+	}
+
+	original := f.URL + "/cdn/" + img.ID
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id": img.ID,
+		"metadata": map[string]any{
+			"hash":              img.Hash,
+			"original_filename": img.Filename,
+			"size":              len(img.Data),
+			"uploaded_at":       img.UploadedAt,
+			"mime_type":         http.DetectContentType(img.Data),
+		},
+		"urls":       map[string]any{"original": original, "webp": original, "avif": original, "jpeg": original, "png": original, "jxl": original},
+		"visibility": img.Visibility,
+		"_links":     map[string]any{"self": "/api/v1/images/" + img.ID, "delete": "/api/v1/images/" + img.ID},
+	})
+}
+
+func (f *Server) original(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	var data []byte
+	img, ok := f.images[r.PathValue("id")]
+	private := ok && img.Visibility == "private"
+	if ok {
+		data = img.Data
+	}
+	f.mu.Unlock()
+
+	switch {
+	case !ok:
+		http.NotFound(w, r)
+	case private && r.URL.Query().Get("sig") == "":
+		http.Error(w, "forbidden", http.StatusForbidden)
+	default:
+		_, _ = w.Write(data)
+	}
+}
+
+func (f *Server) signedURL(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	img, ok := f.images[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "image not found")
+		return
+	}
+
+	var body struct {
+		ExpiresInSeconds int64          `json:"expires_in_seconds"`
+		Transformation   map[string]any `json:"transformation"`
+	}
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}
+	if body.ExpiresInSeconds == 0 {
+		body.ExpiresInSeconds = 3600
+	}
+	if body.ExpiresInSeconds < 60 || body.ExpiresInSeconds > 86400 {
+		writeError(w, http.StatusBadRequest, "expires_in_seconds must be between 60 and 86400")
+		return
+	}
+
+	f.signed++
+	expiresAt := time.Now().Unix() + body.ExpiresInSeconds
+	signed := fmt.Sprintf("%s/cdn/%s?exp=%d&sig=fake%d", f.URL, img.ID, expiresAt, f.signed)
+	keys := make([]string, 0, len(body.Transformation))
+	for k := range body.Transformation {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		signed += fmt.Sprintf("&%s=%v", k, body.Transformation[k])
+	}
+	img.ActiveSignedURL, img.ActiveSignedURLExp = signed, expiresAt
+	writeJSON(w, http.StatusOK, map[string]any{
+		"signed_url":         signed,
+		"expires_at":         expiresAt,
+		"expires_in_seconds": body.ExpiresInSeconds,
+	})
+}
+
+func (f *Server) upload(w http.ResponseWriter, r *http.Request) {
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	target := strings.Trim(r.FormValue("target_path"), "/")
+	if target == "" {
+		target = header.Filename
+	}
+
+	existing := f.findHash(data)
+	if owner := f.owner(target); owner != nil && owner != existing {
+		writeError(w, http.StatusConflict, "path already exists")
+		return
+	}
+
+	img := f.store(data, header.Filename)
+	if f.owner(target) == nil {
+		img.Paths = append(img.Paths, target)
+	}
+	if v := r.FormValue("visibility"); v != "" {
+		img.Visibility = v
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"id":                img.ID,
+		"hash":              img.Hash,
+		"url":               "/" + f.username + "/" + target,
+		"paths":             img.Paths,
+		"is_new":            existing == nil,
+		"size":              len(img.Data),
+		"format":            strings.TrimPrefix(path.Ext(header.Filename), "."),
+		"available_formats": map[string]any{"webp": "", "avif": "", "jpeg": "", "png": "", "jxl": ""},
+		"uploaded_at":       img.UploadedAt,
+		"visibility":        img.Visibility,
+		"_links":            map[string]any{"self": "/api/v1/images/" + img.ID, "delete": "/api/v1/images/" + img.ID},
+	})
+}
+
+func (f *Server) delete(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	img, ok := f.images[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "image not found")
+		return
+	}
+	delete(f.images, img.ID)
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"success":       true,
+		"message":       "deleted",
+		"deleted_paths": img.Paths,
+		"deleted_at":    time.Now().UTC(),
+	})
+}
+
+func (f *Server) deletePath(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.PathValue("username") != f.username {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	p := r.PathValue("filepath")
+	img := f.owner(p)
+	if img == nil {
+		writeError(w, http.StatusNotFound, "path not found")
+		return
+	}
+
+	remaining := []string{}
+	for _, q := range img.Paths {
+		if q != p {
+			remaining = append(remaining, q)
+		}
+	}
+	img.Paths = remaining
+	if len(remaining) == 0 {
+		delete(f.images, img.ID)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"success":         true,
+		"message":         "path deleted",
+		"remaining_paths": remaining,
+		"image_deleted":   len(remaining) == 0,
+		"deleted_at":      time.Now().UTC(),
+	})
+}
+
+func (f *Server) visibility(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Visibility string `json:"visibility"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	img, ok := f.images[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "image not found")
+		return
+	}
+	img.Visibility = body.Visibility
+
+	writeJSON(w, http.StatusOK, map[string]any{"id": img.ID, "visibility": img.Visibility, "message": "updated"})
+}
+
+// userSettings must be called with f.mu held.
+func (f *Server) userSettings() map[string]any {
+	out := map[string]any{
+		"id":                 "user_1",
+		"username":           f.username,
+		"plan":               "free",
+		"created_at":         1700000000,
+		"updated_at":         1700000000,
+		"total_uploads":      len(f.images),
+		"storage_used_bytes": 0,
+	}
+	for k, v := range f.settings {
+		out[k] = v
+	}
+	return out
+}
+
+func (f *Server) getSettings(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"settings": f.userSettings()})
+}
+
+func (f *Server) updateSettings(w http.ResponseWriter, r *http.Request) {
+	var body map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for k, v := range body {
+		if v == nil {
+			delete(f.settings, k)
+			continue
+		}
+		f.settings[k] = v
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"settings": f.userSettings(), "message": "updated"})
+}
+
+// addPreset must be called with f.mu held.
+func (f *Server) addPreset(name string, description any, params map[string]any) map[string]any {
+	f.nextID++
+	preset := map[string]any{
+		"id":          fmt.Sprintf("preset%03d", f.nextID),
+		"name":        name,
+		"description": description,
+		"params":      params,
+		"created_at":  1700000000,
+		"updated_at":  1700000000,
+		"usage_count": 0,
+	}
+	f.presets = append(f.presets, preset)
+	return preset
+}
+
+func (f *Server) listPresets(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"presets": append([]map[string]any{}, f.presets...), "total": len(f.presets)})
+}
+
+func (f *Server) createPreset(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name        string         `json:"name"`
+		Description any            `json:"description"`
+		Params      map[string]any `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, p := range f.presets {
+		if p["name"] == body.Name {
+			writeError(w, http.StatusConflict, "preset already exists")
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusCreated, f.addPreset(body.Name, body.Description, body.Params))
+}
+
+func (f *Server) updatePreset(w http.ResponseWriter, r *http.Request) {
+	var body map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, p := range f.presets {
+		if p["id"] == r.PathValue("id") {
+			for k, v := range body {
+				p[k] = v
+			}
+			writeJSON(w, http.StatusOK, p)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "preset not found")
+}
+
+// listItem must be called with f.mu held.
+func (f *Server) listItem(img *Image) map[string]any {
+	item := map[string]any{
+		"id":                img.ID,
+		"original_filename": img.Filename,
+		"size":              len(img.Data),
+		"uploaded_at":       img.UploadedAt,
+		"url":               "/cdn/" + img.ID,
+		"paths":             img.Paths,
+		"visibility":        img.Visibility,
+	}
+	if len(img.Paths) > 0 {
+		item["cdn_url"] = CDNBaseURL + "/" + f.username + "/" + img.Paths[0]
+	}
+	if img.ActiveSignedURLExp > time.Now().Unix() {
+		item["active_signed_url"] = map[string]any{"signed_url": img.ActiveSignedURL, "expires_at": img.ActiveSignedURLExp}
+	}
+	return item
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	if status == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+		w.Header().Set("Retry-After", "0")
+	}
+	writeJSON(w, status, map[string]any{"error": map[string]any{
+		"code":    http.StatusText(status),
+		"message": message,
+		"status":  status,
+	}})
+}
@@ -0,0 +1,224 @@
+package hooks
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/img-src-io/sdk-go/cache"
+	"github.com/img-src-io/sdk-go/internal/config"
+)
+
+// cacheRule describes how an operation interacts with the response cache.
+// Read operations are stored under the tags returned by tags; mutating
+// operations bump the generation of those tags once they succeed.
+type cacheRule struct {
+	read bool
+	tags func(req *http.Request) []string
+}
+
+var cacheRules = map[string]cacheRule{
+	"getImage":    {read: true, tags: imageTags("images")},
+	"getPreset":   {read: true, tags: staticTags("presets")},
+	"listPresets": {read: true, tags: staticTags("presets")},
+	"getSettings": {read: true, tags: staticTags("settings")},
+	"getUsage":    {read: true, tags: staticTags("usage")},
+
+	"uploadImage":      {tags: staticTags("images", "usage")},
+	"deleteImage":      {tags: imageTags("usage")},
+	"updateVisibility": {tags: imageTags()},
+	"deleteImagePath":  {tags: staticTags("images", "usage")},
+	"createPreset":     {tags: staticTags("presets")},
+	"updatePreset":     {tags: staticTags("presets")},
+	"deletePreset":     {tags: staticTags("presets")},
+	"updateSettings":   {tags: staticTags("settings")},
+}
+
+func staticTags(tags ...string) func(*http.Request) []string {
+	return func(*http.Request) []string {
+		return tags
+	}
+}
+
+// imageTags tags a request with the image ID found in its /images/{id} path.
+func imageTags(extra ...string) func(*http.Request) []string {
+	return func(req *http.Request) []string {
+		tags := append([]string(nil), extra...)
+
+		segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+		for i := 0; i < len(segments)-1; i++ {
+			if segments[i] == "images" {
+				tags = append(tags, "image:"+segments[i+1])
+				break
+			}
+		}
+
+		return tags
+	}
+}
+
+// cacheHook holds the response cache configuration and the invalidation
+// generation of every tag.
+type cacheHook struct {
+	cfg   cache.Config
+	store cache.Store
+	now   func() time.Time
+
+	mu          sync.Mutex
+	generations map[string]uint64
+}
+
+var _ clientMiddleware = (*cacheHook)(nil)
+
+// ConfigureCache enables the response cache for read operations.
+func (h *Hooks) ConfigureCache(cfg cache.Config) {
+	c, ok := findClientMiddleware[*cacheHook](h)
+	if !ok {
+		c = &cacheHook{now: time.Now, generations: map[string]uint64{}}
+		h.registerClientMiddleware(c)
+	}
+
+	c.cfg = cfg
+	c.store = cfg.Store
+	if c.store == nil {
+		c.store = cache.NewLRU(cache.DefaultLRUSize)
+	}
+}
+
+func (c *cacheHook) layer() int {
+	return layerCache
+}
+
+func (c *cacheHook) wrap(next config.HTTPClient) config.HTTPClient {
+	return &cacheClient{hook: c, next: next}
+}
+
+func (c *cacheHook) versions(tags []string) map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	versions := make(map[string]uint64, len(tags))
+	for _, tag := range tags {
+		versions[tag] = c.generations[tag]
+	}
+	return versions
+}
+
+func (c *cacheHook) current(versions map[string]uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for tag, v := range versions {
+		if c.generations[tag] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *cacheHook) invalidate(tags []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, tag := range tags {
+		c.generations[tag]++
+	}
+}
+
+// cacheClient serves fresh entries without a round trip, revalidates expired
+// entries carrying an ETag with If-None-Match, and invalidates entries after
+// successful mutations.
+type cacheClient struct {
+	hook *cacheHook
+	next config.HTTPClient
+}
+
+func (c *cacheClient) Do(req *http.Request) (*http.Response, error) {
+	operationID := operationIDFromRequest(req)
+	rule, ok := cacheRules[operationID]
+	if !ok {
+		return c.next.Do(req)
+	}
+
+	if !rule.read {
+		res, err := c.next.Do(req)
+		if err == nil && res != nil && res.StatusCode >= 200 && res.StatusCode < 300 {
+			c.hook.invalidate(rule.tags(req))
+		}
+		return res, err
+	}
+
+	ttl := c.hook.cfg.TTL(operationID)
+	if req.Method != http.MethodGet || ttl < 0 {
+		return c.next.Do(req)
+	}
+
+	key := requestKey(operationID, req)
+	now := c.hook.now()
+	noCache := strings.Contains(req.Header.Get("Cache-Control"), "no-cache")
+
+	entry, ok := c.hook.store.Get(key)
+	if ok && !c.hook.current(entry.Versions) {
+		c.hook.store.Delete(key)
+		entry, ok = nil, false
+	}
+	if ok && !noCache && entry.Fresh(now) {
+		// The response never reaches the circuit breaker client, so the
+		// probe slot this request may hold is given back.
+		releaseCircuitTicket(req)
+		return replayResponse(entry.StatusCode, entry.Header, entry.Body, req), nil
+	}
+
+	// Capture the generations before the round trip so a mutation that
+	// completes in the meantime is not masked by a stale response.
+	versions := c.hook.versions(rule.tags(req))
+
+	outReq := req
+	if ok && entry.ETag != "" {
+		outReq = req.Clone(req.Context())
+		outReq.Header.Set("If-None-Match", entry.ETag)
+	}
+
+	res, err := c.next.Do(outReq)
+	if err != nil || res == nil {
+		return res, err
+	}
+
+	if res.StatusCode == http.StatusNotModified && ok && outReq != req {
+		res.Body.Close()
+
+		refreshed := *entry
+		refreshed.ExpiresAt = now.Add(ttl)
+		refreshed.Versions = versions
+		c.hook.store.Set(key, &refreshed)
+
+		releaseCircuitTicket(req)
+		return replayResponse(refreshed.StatusCode, refreshed.Header, refreshed.Body, req), nil
+	}
+
+	if res.StatusCode != http.StatusOK || strings.Contains(res.Header.Get("Cache-Control"), "no-store") {
+		return res, nil
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	c.hook.store.Set(key, &cache.Entry{
+		StatusCode: res.StatusCode,
+		Header:     res.Header.Clone(),
+		Body:       body,
+		ETag:       res.Header.Get("ETag"),
+		StoredAt:   now,
+		ExpiresAt:  now.Add(ttl),
+		Versions:   versions,
+	})
+
+	return res, nil
+}
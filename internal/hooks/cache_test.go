@@ -0,0 +1,67 @@
+package hooks
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/img-src-io/sdk-go/cache"
+	"github.com/img-src-io/sdk-go/internal/config"
+	"github.com/img-src-io/sdk-go/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingClient struct {
+	calls int
+}
+
+func (c *countingClient) Do(req *http.Request) (*http.Response, error) {
+	c.calls++
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader([]byte(`{"id":"abc123"}`))),
+		Request:    req,
+	}, nil
+}
+
+func TestCache_ReleasesProbeOnCacheHit(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	h := New()
+	h.ConfigureCircuitBreaker("getImage", retry.CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Second})
+	h.findCircuitBreaker().now = clock.now
+	h.ConfigureCache(cache.Config{DefaultTTL: time.Hour})
+
+	next := &countingClient{}
+	client := h.SDKInit(config.SDKConfiguration{Client: next}).Client
+	hookCtx := BeforeRequestContext{HookContext: HookContext{OperationID: "getImage"}}
+	do := func() error {
+		req, err := http.NewRequest(http.MethodGet, "https://api.img-src.io/api/v1/images/abc123", nil)
+		require.NoError(t, err)
+		req, err = h.BeforeRequest(hookCtx, req)
+		if err != nil {
+			return err
+		}
+		res, err := client.Do(req)
+		require.NoError(t, err)
+		res.Body.Close()
+		return nil
+	}
+
+	require.NoError(t, do())
+	b := h.findCircuitBreaker().breaker("getImage")
+	b.record(false, true)
+	require.Equal(t, retry.CircuitOpen, b.currentState())
+
+	// The probe admitted once the circuit is half-open is served from the
+	// cache, which must not keep its slot.
+	clock.advance(time.Second)
+	require.NoError(t, do())
+	assert.Equal(t, retry.CircuitHalfOpen, b.currentState())
+	require.NoError(t, do())
+	assert.Equal(t, 1, next.calls)
+}
@@ -0,0 +1,326 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/img-src-io/sdk-go/internal/config"
+	"github.com/img-src-io/sdk-go/retry"
+)
+
+const (
+	defaultFailureThreshold = 5
+	defaultOpenTimeout      = 30 * time.Second
+	defaultHalfOpenProbes   = 1
+)
+
+type circuitTicketKey struct{}
+
+// circuitTicket travels with an admitted request so the wrapped HTTP client
+// can report the outcome of each attempt to the right circuit.
+type circuitTicket struct {
+	breaker *circuitBreaker
+	probe   bool
+	settled atomic.Bool
+}
+
+// settle reports whether this is the first outcome reported for the ticket,
+// so that a request both recorded and released frees its probe slot once.
+func (t *circuitTicket) settle() bool {
+	return t.settled.CompareAndSwap(false, true)
+}
+
+// circuitBreakerHook keeps one circuit per operation ID. BeforeRequest rejects
+// requests while a circuit is open with a permanent error, so the retry loop
+// stops immediately instead of backing off against an open circuit. The
+// outcome of every attempt, including the ones the retry loop swallows, is
+// observed by the client middleware.
+type circuitBreakerHook struct {
+	mu        sync.Mutex
+	defaults  *retry.CircuitBreakerConfig
+	overrides map[string]retry.CircuitBreakerConfig
+	breakers  map[string]*circuitBreaker
+	now       func() time.Time
+}
+
+var _ clientMiddleware = (*circuitBreakerHook)(nil)
+var _ beforeRequestHook = (*circuitBreakerHook)(nil)
+
+func newCircuitBreakerHook() *circuitBreakerHook {
+	return &circuitBreakerHook{
+		overrides: map[string]retry.CircuitBreakerConfig{},
+		breakers:  map[string]*circuitBreaker{},
+		now:       time.Now,
+	}
+}
+
+// ConfigureCircuitBreaker enables a circuit breaker for the given operation ID,
+// or for every operation without a specific configuration when operationID is
+// empty.
+func (h *Hooks) ConfigureCircuitBreaker(operationID string, cfg retry.CircuitBreakerConfig) {
+	cb := h.circuitBreaker()
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if operationID == "" {
+		cb.defaults = &cfg
+	} else {
+		cb.overrides[operationID] = cfg
+	}
+	cb.breakers = map[string]*circuitBreaker{}
+}
+
+// CircuitState returns the current state of the circuit for an operation ID.
+// Operations without a circuit breaker are always reported as closed.
+func (h *Hooks) CircuitState(operationID string) retry.CircuitState {
+	cb := h.findCircuitBreaker()
+	if cb == nil {
+		return retry.CircuitClosed
+	}
+
+	b := cb.breaker(operationID)
+	if b == nil {
+		return retry.CircuitClosed
+	}
+	return b.currentState()
+}
+
+func (h *Hooks) findCircuitBreaker() *circuitBreakerHook {
+	cb, _ := findClientMiddleware[*circuitBreakerHook](h)
+	return cb
+}
+
+func (h *Hooks) circuitBreaker() *circuitBreakerHook {
+	if cb := h.findCircuitBreaker(); cb != nil {
+		return cb
+	}
+
+	cb := newCircuitBreakerHook()
+	h.registerClientMiddleware(cb)
+	h.registerBeforeRequestHook(cb)
+	return cb
+}
+
+func (c *circuitBreakerHook) layer() int {
+	return layerCircuitBreaker
+}
+
+func (c *circuitBreakerHook) wrap(next config.HTTPClient) config.HTTPClient {
+	return &circuitBreakerClient{next: next}
+}
+
+func (c *circuitBreakerHook) BeforeRequest(hookCtx BeforeRequestContext, req *http.Request) (*http.Request, error) {
+	b := c.breaker(hookCtx.OperationID)
+	if b == nil {
+		return req, nil
+	}
+
+	probe, err := b.allow()
+	if err != nil {
+		return req, retry.Permanent(err)
+	}
+
+	ctx := context.WithValue(req.Context(), circuitTicketKey{}, &circuitTicket{breaker: b, probe: probe})
+	return req.WithContext(ctx), nil
+}
+
+func (c *circuitBreakerHook) breaker(operationID string) *circuitBreaker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if b, ok := c.breakers[operationID]; ok {
+		return b
+	}
+
+	cfg, ok := c.overrides[operationID]
+	if !ok {
+		if c.defaults == nil {
+			return nil
+		}
+		cfg = *c.defaults
+	}
+
+	b := newCircuitBreaker(operationID, cfg, c.now)
+	c.breakers[operationID] = b
+	return b
+}
+
+// circuitBreakerClient reports the outcome of every attempt to the circuit
+// recorded on the request context by circuitBreakerHook.BeforeRequest.
+type circuitBreakerClient struct {
+	next config.HTTPClient
+}
+
+func (c *circuitBreakerClient) Do(req *http.Request) (*http.Response, error) {
+	res, err := c.next.Do(req)
+
+	t, ok := req.Context().Value(circuitTicketKey{}).(*circuitTicket)
+	if !ok || !t.settle() {
+		return res, err
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		// The caller gave up; that says nothing about the health of the API.
+		t.breaker.release(t.probe)
+	case err != nil || res == nil:
+		t.breaker.record(t.probe, true)
+	default:
+		t.breaker.record(t.probe, res.StatusCode >= 500 && res.StatusCode < 600)
+	}
+
+	return res, err
+}
+
+// releaseCircuitTicket gives back the probe slot held by a request that is
+// answered without reaching the circuit breaker client. It does nothing if
+// the outcome of the request was already recorded.
+func releaseCircuitTicket(req *http.Request) {
+	if t, ok := req.Context().Value(circuitTicketKey{}).(*circuitTicket); ok && t.settle() {
+		t.breaker.release(t.probe)
+	}
+}
+
+type circuitBreaker struct {
+	operationID string
+	cfg         retry.CircuitBreakerConfig
+	now         func() time.Time
+
+	mu       sync.Mutex
+	state    retry.CircuitState
+	failures int
+	since    time.Time
+	probes   int
+}
+
+func newCircuitBreaker(operationID string, cfg retry.CircuitBreakerConfig, now func() time.Time) *circuitBreaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = defaultFailureThreshold
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = defaultOpenTimeout
+	}
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = defaultHalfOpenProbes
+	}
+
+	return &circuitBreaker{
+		operationID: operationID,
+		cfg:         cfg,
+		now:         now,
+	}
+}
+
+func (b *circuitBreaker) currentState() retry.CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// allow admits a request or returns a CircuitOpenError. Requests admitted
+// while half-open are probes.
+func (b *circuitBreaker) allow() (bool, error) {
+	b.mu.Lock()
+	notify := b.noop
+	defer func() {
+		b.mu.Unlock()
+		notify()
+	}()
+
+	now := b.now()
+
+	switch b.state {
+	case retry.CircuitOpen:
+		if wait := b.cfg.OpenTimeout - now.Sub(b.since); wait > 0 {
+			return false, &retry.CircuitOpenError{OperationID: b.operationID, RetryAfter: wait}
+		}
+		notify = b.transition(retry.CircuitHalfOpen, now)
+	case retry.CircuitHalfOpen:
+		if b.probes >= b.cfg.HalfOpenProbes {
+			wait := b.cfg.OpenTimeout - now.Sub(b.since)
+			if wait > 0 {
+				return false, &retry.CircuitOpenError{OperationID: b.operationID, RetryAfter: wait}
+			}
+			// The outstanding probes never reported back; assume they are lost.
+			b.probes = 0
+			b.since = now
+		}
+	default:
+		return false, nil
+	}
+
+	b.probes++
+	return true, nil
+}
+
+// record reports the outcome of an admitted request.
+func (b *circuitBreaker) record(probe bool, failed bool) {
+	b.mu.Lock()
+	notify := b.noop
+	defer func() {
+		b.mu.Unlock()
+		notify()
+	}()
+
+	if probe && b.probes > 0 {
+		b.probes--
+	}
+
+	switch b.state {
+	case retry.CircuitClosed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			notify = b.transition(retry.CircuitOpen, b.now())
+		}
+	case retry.CircuitHalfOpen:
+		// Late results of requests admitted before the circuit opened do not
+		// decide the outcome of the probe.
+		if !probe {
+			return
+		}
+		if failed {
+			notify = b.transition(retry.CircuitOpen, b.now())
+		} else {
+			notify = b.transition(retry.CircuitClosed, b.now())
+		}
+	}
+}
+
+// release frees a probe slot without recording an outcome.
+func (b *circuitBreaker) release(probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe && b.probes > 0 {
+		b.probes--
+	}
+}
+
+// transition must be called with b.mu held. It returns the state change
+// notification to run once the lock is released.
+func (b *circuitBreaker) transition(to retry.CircuitState, now time.Time) func() {
+	from := b.state
+	b.state = to
+	b.since = now
+	b.failures = 0
+	b.probes = 0
+
+	if b.cfg.OnStateChange == nil || from == to {
+		return b.noop
+	}
+	return func() {
+		b.cfg.OnStateChange(b.operationID, from, to)
+	}
+}
+
+func (b *circuitBreaker) noop() {}
@@ -0,0 +1,185 @@
+package hooks
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/img-src-io/sdk-go/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestBreaker(cfg retry.CircuitBreakerConfig) (*circuitBreaker, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	return newCircuitBreaker("getImage", cfg, clock.now), clock
+}
+
+func TestCircuitBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	t.Parallel()
+	b, _ := newTestBreaker(retry.CircuitBreakerConfig{FailureThreshold: 3})
+
+	for i := 0; i < 2; i++ {
+		_, err := b.allow()
+		require.NoError(t, err)
+		b.record(false, true)
+	}
+	assert.Equal(t, retry.CircuitClosed, b.currentState())
+
+	// A success resets the consecutive failure count.
+	b.record(false, false)
+	for i := 0; i < 3; i++ {
+		b.record(false, true)
+	}
+	assert.Equal(t, retry.CircuitOpen, b.currentState())
+
+	_, err := b.allow()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, retry.ErrCircuitOpen))
+
+	var openErr *retry.CircuitOpenError
+	require.True(t, errors.As(err, &openErr))
+	assert.Equal(t, "getImage", openErr.OperationID)
+	assert.Equal(t, defaultOpenTimeout, openErr.RetryAfter)
+}
+
+func TestCircuitBreaker_HalfOpenProbe(t *testing.T) {
+	t.Parallel()
+
+	t.Run("successful probe closes", func(t *testing.T) {
+		t.Parallel()
+		b, clock := newTestBreaker(retry.CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Second})
+		b.record(false, true)
+		require.Equal(t, retry.CircuitOpen, b.currentState())
+
+		clock.advance(time.Second)
+		probe, err := b.allow()
+		require.NoError(t, err)
+		assert.True(t, probe)
+		assert.Equal(t, retry.CircuitHalfOpen, b.currentState())
+
+		// Only one probe at a time by default.
+		_, err = b.allow()
+		assert.True(t, retry.IsCircuitOpenError(err))
+
+		b.record(true, false)
+		assert.Equal(t, retry.CircuitClosed, b.currentState())
+
+		probe, err = b.allow()
+		require.NoError(t, err)
+		assert.False(t, probe)
+	})
+
+	t.Run("failed probe reopens", func(t *testing.T) {
+		t.Parallel()
+		b, clock := newTestBreaker(retry.CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Second})
+		b.record(false, true)
+
+		clock.advance(time.Second)
+		probe, err := b.allow()
+		require.NoError(t, err)
+		b.record(probe, true)
+		assert.Equal(t, retry.CircuitOpen, b.currentState())
+
+		_, err = b.allow()
+		assert.True(t, retry.IsCircuitOpenError(err))
+	})
+
+	t.Run("late non-probe result is ignored", func(t *testing.T) {
+		t.Parallel()
+		b, clock := newTestBreaker(retry.CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Second})
+		b.record(false, true)
+
+		clock.advance(time.Second)
+		_, err := b.allow()
+		require.NoError(t, err)
+
+		b.record(false, false)
+		assert.Equal(t, retry.CircuitHalfOpen, b.currentState())
+	})
+
+	t.Run("lost probe is replaced after the open timeout", func(t *testing.T) {
+		t.Parallel()
+		b, clock := newTestBreaker(retry.CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Second})
+		b.record(false, true)
+
+		clock.advance(time.Second)
+		_, err := b.allow()
+		require.NoError(t, err)
+
+		clock.advance(time.Second)
+		probe, err := b.allow()
+		require.NoError(t, err)
+		assert.True(t, probe)
+	})
+
+	t.Run("released probe frees the slot", func(t *testing.T) {
+		t.Parallel()
+		b, clock := newTestBreaker(retry.CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Second})
+		b.record(false, true)
+
+		clock.advance(time.Second)
+		probe, err := b.allow()
+		require.NoError(t, err)
+		b.release(probe)
+
+		_, err = b.allow()
+		require.NoError(t, err)
+	})
+}
+
+func TestCircuitBreaker_OnStateChange(t *testing.T) {
+	t.Parallel()
+	var changes []string
+	b, clock := newTestBreaker(retry.CircuitBreakerConfig{
+		FailureThreshold: 1,
+		OpenTimeout:      time.Second,
+		OnStateChange: func(operationID string, from, to retry.CircuitState) {
+			changes = append(changes, operationID+":"+from.String()+"->"+to.String())
+		},
+	})
+
+	b.record(false, true)
+	clock.advance(time.Second)
+	probe, err := b.allow()
+	require.NoError(t, err)
+	b.record(probe, false)
+
+	assert.Equal(t, []string{
+		"getImage:closed->open",
+		"getImage:open->half-open",
+		"getImage:half-open->closed",
+	}, changes)
+}
+
+func TestHooks_ConfigureCircuitBreaker(t *testing.T) {
+	t.Parallel()
+	h := New()
+	assert.Nil(t, h.findCircuitBreaker())
+	assert.Equal(t, retry.CircuitClosed, h.CircuitState("getImage"))
+	registered := len(h.beforeRequestHook)
+
+	h.ConfigureCircuitBreaker("getImage", retry.CircuitBreakerConfig{FailureThreshold: 2})
+	h.ConfigureCircuitBreaker("getUsage", retry.CircuitBreakerConfig{FailureThreshold: 4})
+
+	cb := h.findCircuitBreaker()
+	require.NotNil(t, cb)
+	assert.Len(t, h.sdkInitHooks, 1)
+	assert.Len(t, h.beforeRequestHook, registered+2)
+
+	assert.Equal(t, 2, cb.breaker("getImage").cfg.FailureThreshold)
+	assert.Equal(t, 4, cb.breaker("getUsage").cfg.FailureThreshold)
+	assert.Nil(t, cb.breaker("listImages"))
+
+	h.ConfigureCircuitBreaker("", retry.CircuitBreakerConfig{})
+	require.NotNil(t, cb.breaker("listImages"))
+	assert.Equal(t, defaultFailureThreshold, cb.breaker("listImages").cfg.FailureThreshold)
+}
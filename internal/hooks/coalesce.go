@@ -0,0 +1,133 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/img-src-io/sdk-go/internal/config"
+)
+
+// coalesceHook shares one in-flight round trip between concurrent identical
+// GET requests. Every caller receives its own response with its own copy of
+// the body, so the structs the SDK decodes from it are never shared.
+type coalesceHook struct {
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+type coalescedCall struct {
+	done      chan struct{}
+	followers int
+
+	statusCode int
+	header     http.Header
+	body       []byte
+	err        error
+}
+
+var _ clientMiddleware = (*coalesceHook)(nil)
+
+// EnableRequestCoalescing enables request coalescing for GET operations.
+func (h *Hooks) EnableRequestCoalescing() {
+	if _, ok := findClientMiddleware[*coalesceHook](h); ok {
+		return
+	}
+
+	h.registerClientMiddleware(&coalesceHook{calls: map[string]*coalescedCall{}})
+}
+
+func (c *coalesceHook) layer() int {
+	return layerCoalesce
+}
+
+func (c *coalesceHook) wrap(next config.HTTPClient) config.HTTPClient {
+	return &coalesceClient{hook: c, next: next}
+}
+
+type coalesceClient struct {
+	hook *coalesceHook
+	next config.HTTPClient
+}
+
+func (c *coalesceClient) Do(req *http.Request) (*http.Response, error) {
+	operationID := operationIDFromRequest(req)
+	if operationID == "" || req.Method != http.MethodGet {
+		return c.next.Do(req)
+	}
+
+	key := requestKey(operationID, req)
+
+	c.hook.mu.Lock()
+	if call, ok := c.hook.calls[key]; ok {
+		call.followers++
+		c.hook.mu.Unlock()
+		return c.follow(call, req)
+	}
+
+	call := &coalescedCall{done: make(chan struct{})}
+	c.hook.calls[key] = call
+	c.hook.mu.Unlock()
+
+	res, err := c.lead(call, req)
+
+	c.hook.mu.Lock()
+	delete(c.hook.calls, key)
+	c.hook.mu.Unlock()
+	close(call.done)
+
+	return res, err
+}
+
+// lead performs the round trip and buffers the response for the followers.
+func (c *coalesceClient) lead(call *coalescedCall, req *http.Request) (*http.Response, error) {
+	res, err := c.next.Do(req)
+	if err != nil || res == nil {
+		call.err = err
+		if call.err == nil {
+			call.err = errors.New("error sending request: no response")
+		}
+		return res, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		call.err = err
+		return nil, err
+	}
+
+	call.statusCode = res.StatusCode
+	call.header = res.Header.Clone()
+	call.body = body
+
+	return replayResponse(res.StatusCode, res.Header, body, req), nil
+}
+
+// follow waits for the leader's response. A follower whose own context is
+// still alive repeats the request itself if the leader was cancelled.
+func (c *coalesceClient) follow(call *coalescedCall, req *http.Request) (*http.Response, error) {
+	select {
+	case <-call.done:
+	case <-req.Context().Done():
+		releaseCircuitTicket(req)
+		return nil, req.Context().Err()
+	}
+
+	if call.err != nil && req.Context().Err() == nil &&
+		(errors.Is(call.err, context.Canceled) || errors.Is(call.err, context.DeadlineExceeded)) {
+		return c.next.Do(req)
+	}
+
+	// The shared round trip was reported to the circuit breaker by the
+	// leader, so the probe slot this request may hold is given back.
+	releaseCircuitTicket(req)
+
+	if call.err != nil {
+		return nil, call.err
+	}
+
+	return replayResponse(call.statusCode, call.header, call.body, req), nil
+}
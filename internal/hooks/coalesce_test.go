@@ -0,0 +1,166 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type blockingClient struct {
+	calls   atomic.Int32
+	release chan struct{}
+	// errs are the errors of the first calls, in order. Later calls succeed.
+	errs []error
+}
+
+func (c *blockingClient) Do(req *http.Request) (*http.Response, error) {
+	n := int(c.calls.Add(1))
+	<-c.release
+	if n <= len(c.errs) && c.errs[n-1] != nil {
+		return nil, c.errs[n-1]
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader([]byte(`{"id":"abc123"}`))),
+		Request:    req,
+	}, nil
+}
+
+func newCoalesceTestClient(next *blockingClient) (*coalesceHook, *coalesceClient) {
+	hook := &coalesceHook{calls: map[string]*coalescedCall{}}
+	return hook, hook.wrap(next).(*coalesceClient)
+}
+
+func newOperationRequest(t *testing.T, ctx context.Context, method, url string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.WithValue(ctx, operationIDKey{}, "getImage"), method, url, nil)
+	require.NoError(t, err)
+	return req
+}
+
+func waitForFollowers(t *testing.T, hook *coalesceHook, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		hook.mu.Lock()
+		defer hook.mu.Unlock()
+		for _, call := range hook.calls {
+			return call.followers == n
+		}
+		return false
+	}, time.Second, time.Millisecond)
+}
+
+func TestCoalesce_SharesInFlightRequest(t *testing.T) {
+	t.Parallel()
+	next := &blockingClient{release: make(chan struct{})}
+	hook, client := newCoalesceTestClient(next)
+
+	const callers = 10
+	bodies := make([][]byte, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res, err := client.Do(newOperationRequest(t, context.Background(), http.MethodGet, "https://api.img-src.io/api/v1/images/abc123"))
+			require.NoError(t, err)
+			bodies[i], err = io.ReadAll(res.Body)
+			require.NoError(t, err)
+		}(i)
+	}
+
+	waitForFollowers(t, hook, callers-1)
+	close(next.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), next.calls.Load())
+	for _, body := range bodies {
+		assert.Equal(t, `{"id":"abc123"}`, string(body))
+	}
+
+	// Each caller owns its body.
+	bodies[0][0] = 'X'
+	assert.Equal(t, byte('{'), bodies[1][0])
+	assert.Empty(t, hook.calls)
+}
+
+func TestCoalesce_DistinctRequestsAreNotShared(t *testing.T) {
+	t.Parallel()
+	next := &blockingClient{release: make(chan struct{})}
+	close(next.release)
+	_, client := newCoalesceTestClient(next)
+
+	for _, url := range []string{
+		"https://api.img-src.io/api/v1/images/abc123",
+		"https://api.img-src.io/api/v1/images/def456",
+	} {
+		_, err := client.Do(newOperationRequest(t, context.Background(), http.MethodGet, url))
+		require.NoError(t, err)
+	}
+
+	_, err := client.Do(newOperationRequest(t, context.Background(), http.MethodDelete, "https://api.img-src.io/api/v1/images/abc123"))
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(3), next.calls.Load())
+}
+
+func TestCoalesce_FollowerRetriesWhenLeaderIsCancelled(t *testing.T) {
+	t.Parallel()
+	next := &blockingClient{release: make(chan struct{}), errs: []error{context.Canceled}}
+	hook, client := newCoalesceTestClient(next)
+
+	leaderDone := make(chan struct{})
+	go func() {
+		defer close(leaderDone)
+		_, err := client.Do(newOperationRequest(t, context.Background(), http.MethodGet, "https://api.img-src.io/api/v1/images/abc123"))
+		assert.ErrorIs(t, err, context.Canceled)
+	}()
+	require.Eventually(t, func() bool { return next.calls.Load() == 1 }, time.Second, time.Millisecond)
+
+	followerDone := make(chan error, 1)
+	go func() {
+		_, err := client.Do(newOperationRequest(t, context.Background(), http.MethodGet, "https://api.img-src.io/api/v1/images/abc123"))
+		followerDone <- err
+	}()
+
+	waitForFollowers(t, hook, 1)
+	next.release <- struct{}{}
+	<-leaderDone
+
+	// The follower repeats the request on its own.
+	close(next.release)
+	require.NoError(t, <-followerDone)
+	assert.Equal(t, int32(2), next.calls.Load())
+}
+
+func TestCoalesce_FollowerContextCancelled(t *testing.T) {
+	t.Parallel()
+	next := &blockingClient{release: make(chan struct{})}
+	hook, client := newCoalesceTestClient(next)
+
+	go func() {
+		_, _ = client.Do(newOperationRequest(t, context.Background(), http.MethodGet, "https://api.img-src.io/api/v1/images/abc123"))
+	}()
+	require.Eventually(t, func() bool { return next.calls.Load() == 1 }, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := client.Do(newOperationRequest(t, ctx, http.MethodGet, "https://api.img-src.io/api/v1/images/abc123"))
+		errc <- err
+	}()
+
+	waitForFollowers(t, hook, 1)
+	cancel()
+	assert.ErrorIs(t, <-errc, context.Canceled)
+	close(next.release)
+}
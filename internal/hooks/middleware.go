@@ -0,0 +1,125 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/img-src-io/sdk-go/internal/config"
+)
+
+// Layers of the client middlewares, outermost first. Cached responses never
+// reach the coalescer, and coalesced requests reach the circuit breaker once.
+const (
+	layerCache = iota * 10
+	layerCoalesce
+	layerCircuitBreaker
+)
+
+type operationIDKey struct{}
+
+// clientMiddleware is implemented by hooks that need to observe every HTTP
+// attempt rather than only the final outcome of an operation.
+type clientMiddleware interface {
+	layer() int
+	wrap(next config.HTTPClient) config.HTTPClient
+}
+
+// clientChain composes the registered middlewares around the configured HTTP
+// client in layer order, independently of the order they were registered in,
+// and tags every request with its operation ID.
+type clientChain struct {
+	middlewares []clientMiddleware
+}
+
+var _ sdkInitHook = (*clientChain)(nil)
+var _ beforeRequestHook = (*clientChain)(nil)
+
+func (h *Hooks) findClientChain() *clientChain {
+	for _, hook := range h.sdkInitHooks {
+		if c, ok := hook.(*clientChain); ok {
+			return c
+		}
+	}
+	return nil
+}
+
+// findClientMiddleware returns the registered middleware of type T, if any.
+func findClientMiddleware[T clientMiddleware](h *Hooks) (T, bool) {
+	if chain := h.findClientChain(); chain != nil {
+		for _, m := range chain.middlewares {
+			if t, ok := m.(T); ok {
+				return t, true
+			}
+		}
+	}
+
+	var zero T
+	return zero, false
+}
+
+func (h *Hooks) registerClientMiddleware(m clientMiddleware) {
+	chain := h.findClientChain()
+	if chain == nil {
+		chain = &clientChain{}
+		h.registerSDKInitHook(chain)
+		h.registerBeforeRequestHook(chain)
+	}
+
+	chain.middlewares = append(chain.middlewares, m)
+}
+
+func (c *clientChain) SDKInit(cfg config.SDKConfiguration) config.SDKConfiguration {
+	if cfg.Client == nil {
+		return cfg
+	}
+
+	middlewares := append([]clientMiddleware(nil), c.middlewares...)
+	sort.SliceStable(middlewares, func(i, j int) bool {
+		return middlewares[i].layer() > middlewares[j].layer()
+	})
+
+	for _, m := range middlewares {
+		cfg.Client = m.wrap(cfg.Client)
+	}
+	return cfg
+}
+
+func (c *clientChain) BeforeRequest(hookCtx BeforeRequestContext, req *http.Request) (*http.Request, error) {
+	if operationIDFromRequest(req) == hookCtx.OperationID {
+		return req, nil
+	}
+	return req.WithContext(context.WithValue(req.Context(), operationIDKey{}, hookCtx.OperationID)), nil
+}
+
+func operationIDFromRequest(req *http.Request) string {
+	operationID, _ := req.Context().Value(operationIDKey{}).(string)
+	return operationID
+}
+
+// requestKey identifies a request by operation, URL and credentials, so
+// different clients or API keys never share responses.
+func requestKey(operationID string, req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.Header.Get("Authorization")))
+	return operationID + " " + hex.EncodeToString(sum[:8]) + " " + req.URL.String()
+}
+
+// replayResponse builds a response to req from a buffered status, header and body.
+func replayResponse(statusCode int, header http.Header, body []byte, req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -9,6 +9,10 @@ package hooks
  */
 
 func initHooks(h *Hooks) {
+	// Uploads are rewritten before any other hook sees the request, so that a
+	// failed rewrite never holds a circuit breaker ticket.
+	h.registerBeforeRequestHook(&uploadHook{})
+
 	// exampleHook := &ExampleHook{}
 
 	// h.registerSDKInitHook(exampleHook)
@@ -0,0 +1,262 @@
+package hooks
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+
+	"github.com/img-src-io/sdk-go/internal/sniff"
+)
+
+// FileTransform rewrites the content of an uploaded file before it is sent.
+// A returned reader that implements io.Closer is closed once the file has
+// been written, so that transforms may produce their output in a goroutine
+// feeding an io.Pipe.
+type FileTransform func(fileName string, r io.Reader) (io.Reader, error)
+
+// ContentTypeMismatchError reports a file whose contents do not match the
+// type declared by its override or its extension.
+type ContentTypeMismatchError struct {
+	FileName string
+	// Declared is the type set by WithFileContentType or the type of the
+	// extension.
+	Declared string
+	// Detected is the type sniffed from the contents.
+	Detected string
+}
+
+func (e *ContentTypeMismatchError) Error() string {
+	return fmt.Sprintf("%s is declared as %s but contains %s", e.FileName, e.Declared, e.Detected)
+}
+
+type (
+	uploadRewrittenKey struct{}
+	fileContentTypeKey struct{}
+	exactUploadKey     struct{}
+)
+
+// WithExactUpload returns a context marking the uploads made with it as
+// re-uploads of existing originals, which rely on the API deduplicating
+// identical bytes. Their files are sent unchanged: neither transformed nor
+// checked.
+func WithExactUpload(ctx context.Context) context.Context {
+	return context.WithValue(ctx, exactUploadKey{}, true)
+}
+
+// WithFileContentType returns a context overriding the Content-Type of the
+// files uploaded with it, which is otherwise detected from their contents or
+// extension.
+func WithFileContentType(ctx context.Context, contentType string) context.Context {
+	return context.WithValue(ctx, fileContentTypeKey{}, contentType)
+}
+
+// uploadHook rewrites the file parts of the multipart body of uploadImage
+// requests once it has been serialized: it runs them through the configured
+// transform, then sets their Content-Type from their contents, which the
+// generated code only derives from the file extension.
+type uploadHook struct {
+	transform FileTransform
+	// strict fails uploads whose declared type contradicts the contents.
+	strict bool
+	// warn is called for every mismatch that does not fail the upload.
+	warn func(err *ContentTypeMismatchError)
+}
+
+var _ beforeRequestHook = (*uploadHook)(nil)
+
+// ConfigureUploadTransform runs the files of every upload through transform,
+// replacing the previous one.
+func (h *Hooks) ConfigureUploadTransform(transform FileTransform) {
+	h.uploads().transform = transform
+}
+
+// UploadTransform returns the transform set by ConfigureUploadTransform.
+func (h *Hooks) UploadTransform() FileTransform {
+	return h.uploads().transform
+}
+
+// ConfigureContentTypeWarning calls warn for every uploaded file whose
+// contents contradict its declared type.
+func (h *Hooks) ConfigureContentTypeWarning(warn func(err *ContentTypeMismatchError)) {
+	h.uploads().warn = warn
+}
+
+// ConfigureStrictContentType fails uploads of files whose contents contradict
+// their declared type with a *ContentTypeMismatchError.
+func (h *Hooks) ConfigureStrictContentType() {
+	h.uploads().strict = true
+}
+
+// uploads returns the upload hook registered by initHooks.
+func (h *Hooks) uploads() *uploadHook {
+	for _, hook := range h.beforeRequestHook {
+		if u, ok := hook.(*uploadHook); ok {
+			return u
+		}
+	}
+	panic("hooks: upload hook not registered")
+}
+
+func (u *uploadHook) BeforeRequest(hookCtx BeforeRequestContext, req *http.Request) (*http.Request, error) {
+	// Retries replay the body rewritten by the first attempt.
+	if hookCtx.OperationID != "uploadImage" || req.Body == nil || req.Context().Value(uploadRewrittenKey{}) != nil || req.Context().Value(exactUploadKey{}) != nil {
+		return req, nil
+	}
+
+	_, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || params["boundary"] == "" {
+		return req, nil
+	}
+	override, _ := req.Context().Value(fileContentTypeKey{}).(string)
+
+	var buf bytes.Buffer
+	if req.ContentLength > 0 {
+		buf.Grow(int(req.ContentLength))
+	}
+	err = u.rewrite(&buf, multipart.NewReader(req.Body, params["boundary"]), params["boundary"], override)
+	req.Body.Close()
+	if err != nil {
+		return req, err
+	}
+
+	data := buf.Bytes()
+	out := req.WithContext(context.WithValue(req.Context(), uploadRewrittenKey{}, true))
+	out.Body = io.NopCloser(bytes.NewReader(data))
+	out.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	out.ContentLength = int64(len(data))
+	return out, nil
+}
+
+// rewrite copies the parts of r to w, keeping the boundary so that the
+// Content-Type header of the request stays valid.
+func (u *uploadHook) rewrite(w io.Writer, r *multipart.Reader, boundary, override string) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(boundary); err != nil {
+		return err
+	}
+
+	for {
+		part, err := r.NextRawPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error reading upload: %w", err)
+		}
+
+		if part.FileName() == "" {
+			pw, err := mw.CreatePart(part.Header)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(pw, part); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := u.rewriteFile(mw, part, override); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+func (u *uploadHook) rewriteFile(mw *multipart.Writer, part *multipart.Part, override string) error {
+	fileName := part.FileName()
+
+	// Hide the part's Close method, so that a transform returning its input
+	// unchanged is not mistaken for one producing a reader to close.
+	var content io.Reader = struct{ io.Reader }{part}
+	if u.transform != nil {
+		out, err := u.transform(fileName, content)
+		if err != nil {
+			return fmt.Errorf("error transforming %s: %w", fileName, err)
+		}
+		if c, ok := out.(io.Closer); ok {
+			defer c.Close()
+		}
+		content = out
+	}
+
+	buffered := bufio.NewReaderSize(content, sniff.HeaderSize)
+	head, err := buffered.Peek(sniff.HeaderSize)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	contentType, err := u.contentType(fileName, override, head)
+	if err != nil {
+		return err
+	}
+
+	header := make(textproto.MIMEHeader, len(part.Header))
+	for k, v := range part.Header {
+		header[k] = v
+	}
+	header.Set("Content-Type", contentType)
+	pw, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(pw, buffered)
+	return err
+}
+
+// contentType returns the Content-Type of a file starting with head. The
+// override wins if set; otherwise the type sniffed from the contents is used
+// when it is an image, then the type of the extension.
+func (u *uploadHook) contentType(fileName, override string, head []byte) (string, error) {
+	var detected string
+	if len(head) > 0 {
+		if t := sniff.ContentType(head); strings.HasPrefix(t, "image/") {
+			detected = t
+		}
+	}
+
+	declared := override
+	if declared == "" {
+		declared = mime.TypeByExtension(filepath.Ext(fileName))
+	}
+
+	if detected != "" && contradicts(declared, detected) {
+		mismatch := &ContentTypeMismatchError{FileName: fileName, Declared: declared, Detected: detected}
+		if u.strict {
+			return "", mismatch
+		}
+		if u.warn != nil {
+			u.warn(mismatch)
+		}
+	}
+
+	switch {
+	case override != "":
+		return override, nil
+	case detected != "":
+		return detected, nil
+	case declared != "":
+		return declared, nil
+	}
+	return "application/octet-stream", nil
+}
+
+// contradicts reports whether a declared image type differs from the
+// detected one. Other declared types are not compared, since the contents of
+// such files are not sniffed reliably.
+func contradicts(declared, detected string) bool {
+	declared, _, _ = strings.Cut(declared, ";")
+	declared = strings.ToLower(strings.TrimSpace(declared))
+	if declared == "image/jpg" || declared == "image/pjpeg" {
+		declared = "image/jpeg"
+	}
+	return strings.HasPrefix(declared, "image/") && declared != detected
+}
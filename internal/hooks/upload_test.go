@@ -0,0 +1,198 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newUploadRequest(t *testing.T, files map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	require.NoError(t, w.WriteField("target_path", "photos"))
+	for name, content := range files {
+		fw, err := w.CreateFormFile("file", name)
+		require.NoError(t, err)
+		_, err = io.WriteString(fw, content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	req, err := http.NewRequest(http.MethodPost, "https://api.img-src.io/api/v1/images", bytes.NewReader(body.Bytes()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func readUploadRequest(t *testing.T, req *http.Request) map[string]string {
+	t.Helper()
+	_, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	body, err := req.GetBody()
+	require.NoError(t, err)
+
+	parts := map[string]string{}
+	r := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := r.NextPart()
+		if err == io.EOF {
+			return parts
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(part)
+		require.NoError(t, err)
+		parts[part.FormName()+":"+part.FileName()] = string(data)
+	}
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestUploadHook_Transform(t *testing.T) {
+	t.Parallel()
+	h := New()
+	var outputs []*closeRecorder
+	h.ConfigureUploadTransform(func(fileName string, r io.Reader) (io.Reader, error) {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		out := &closeRecorder{Reader: strings.NewReader(fileName + ":" + strings.ToUpper(string(data)))}
+		outputs = append(outputs, out)
+		return out, nil
+	})
+	hookCtx := BeforeRequestContext{HookContext: HookContext{OperationID: "uploadImage"}}
+
+	req, err := h.BeforeRequest(hookCtx, newUploadRequest(t, map[string]string{"cat.png": "meow"}))
+	require.NoError(t, err)
+	want := map[string]string{"target_path:": "photos", "file:cat.png": "cat.png:MEOW"}
+	assert.Equal(t, want, readUploadRequest(t, req))
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.EqualValues(t, len(body), req.ContentLength)
+	require.Len(t, outputs, 1)
+	assert.True(t, outputs[0].closed)
+
+	// A retry replays the rewritten body without transforming it again.
+	retried, err := h.BeforeRequest(hookCtx, req)
+	require.NoError(t, err)
+	assert.Equal(t, want, readUploadRequest(t, retried))
+	assert.Len(t, outputs, 1)
+
+	// Other operations are left alone.
+	other, err := h.BeforeRequest(BeforeRequestContext{HookContext: HookContext{OperationID: "updateImage"}}, newUploadRequest(t, map[string]string{"cat.png": "meow"}))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"target_path:": "photos", "file:cat.png": "meow"}, readUploadRequest(t, other))
+	assert.Len(t, outputs, 1)
+}
+
+func TestUploadHook_TransformError(t *testing.T) {
+	t.Parallel()
+	h := New()
+	h.ConfigureUploadTransform(func(string, io.Reader) (io.Reader, error) {
+		return nil, io.ErrUnexpectedEOF
+	})
+
+	_, err := h.BeforeRequest(BeforeRequestContext{HookContext: HookContext{OperationID: "uploadImage"}}, newUploadRequest(t, map[string]string{"cat.png": "meow"}))
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Contains(t, err.Error(), "cat.png")
+}
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")
+
+// uploadFile sends a file through the upload hook h and returns the
+// Content-Type of its part and the part's content.
+func uploadFile(ctx context.Context, t *testing.T, h *Hooks, fileName string, content []byte) (string, []byte, error) {
+	t.Helper()
+	req := newUploadRequest(t, map[string]string{fileName: string(content)})
+	req, err := h.BeforeRequest(BeforeRequestContext{HookContext: HookContext{OperationID: "uploadImage"}}, req.WithContext(ctx))
+	if err != nil {
+		return "", nil, err
+	}
+
+	_, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	r := multipart.NewReader(req.Body, params["boundary"])
+	for {
+		part, err := r.NextPart()
+		require.NoError(t, err)
+		if part.FileName() == "" {
+			continue
+		}
+		data, err := io.ReadAll(part)
+		require.NoError(t, err)
+		return part.Header.Get("Content-Type"), data, nil
+	}
+}
+
+func TestUploadHook_ContentType(t *testing.T) {
+	t.Parallel()
+	svg := []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`)
+
+	tests := []struct {
+		name     string
+		fileName string
+		content  []byte
+		override string
+		want     string
+		mismatch bool
+	}{
+		{"sniffed without extension", "upload", pngHeader, "", "image/png", false},
+		{"sniffed over wrong extension", "photo.jpg", pngHeader, "", "image/png", true},
+		{"sniffed with matching extension", "photo.png", pngHeader, "", "image/png", false},
+		{"svg", "logo", svg, "", "image/svg+xml", false},
+		{"extension for unknown content", "notes.txt", []byte("hello"), "", "text/plain; charset=utf-8", false},
+		{"octet-stream fallback", "blob", []byte{0x00, 0x01}, "", "application/octet-stream", false},
+		{"override", "photo", []byte("hello"), "image/x-custom", "image/x-custom", false},
+		{"contradicting override", "photo.png", pngHeader, "image/webp", "image/webp", true},
+		{"jpg alias", "photo", []byte{0xff, 0xd8, 0xff, 0xe0}, "image/jpg", "image/jpg", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			if tt.override != "" {
+				ctx = WithFileContentType(ctx, tt.override)
+			}
+
+			var warned []*ContentTypeMismatchError
+			h := New()
+			h.ConfigureContentTypeWarning(func(err *ContentTypeMismatchError) {
+				warned = append(warned, err)
+			})
+			got, data, err := uploadFile(ctx, t, h, tt.fileName, tt.content)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.mismatch, len(warned) == 1)
+			assert.Equal(t, tt.content, data)
+
+			strict := New()
+			strict.ConfigureStrictContentType()
+			_, _, err = uploadFile(ctx, t, strict, tt.fileName, tt.content)
+			if tt.mismatch {
+				var mismatch *ContentTypeMismatchError
+				require.ErrorAs(t, err, &mismatch)
+				assert.Equal(t, tt.fileName, mismatch.FileName)
+				assert.True(t, strings.HasPrefix(mismatch.Detected, "image/"))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+// Package limit bounds the size of streamed content.
+package limit
+
+import "io"
+
+// Reader reads from R and fails with Err once more than N bytes were read,
+// instead of truncating the content like io.LimitReader.
+type Reader struct {
+	R   io.Reader
+	N   int64
+	Err error
+
+	exceeded bool
+}
+
+// NewReader returns a Reader failing with err after n bytes of r.
+func NewReader(r io.Reader, n int64, err error) *Reader {
+	return &Reader{R: r, N: n, Err: err}
+}
+
+func (l *Reader) Read(p []byte) (int, error) {
+	if l.exceeded {
+		return 0, l.Err
+	}
+	// Read one byte past the limit, so that content of exactly N bytes is
+	// not reported as too large.
+	if int64(len(p)) > l.N+1 {
+		p = p[:l.N+1]
+	}
+	n, err := l.R.Read(p)
+	l.N -= int64(n)
+	if l.N < 0 {
+		l.exceeded = true
+		return 0, l.Err
+	}
+	return n, err
+}
+
+// Exceeded reports whether more than the limit was read.
+func (l *Reader) Exceeded() bool {
+	return l.exceeded
+}
@@ -0,0 +1,37 @@
+package limit_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/img-src-io/sdk-go/internal/limit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReader(t *testing.T) {
+	t.Parallel()
+	errTooLarge := errors.New("too large")
+
+	t.Run("at the limit", func(t *testing.T) {
+		t.Parallel()
+		r := limit.NewReader(strings.NewReader("hello"), 5, errTooLarge)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+		assert.False(t, r.Exceeded())
+	})
+
+	t.Run("over the limit", func(t *testing.T) {
+		t.Parallel()
+		r := limit.NewReader(strings.NewReader("hello!"), 5, errTooLarge)
+		_, err := io.ReadAll(r)
+		assert.ErrorIs(t, err, errTooLarge)
+		assert.True(t, r.Exceeded())
+
+		_, err = r.Read(make([]byte, 1))
+		assert.ErrorIs(t, err, errTooLarge)
+	})
+}
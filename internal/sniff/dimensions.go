@@ -0,0 +1,200 @@
+package sniff
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// ErrMalformed is returned when a header cannot be parsed.
+var ErrMalformed = errors.New("malformed image header")
+
+// WebPSize returns the canvas size of a WebP file from its first 30 bytes.
+func WebPSize(data []byte) (width, height int, err error) {
+	if len(data) < 30 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return 0, 0, ErrMalformed
+	}
+
+	switch string(data[12:16]) {
+	case "VP8 ":
+		if data[23] != 0x9d || data[24] != 0x01 || data[25] != 0x2a {
+			return 0, 0, ErrMalformed
+		}
+		width = int(binary.LittleEndian.Uint16(data[26:]) & 0x3fff)
+		height = int(binary.LittleEndian.Uint16(data[28:]) & 0x3fff)
+	case "VP8L":
+		if data[20] != 0x2f {
+			return 0, 0, ErrMalformed
+		}
+		bits := binary.LittleEndian.Uint32(data[21:])
+		width = int(bits&0x3fff) + 1
+		height = int(bits>>14&0x3fff) + 1
+	case "VP8X":
+		width = int(uint32(data[24])|uint32(data[25])<<8|uint32(data[26])<<16) + 1
+		height = int(uint32(data[27])|uint32(data[28])<<8|uint32(data[29])<<16) + 1
+	default:
+		return 0, 0, ErrMalformed
+	}
+	if width == 0 || height == 0 {
+		return 0, 0, ErrMalformed
+	}
+	return width, height, nil
+}
+
+// HEIFSize returns the size of an AVIF or HEIC image from its leading boxes,
+// which must include the meta box. It reports the largest image spatial
+// extent, which is that of the primary image for all but exotic files.
+func HEIFSize(data []byte) (width, height int, err error) {
+	meta, ok := findBox(data, "meta")
+	if !ok || len(meta) < 4 {
+		return 0, 0, ErrMalformed
+	}
+	iprp, ok := findBox(meta[4:], "iprp")
+	if !ok {
+		return 0, 0, ErrMalformed
+	}
+	ipco, ok := findBox(iprp, "ipco")
+	if !ok {
+		return 0, 0, ErrMalformed
+	}
+
+	eachBox(ipco, func(typ string, body []byte) bool {
+		if typ == "ispe" && len(body) >= 12 {
+			w := int(binary.BigEndian.Uint32(body[4:]))
+			h := int(binary.BigEndian.Uint32(body[8:]))
+			if w*h > width*height {
+				width, height = w, h
+			}
+		}
+		return true
+	})
+	if width == 0 || height == 0 {
+		return 0, 0, ErrMalformed
+	}
+	return width, height, nil
+}
+
+// JXLSize returns the size of a JPEG XL image, either a bare codestream or a
+// container, from its leading bytes.
+func JXLSize(data []byte) (width, height int, err error) {
+	if len(data) >= len(jxlContainer) && string(data[:len(jxlContainer)]) == string(jxlContainer) {
+		found := false
+		eachBox(data, func(typ string, body []byte) bool {
+			switch typ {
+			case "jxlc":
+				data, found = body, true
+			case "jxlp":
+				if len(body) > 4 {
+					data, found = body[4:], true
+				}
+			}
+			return !found
+		})
+		if !found {
+			return 0, 0, ErrMalformed
+		}
+	}
+	if len(data) < 2 || data[0] != 0xff || data[1] != 0x0a {
+		return 0, 0, ErrMalformed
+	}
+
+	br := &bitReader{data: data[2:]}
+	u32 := func() uint32 {
+		switch br.read(2) {
+		case 0:
+			return br.read(9) + 1
+		case 1:
+			return br.read(13) + 1
+		case 2:
+			return br.read(18) + 1
+		default:
+			return br.read(30) + 1
+		}
+	}
+
+	var w, h uint32
+	div8 := br.read(1) == 1
+	if div8 {
+		h = 8 * (br.read(5) + 1)
+	} else {
+		h = u32()
+	}
+	switch ratio := br.read(3); ratio {
+	case 0:
+		if div8 {
+			w = 8 * (br.read(5) + 1)
+		} else {
+			w = u32()
+		}
+	default:
+		num := []uint64{0, 1, 12, 4, 3, 16, 5, 2}[ratio]
+		den := []uint64{0, 1, 10, 3, 2, 9, 4, 1}[ratio]
+		w = uint32(uint64(h) * num / den)
+	}
+	if br.overrun {
+		return 0, 0, ErrMalformed
+	}
+	return int(w), int(h), nil
+}
+
+type bitReader struct {
+	data    []byte
+	pos     int
+	overrun bool
+}
+
+// read returns the next n bits, least significant first.
+func (b *bitReader) read(n int) uint32 {
+	var v uint32
+	for i := 0; i < n; i++ {
+		if b.pos/8 >= len(b.data) {
+			b.overrun = true
+			return 0
+		}
+		bit := b.data[b.pos/8] >> (b.pos % 8) & 1
+		v |= uint32(bit) << i
+		b.pos++
+	}
+	return v
+}
+
+// findBox returns the body of the first box of type typ in data.
+func findBox(data []byte, typ string) ([]byte, bool) {
+	var found []byte
+	ok := false
+	eachBox(data, func(t string, body []byte) bool {
+		if t == typ {
+			found, ok = body, true
+			return false
+		}
+		return true
+	})
+	return found, ok
+}
+
+// eachBox calls fn with the type and body of every ISO base media box in
+// data until it returns false. A truncated last box is passed with the bytes
+// available.
+func eachBox(data []byte, fn func(typ string, body []byte) bool) {
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data))
+		typ := string(data[4:8])
+		header := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return
+			}
+			size, header = binary.BigEndian.Uint64(data[8:]), 16
+		}
+		if size < header {
+			return
+		}
+		end := min(size, uint64(len(data)))
+		if !fn(typ, data[header:end]) {
+			return
+		}
+		data = data[end:]
+	}
+}
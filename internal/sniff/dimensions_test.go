@@ -0,0 +1,150 @@
+package sniff
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// box builds an ISO base media box.
+func box(typ string, body ...[]byte) []byte {
+	var content []byte
+	for _, b := range body {
+		content = append(content, b...)
+	}
+	out := binary.BigEndian.AppendUint32(nil, uint32(8+len(content)))
+	return append(append(out, typ...), content...)
+}
+
+func ispe(w, h uint32) []byte {
+	body := binary.BigEndian.AppendUint32([]byte{0, 0, 0, 0}, w)
+	return box("ispe", binary.BigEndian.AppendUint32(body, h))
+}
+
+// bitWriter writes bits least significant first, like the JPEG XL reader.
+type bitWriter struct {
+	data []byte
+	n    int
+}
+
+func (b *bitWriter) write(v uint32, n int) {
+	for i := 0; i < n; i++ {
+		if b.n%8 == 0 {
+			b.data = append(b.data, 0)
+		}
+		b.data[len(b.data)-1] |= byte(v>>i&1) << (b.n % 8)
+		b.n++
+	}
+}
+
+func TestWebPSize(t *testing.T) {
+	t.Parallel()
+
+	riff := func(chunk string, payload ...byte) []byte {
+		data := append([]byte("RIFF\x00\x00\x00\x00WEBP"), chunk...)
+		data = append(data, 0, 0, 0, 0)
+		data = append(data, payload...)
+		for len(data) < 30 {
+			data = append(data, 0)
+		}
+		return data
+	}
+
+	lossy := riff("VP8 ", 0, 0, 0, 0x9d, 0x01, 0x2a, 0x40, 0x01, 0xf0, 0x00) // 320x240
+	bits := uint32(800-1) | uint32(600-1)<<14
+	lossless := riff("VP8L", append([]byte{0x2f}, binary.LittleEndian.AppendUint32(nil, bits)...)...)
+	extended := riff("VP8X", 0, 0, 0, 0, 0x7f, 0x07, 0x00, 0x37, 0x04, 0x00) // 1920x1080
+
+	tests := []struct {
+		name string
+		data []byte
+		w, h int
+	}{
+		{"lossy", lossy, 320, 240},
+		{"lossless", lossless, 800, 600},
+		{"extended", extended, 1920, 1080},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w, h, err := WebPSize(tt.data)
+			require.NoError(t, err)
+			assert.Equal(t, []int{tt.w, tt.h}, []int{w, h})
+		})
+	}
+
+	_, _, err := WebPSize(riff("VP8 ", 0, 0, 0, 1, 2, 3))
+	assert.ErrorIs(t, err, ErrMalformed)
+	_, _, err = WebPSize([]byte("RIFF"))
+	assert.ErrorIs(t, err, ErrMalformed)
+}
+
+func TestHEIFSize(t *testing.T) {
+	t.Parallel()
+
+	ftyp := box("ftyp", []byte("avif\x00\x00\x00\x00mif1"))
+	meta := box("meta", []byte{0, 0, 0, 0},
+		box("hdlr", make([]byte, 24)),
+		box("iprp", box("ipco", ispe(160, 90), box("pixi", []byte{0, 0, 0, 0, 1, 8}), ispe(4032, 3024)), box("ipma", make([]byte, 8))),
+	)
+	data := append(append(ftyp, meta...), box("mdat", make([]byte, 16))...)
+
+	w, h, err := HEIFSize(data)
+	require.NoError(t, err)
+	assert.Equal(t, []int{4032, 3024}, []int{w, h})
+
+	_, _, err = HEIFSize(ftyp)
+	assert.ErrorIs(t, err, ErrMalformed)
+}
+
+func TestJXLSize(t *testing.T) {
+	t.Parallel()
+
+	var small bitWriter
+	small.write(1, 1) // div8
+	small.write(32/8-1, 5)
+	small.write(0, 3)
+	small.write(64/8-1, 5)
+
+	var ratio bitWriter
+	ratio.write(0, 1)
+	ratio.write(0, 2)
+	ratio.write(75-1, 9)
+	ratio.write(3, 3) // 4:3
+
+	var large bitWriter
+	large.write(0, 1)
+	large.write(2, 2)
+	large.write(10000-1, 18)
+	large.write(0, 3)
+	large.write(3, 2)
+	large.write(70000-1, 30)
+
+	codestream := func(b bitWriter) []byte { return append([]byte{0xff, 0x0a}, b.data...) }
+	container := append(append([]byte(nil), jxlContainer...), box("ftyp", []byte("jxl \x00\x00\x00\x00jxl "))...)
+	container = append(container, box("jxlp", append([]byte{0, 0, 0, 0}, codestream(small)...))...)
+
+	tests := []struct {
+		name string
+		data []byte
+		w, h int
+	}{
+		{"multiple of 8", codestream(small), 64, 32},
+		{"aspect ratio", codestream(ratio), 100, 75},
+		{"large", codestream(large), 70000, 10000},
+		{"container", container, 64, 32},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w, h, err := JXLSize(tt.data)
+			require.NoError(t, err)
+			assert.Equal(t, []int{tt.w, tt.h}, []int{w, h})
+		})
+	}
+
+	_, _, err := JXLSize([]byte{0xff, 0x0a})
+	assert.ErrorIs(t, err, ErrMalformed)
+}
@@ -0,0 +1,102 @@
+// Package sniff detects image formats from their leading bytes, including
+// the formats http.DetectContentType does not know about.
+package sniff
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// HeaderSize is the number of leading bytes ContentType looks at.
+const HeaderSize = 512
+
+var jxlContainer = []byte{0x00, 0x00, 0x00, 0x0c, 'J', 'X', 'L', ' ', 0x0d, 0x0a, 0x87, 0x0a}
+
+// ContentType returns the MIME type of data, which should hold at least the
+// first HeaderSize bytes of the file. It falls back to http.DetectContentType
+// for anything that is not an image.
+func ContentType(data []byte) string {
+	if len(data) > HeaderSize {
+		data = data[:HeaderSize]
+	}
+
+	switch {
+	case bytes.HasPrefix(data, []byte{0xff, 0xd8, 0xff}):
+		return "image/jpeg"
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return "image/png"
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return "image/gif"
+	case len(data) >= 12 && bytes.Equal(data[:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		return "image/webp"
+	case bytes.HasPrefix(data, []byte{0xff, 0x0a}), bytes.HasPrefix(data, jxlContainer):
+		return "image/jxl"
+	}
+
+	if brand, ok := ftypBrand(data); ok {
+		switch brand {
+		case "avif", "avis":
+			return "image/avif"
+		case "heic", "heix", "heim", "heis", "hevc", "hevx":
+			return "image/heic"
+		case "mif1", "msf1":
+			return "image/heif"
+		}
+	}
+
+	if isSVG(data) {
+		return "image/svg+xml"
+	}
+
+	return http.DetectContentType(data)
+}
+
+// isSVG reports whether data starts an SVG document: an svg root element,
+// possibly preceded by an XML declaration, comments or a doctype.
+func isSVG(data []byte) bool {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	for {
+		data = bytes.TrimLeft(data, " \t\r\n")
+		switch {
+		case bytes.HasPrefix(data, []byte("<svg")):
+			return len(data) > 4 && bytes.ContainsRune([]byte(" \t\r\n>/"), rune(data[4]))
+		case bytes.HasPrefix(data, []byte("<?")), bytes.HasPrefix(data, []byte("<!")):
+			end := []byte(">")
+			if bytes.HasPrefix(data, []byte("<!--")) {
+				end = []byte("-->")
+			}
+			i := bytes.Index(data, end)
+			if i < 0 {
+				return false
+			}
+			data = data[i+len(end):]
+		default:
+			return false
+		}
+	}
+}
+
+// ftypBrand returns the major brand of an ISO base media file, or of its first
+// image compatible brand when the major brand is the generic mif1.
+func ftypBrand(data []byte) (string, bool) {
+	if len(data) < 16 || !bytes.Equal(data[4:8], []byte("ftyp")) {
+		return "", false
+	}
+
+	size := int(data[0])<<24 | int(data[1])<<16 | int(data[2])<<8 | int(data[3])
+	if size < 16 || size > len(data) {
+		size = len(data)
+	}
+
+	major := string(data[8:12])
+	if major != "mif1" && major != "msf1" {
+		return major, true
+	}
+	for i := 16; i+4 <= size; i += 4 {
+		switch b := string(data[i : i+4]); b {
+		case "avif", "avis", "heic", "heix":
+			return b, true
+		}
+	}
+	return major, true
+}
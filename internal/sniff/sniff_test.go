@@ -0,0 +1,50 @@
+package sniff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentType(t *testing.T) {
+	t.Parallel()
+
+	ftyp := func(major string, compatible ...string) []byte {
+		box := []byte{0, 0, 0, byte(16 + 4*len(compatible)), 'f', 't', 'y', 'p'}
+		box = append(box, major...)
+		box = append(box, 0, 0, 0, 0)
+		for _, c := range compatible {
+			box = append(box, c...)
+		}
+		return append(box, "....mdat"...)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"jpeg", []byte{0xff, 0xd8, 0xff, 0xe0, 0, 0x10, 'J', 'F', 'I', 'F'}, "image/jpeg"},
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"), "image/png"},
+		{"gif", []byte("GIF89a\x01\x00\x01\x00"), "image/gif"},
+		{"webp", []byte("RIFF\x24\x00\x00\x00WEBPVP8 "), "image/webp"},
+		{"jxl codestream", []byte{0xff, 0x0a, 0xfa, 0x1f}, "image/jxl"},
+		{"jxl container", append(append([]byte(nil), jxlContainer...), "ftypjxl "...), "image/jxl"},
+		{"avif", ftyp("avif", "mif1", "miaf"), "image/avif"},
+		{"avif via mif1", ftyp("mif1", "miaf", "avif"), "image/avif"},
+		{"heic", ftyp("heic", "mif1", "heic"), "image/heic"},
+		{"heif", ftyp("mif1", "miaf"), "image/heif"},
+		{"mp4", ftyp("isom", "mp41"), "video/mp4"},
+		{"svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), "image/svg+xml"},
+		{"svg with prolog", []byte("<?xml version=\"1.0\"?>\n<!-- logo -->\n<!DOCTYPE svg>\n<svg>"), "image/svg+xml"},
+		{"xml", []byte(`<?xml version="1.0"?><feed>`), "text/xml; charset=utf-8"},
+		{"not svg", []byte("<svgfoo>"), "text/plain; charset=utf-8"},
+		{"text", []byte("hello"), "text/plain; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, ContentType(tt.data))
+		})
+	}
+}
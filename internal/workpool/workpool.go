@@ -0,0 +1,42 @@
+// Package workpool runs indexed work on a bounded number of goroutines.
+package workpool
+
+import (
+	"context"
+	"sync"
+)
+
+// ForEach calls fn for every index in [0, n) using at most concurrency
+// goroutines, or one if concurrency is not positive. It stops handing out
+// indexes once ctx is done and returns when every call has returned.
+func ForEach(ctx context.Context, n, concurrency int, fn func(ctx context.Context, i int)) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if concurrency > n {
+		concurrency = n
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(ctx, i)
+			}
+		}()
+	}
+
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+}
@@ -0,0 +1,42 @@
+package workpool
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForEach(t *testing.T) {
+	t.Parallel()
+
+	t.Run("calls every index", func(t *testing.T) {
+		t.Parallel()
+		var running, peak atomic.Int32
+		seen := make([]bool, 50)
+		ForEach(context.Background(), len(seen), 3, func(ctx context.Context, i int) {
+			n := running.Add(1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			seen[i] = true
+			running.Add(-1)
+		})
+		for i, ok := range seen {
+			assert.True(t, ok, i)
+		}
+		assert.LessOrEqual(t, peak.Load(), int32(3))
+	})
+
+	t.Run("stops once cancelled", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithCancel(context.Background())
+		var calls atomic.Int32
+		ForEach(ctx, 100, 0, func(ctx context.Context, i int) {
+			if calls.Add(1) == 5 {
+				cancel()
+			}
+		})
+		assert.Less(t, calls.Load(), int32(100))
+	})
+}
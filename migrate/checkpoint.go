@@ -0,0 +1,146 @@
+package migrate
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// checkpointEntry is a line of the checkpoint file.
+type checkpointEntry struct {
+	Key        string `json:"key"`
+	SourceURL  string `json:"source_url"`
+	TargetPath string `json:"target_path"`
+	ImageID    string `json:"image_id"`
+	URL        string `json:"url"`
+}
+
+// checkpoint tracks imported objects, appending each to a JSON lines file so
+// that a crash loses at most the uploads in flight.
+type checkpoint struct {
+	mu   sync.Mutex
+	done map[string]checkpointEntry
+	f    *os.File
+	enc  *json.Encoder
+}
+
+func openCheckpoint(name string) (*checkpoint, error) {
+	cp := &checkpoint{done: map[string]checkpointEntry{}}
+	if name == "" {
+		return cp, nil
+	}
+
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening checkpoint: %w", err)
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error reading checkpoint: %w", err)
+	}
+	// A torn final line from an interrupted run is dropped, so that the next
+	// record starts on a line of its own; the object is simply imported
+	// again.
+	if end := bytes.LastIndexByte(data, '\n') + 1; end < len(data) {
+		if err := f.Truncate(int64(end)); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("error repairing checkpoint: %w", err)
+		}
+		data = data[:end]
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		var entry checkpointEntry
+		if err := json.Unmarshal(sc.Bytes(), &entry); err != nil || entry.Key == "" {
+			continue
+		}
+		cp.done[entry.Key] = entry
+	}
+	if err := sc.Err(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error reading checkpoint: %w", err)
+	}
+
+	cp.f, cp.enc = f, json.NewEncoder(f)
+	return cp, nil
+}
+
+func (c *checkpoint) lookup(key string) (checkpointEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.done[key]
+	return entry, ok
+}
+
+func (c *checkpoint) record(entry checkpointEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.done[entry.Key] = entry
+	if c.enc == nil {
+		return nil
+	}
+	if err := c.enc.Encode(entry); err != nil {
+		return fmt.Errorf("error writing checkpoint: %w", err)
+	}
+	return nil
+}
+
+// entries returns every recorded entry sorted by key.
+func (c *checkpoint) entries() []checkpointEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries := make([]checkpointEntry, 0, len(c.done))
+	for _, entry := range c.done {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries
+}
+
+func (c *checkpoint) Close() error {
+	if c.f == nil {
+		return nil
+	}
+	return c.f.Close()
+}
+
+// mappingHeader is the header row of the mapping CSV.
+var mappingHeader = []string{"source_url", "url", "image_id", "target_path", "key"}
+
+func writeMapping(name string, entries []checkpointEntry) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error writing mapping: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	w := csv.NewWriter(tmp)
+	_ = w.Write(mappingHeader)
+	for _, e := range entries {
+		_ = w.Write([]string{e.SourceURL, e.URL, e.ImageID, e.TargetPath, e.Key})
+	}
+	w.Flush()
+	if err := errors.Join(w.Error(), tmp.Close()); err != nil {
+		return fmt.Errorf("error writing mapping: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return fmt.Errorf("error writing mapping: %w", err)
+	}
+	return nil
+}
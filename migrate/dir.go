@@ -0,0 +1,63 @@
+package migrate
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DirSource imports the files below a local directory. Keys are the
+// slash-separated paths relative to Root; hidden files and directories are
+// skipped.
+type DirSource struct {
+	Root string
+}
+
+// List implements Source.
+func (d *DirSource) List(ctx context.Context, fn func(Object) error) error {
+	root, err := filepath.Abs(d.Root)
+	if err != nil {
+		return err
+	}
+
+	return filepath.WalkDir(root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if name != root && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, name)
+		if err != nil {
+			return err
+		}
+		return fn(Object{
+			Key:  filepath.ToSlash(rel),
+			URL:  (&url.URL{Scheme: "file", Path: filepath.ToSlash(name)}).String(),
+			Size: info.Size(),
+		})
+	})
+}
+
+// Open implements Source.
+func (d *DirSource) Open(_ context.Context, obj Object) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(d.Root, filepath.FromSlash(obj.Key)))
+}
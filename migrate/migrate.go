@@ -0,0 +1,212 @@
+// Package migrate imports images from other storage into img-src.
+//
+// Objects are read from a Source, such as a local directory, a list of HTTP
+// URLs or an S3-compatible bucket, and uploaded one by one with
+// Images.Upload. Progress is checkpointed so that an interrupted import
+// resumes where it stopped, and a CSV maps every source URL to its new
+// img-src URL.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/internal/limit"
+	"github.com/img-src-io/sdk-go/internal/workpool"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/img-src-io/sdk-go/models/operations"
+)
+
+// ErrTooLarge is recorded for objects larger than Options.MaxSize.
+var ErrTooLarge = errors.New("object exceeds the maximum size")
+
+// Object is an object of a Source.
+type Object struct {
+	// Key identifies the object within the source, e.g. "products/42.jpg".
+	// It is the default target path.
+	Key string
+	// URL is where the object lives today. It is the first column of the
+	// mapping CSV.
+	URL string
+	// Size in bytes, or zero if unknown.
+	Size int64
+}
+
+// Source lists and reads the objects to import.
+type Source interface {
+	// List calls fn for every object, stopping at the first error.
+	List(ctx context.Context, fn func(Object) error) error
+	// Open returns the contents of an object listed by List.
+	Open(ctx context.Context, obj Object) (io.ReadCloser, error)
+}
+
+// Options configures Import.
+type Options struct {
+	// TargetPrefix is prepended to the keys to form target paths.
+	TargetPrefix string
+	// TargetPath, if set, maps an object to its target path instead.
+	TargetPath func(obj Object) string
+	// Visibility of the uploaded images. Defaults to the account default.
+	Visibility *components.Visibility
+	// MaxSize is the largest object imported, in bytes. Zero means no limit.
+	MaxSize int64
+	// Concurrency is the number of objects uploaded in parallel. Defaults to 4.
+	Concurrency int
+	// CheckpointFile records every imported object. Objects recorded in it
+	// by an earlier run are skipped.
+	CheckpointFile string
+	// MappingFile, if set, receives a CSV mapping every imported object,
+	// including those of earlier runs, to its img-src image.
+	MappingFile string
+	// OnObject, if set, is called after every object with its outcome. It may
+	// be called from several goroutines at once.
+	OnObject func(res Result)
+}
+
+// Result is the outcome of importing a single object.
+type Result struct {
+	Object     Object
+	TargetPath string
+	ImageID    string
+	// URL is the img-src URL of the image.
+	URL string
+	// Resumed reports that the object was imported by an earlier run.
+	Resumed bool
+	Err     error
+}
+
+// Report summarizes an Import run.
+type Report struct {
+	Results  []Result
+	Imported int
+	Resumed  int
+	Failed   int
+}
+
+// Err returns the errors of every failed object joined together, or nil.
+func (r *Report) Err() error {
+	if r == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, res := range r.Results {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.Object.Key, res.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Import uploads every object of src. Failures of individual objects are
+// recorded in the report rather than aborting the run; run Import again with
+// the same CheckpointFile to retry them.
+func Import(ctx context.Context, client *sdkgo.Imgsrc, src Source, opts *Options) (*Report, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	cp, err := openCheckpoint(opts.CheckpointFile)
+	if err != nil {
+		return nil, err
+	}
+	defer cp.Close()
+
+	var objects []Object
+	if err := src.List(ctx, func(obj Object) error {
+		objects = append(objects, obj)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("error listing source: %w", err)
+	}
+
+	report := &Report{Results: make([]Result, len(objects))}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	workpool.ForEach(ctx, len(objects), concurrency, func(ctx context.Context, i int) {
+		obj := objects[i]
+		res := Result{Object: obj, TargetPath: targetPath(obj, opts)}
+
+		if entry, ok := cp.lookup(obj.Key); ok {
+			res.ImageID, res.URL, res.Resumed = entry.ImageID, entry.URL, true
+		} else {
+			res.ImageID, res.URL, res.Err = importObject(ctx, client, src, obj, res.TargetPath, opts)
+			if res.Err == nil {
+				res.Err = cp.record(checkpointEntry{Key: obj.Key, SourceURL: obj.URL, TargetPath: res.TargetPath, ImageID: res.ImageID, URL: res.URL})
+			}
+		}
+
+		report.Results[i] = res
+		if opts.OnObject != nil {
+			opts.OnObject(res)
+		}
+	})
+	if err := ctx.Err(); err != nil {
+		return report, err
+	}
+
+	for _, res := range report.Results {
+		switch {
+		case res.Err != nil:
+			report.Failed++
+		case res.Resumed:
+			report.Resumed++
+		default:
+			report.Imported++
+		}
+	}
+
+	if opts.MappingFile != "" {
+		if err := writeMapping(opts.MappingFile, cp.entries()); err != nil {
+			return report, err
+		}
+	}
+
+	return report, report.Err()
+}
+
+func targetPath(obj Object, opts *Options) string {
+	if opts.TargetPath != nil {
+		return strings.Trim(opts.TargetPath(obj), "/")
+	}
+	return strings.Trim(path.Join(opts.TargetPrefix, obj.Key), "/")
+}
+
+func importObject(ctx context.Context, client *sdkgo.Imgsrc, src Source, obj Object, target string, opts *Options) (id, url string, err error) {
+	if opts.MaxSize > 0 && obj.Size > opts.MaxSize {
+		return "", "", fmt.Errorf("%w: %d bytes", ErrTooLarge, obj.Size)
+	}
+
+	body, err := src.Open(ctx, obj)
+	if err != nil {
+		return "", "", fmt.Errorf("error opening object: %w", err)
+	}
+	defer body.Close()
+
+	var content io.Reader = body
+	if opts.MaxSize > 0 {
+		content = limit.NewReader(body, opts.MaxSize, ErrTooLarge)
+	}
+
+	res, err := client.Images.Upload(ctx, &operations.UploadImageRequestBody{
+		File:       &operations.File{FileName: path.Base(target), Content: content},
+		TargetPath: sdkgo.String(target),
+		Visibility: opts.Visibility,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	out := res.GetUploadResponse()
+	if out == nil {
+		return "", "", errors.New("empty upload response")
+	}
+	return out.ID, out.URL, nil
+}
@@ -0,0 +1,264 @@
+package migrate_test
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/img-src-io/sdk-go/internal/fakeapi"
+	"github.com/img-src-io/sdk-go/migrate"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bucket is a minimal S3-compatible server holding a single bucket. It pages
+// listings two keys at a time and rejects unsigned requests.
+type bucket struct {
+	*httptest.Server
+	name    string
+	objects map[string]string
+
+	mu   sync.Mutex
+	fail map[string]bool
+}
+
+func newBucket(t *testing.T, name string, objects map[string]string) *bucket {
+	t.Helper()
+	b := &bucket{name: name, objects: objects, fail: map[string]bool{}}
+	b.Server = httptest.NewServer(http.HandlerFunc(b.serve))
+	t.Cleanup(b.Close)
+	return b
+}
+
+func (b *bucket) setFail(key string, fail bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.fail[key] = fail
+}
+
+func (b *bucket) serve(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=minio/") ||
+		r.Header.Get("X-Amz-Date") == "" {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+		return
+	}
+
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+b.name+"/")
+	if !ok && r.URL.Path != "/"+b.name {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+	if key != "" {
+		b.mu.Lock()
+		fail := b.fail[key]
+		b.mu.Unlock()
+		data, ok := b.objects[key]
+		switch {
+		case fail:
+			http.Error(w, "AccessDenied", http.StatusForbidden)
+		case !ok:
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+		default:
+			_, _ = w.Write([]byte(data))
+		}
+		return
+	}
+
+	q := r.URL.Query()
+	if q.Get("list-type") != "2" {
+		http.Error(w, "InvalidRequest", http.StatusBadRequest)
+		return
+	}
+	var keys []string
+	for k := range b.objects {
+		if strings.HasPrefix(k, q.Get("prefix")) && k > q.Get("continuation-token") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	type content struct {
+		Key  string
+		Size int
+	}
+	res := struct {
+		XMLName               xml.Name `xml:"ListBucketResult"`
+		Contents              []content
+		IsTruncated           bool
+		NextContinuationToken string `xml:",omitempty"`
+	}{}
+	if len(keys) > 2 {
+		keys = keys[:2]
+		res.IsTruncated, res.NextContinuationToken = true, keys[1]
+	}
+	for _, k := range keys {
+		res.Contents = append(res.Contents, content{k, len(b.objects[k])})
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(res)
+}
+
+func readCSV(t *testing.T, name string) [][]string {
+	t.Helper()
+	f, err := os.Open(name)
+	require.NoError(t, err)
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+	return rows
+}
+
+func TestImport_S3(t *testing.T) {
+	t.Parallel()
+	b := newBucket(t, "legacy", map[string]string{
+		"media/products/1.jpg":      "one",
+		"media/products/2 copy.jpg": "two",
+		"media/banner.png":          "three",
+		"media/folder/":             "",
+		"other/skip.png":            "skip",
+	})
+	api := fakeapi.New(t)
+	dir := t.TempDir()
+	opts := &migrate.Options{
+		TargetPrefix:   "imported",
+		Visibility:     components.VisibilityPrivate.ToPointer(),
+		CheckpointFile: filepath.Join(dir, "checkpoint.jsonl"),
+		MappingFile:    filepath.Join(dir, "mapping.csv"),
+	}
+	src := &migrate.S3Source{Endpoint: b.URL, Bucket: "legacy", Prefix: "media/", AccessKeyID: "minio", SecretAccessKey: "minio123"}
+
+	b.setFail("media/products/2 copy.jpg", true)
+	report, err := migrate.Import(context.Background(), api.SDK(), src, opts)
+	require.Error(t, err)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, 2, api.Len())
+
+	b.setFail("media/products/2 copy.jpg", false)
+	report, err = migrate.Import(context.Background(), api.SDK(), src, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Imported)
+	assert.Equal(t, 2, report.Resumed)
+	assert.Equal(t, 3, api.Len())
+	assert.Equal(t, 3, api.Count("POST /api/v1/images"))
+
+	for _, img := range api.Images() {
+		assert.Equal(t, "private", img.Visibility)
+	}
+	assert.ElementsMatch(t, []string{"imported/banner.png", "imported/products/1.jpg", "imported/products/2 copy.jpg"},
+		[]string{api.Images()[0].Paths[0], api.Images()[1].Paths[0], api.Images()[2].Paths[0]})
+
+	rows := readCSV(t, opts.MappingFile)
+	require.Len(t, rows, 4)
+	assert.Equal(t, []string{"source_url", "url", "image_id", "target_path", "key"}, rows[0])
+	assert.Equal(t, b.URL+"/legacy/media/products/2%20copy.jpg", rows[3][0])
+	assert.Equal(t, "/alice/imported/products/2 copy.jpg", rows[3][1])
+	assert.Equal(t, "imported/products/2 copy.jpg", rows[3][3])
+	assert.Equal(t, "products/2 copy.jpg", rows[3][4])
+}
+
+func TestImport_Dir(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	for name, data := range map[string]string{
+		"a.png":         "a",
+		"sub/b.jpg":     "b",
+		".hidden/c.gif": "c",
+		"big.png":       strings.Repeat("x", 100),
+	} {
+		name = filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(name), 0o755))
+		require.NoError(t, os.WriteFile(name, []byte(data), 0o644))
+	}
+	api := fakeapi.New(t)
+
+	var mu sync.Mutex
+	var seen []string
+	report, err := migrate.Import(context.Background(), api.SDK(), &migrate.DirSource{Root: root}, &migrate.Options{
+		MaxSize:    10,
+		TargetPath: func(obj migrate.Object) string { return "site/" + strings.ToUpper(obj.Key) },
+		OnObject: func(res migrate.Result) {
+			mu.Lock()
+			defer mu.Unlock()
+			seen = append(seen, res.Object.Key)
+		},
+	})
+	require.ErrorIs(t, err, migrate.ErrTooLarge)
+	assert.ElementsMatch(t, []string{"a.png", "big.png", "sub/b.jpg"}, seen)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, 1, report.Failed)
+
+	paths := []string{api.Images()[0].Paths[0], api.Images()[1].Paths[0]}
+	assert.ElementsMatch(t, []string{"site/A.PNG", "site/SUB/B.JPG"}, paths)
+}
+
+func TestImport_TornCheckpoint(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	for _, name := range []string{"a.png", "b.png", "c.png"} {
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(name), 0o644))
+	}
+	api := fakeapi.New(t)
+	opts := &migrate.Options{CheckpointFile: filepath.Join(t.TempDir(), "checkpoint.jsonl")}
+	src := &migrate.DirSource{Root: root}
+
+	report, err := migrate.Import(context.Background(), api.SDK(), src, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Imported)
+
+	// An interrupted run leaves the last record half written.
+	data, err := os.ReadFile(opts.CheckpointFile)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(opts.CheckpointFile, data[:len(data)-10], 0o644))
+
+	report, err = migrate.Import(context.Background(), api.SDK(), src, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Imported)
+	assert.Equal(t, 2, report.Resumed)
+
+	// The record written after the torn line survives the next resume.
+	report, err = migrate.Import(context.Background(), api.SDK(), src, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.Imported)
+	assert.Equal(t, 3, report.Resumed)
+	assert.Equal(t, 3, api.Len())
+}
+
+func TestImport_URLList(t *testing.T) {
+	t.Parallel()
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing.png" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, "data of %s", r.URL.Path)
+	}))
+	t.Cleanup(origin.Close)
+
+	list := fmt.Sprintf("# exported from the old CMS\n%[1]s/uploads/2023/a.jpg\n\n  %[1]s/uploads/b.png  \n%[1]s/missing.png\n", origin.URL)
+	urls, err := migrate.ReadURLList(strings.NewReader(list))
+	require.NoError(t, err)
+	require.Len(t, urls, 3)
+
+	api := fakeapi.New(t)
+	mapping := filepath.Join(t.TempDir(), "mapping.csv")
+	report, err := migrate.Import(context.Background(), api.SDK(), &migrate.URLSource{URLs: urls}, &migrate.Options{MappingFile: mapping})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+	assert.Equal(t, 2, report.Imported)
+
+	rows := readCSV(t, mapping)
+	require.Len(t, rows, 3)
+	assert.Equal(t, []string{origin.URL + "/uploads/2023/a.jpg", "/alice/uploads/2023/a.jpg"}, rows[1][:2])
+	assert.Equal(t, []string{origin.URL + "/uploads/b.png", "/alice/uploads/b.png"}, rows[2][:2])
+}
@@ -0,0 +1,150 @@
+package migrate
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// S3Source imports the objects of a bucket on Amazon S3 or any S3-compatible
+// store, such as MinIO, Cloudflare R2 or Google Cloud Storage in
+// interoperability mode. Keys are the object keys with Prefix removed.
+//
+// Requests are signed with AWS Signature Version 4 when AccessKeyID is set and
+// sent anonymously otherwise.
+type S3Source struct {
+	// Endpoint is the base URL of the store, e.g. "https://s3.eu-west-1.amazonaws.com"
+	// or "http://localhost:9000".
+	Endpoint string
+	Bucket   string
+	// Prefix limits the import to keys starting with it.
+	Prefix string
+	// Region defaults to "us-east-1".
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// VirtualHosted addresses the bucket as a subdomain of Endpoint instead of
+	// as its first path segment.
+	VirtualHosted bool
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	now func() time.Time
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key  string `xml:"Key"`
+		Size int64  `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List implements Source using ListObjectsV2.
+func (s *S3Source) List(ctx context.Context, fn func(Object) error) error {
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}}
+		if s.Prefix != "" {
+			query.Set("prefix", s.Prefix)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		u, err := s.url("")
+		if err != nil {
+			return err
+		}
+		u.RawQuery = query.Encode()
+
+		body, err := s.get(ctx, u)
+		if err != nil {
+			return err
+		}
+		var page listBucketResult
+		err = xml.NewDecoder(body).Decode(&page)
+		body.Close()
+		if err != nil {
+			return fmt.Errorf("error decoding bucket listing: %w", err)
+		}
+
+		for _, c := range page.Contents {
+			if strings.HasSuffix(c.Key, "/") {
+				continue
+			}
+			key := strings.TrimLeft(strings.TrimPrefix(c.Key, s.Prefix), "/")
+			u, err := s.url(c.Key)
+			if err != nil {
+				return err
+			}
+			if err := fn(Object{Key: key, URL: u.String(), Size: c.Size}); err != nil {
+				return err
+			}
+		}
+
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// Open implements Source.
+func (s *S3Source) Open(ctx context.Context, obj Object) (io.ReadCloser, error) {
+	u, err := url.Parse(obj.URL)
+	if err != nil {
+		return nil, err
+	}
+	return s.get(ctx, u)
+}
+
+// url returns the URL of an object key, or of the bucket if key is empty.
+func (s *S3Source) url(key string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSuffix(s.Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+
+	p := "/" + key
+	if s.VirtualHosted {
+		u.Host = s.Bucket + "." + u.Host
+	} else {
+		p = "/" + s.Bucket + p
+	}
+	u.Path = u.Path + p
+	u.RawPath = u.Path[:len(u.Path)-len(p)] + s3Escape(p)
+	return u, nil
+}
+
+func (s *S3Source) get(ctx context.Context, u *url.URL) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.AccessKeyID != "" {
+		now := time.Now
+		if s.now != nil {
+			now = s.now
+		}
+		region := s.Region
+		if region == "" {
+			region = "us-east-1"
+		}
+		req.Header.Set("X-Amz-Content-Sha256", emptySHA256)
+		if s.SessionToken != "" {
+			req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+		}
+		signV4(req, s.AccessKeyID, s.SecretAccessKey, region, "s3", now())
+	}
+
+	return get(s.HTTPClient, req)
+}
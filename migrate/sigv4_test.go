@@ -0,0 +1,47 @@
+package migrate
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignV4(t *testing.T) {
+	t.Parallel()
+
+	// The "get-vanilla" case of the AWS Signature Version 4 test suite.
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	require.NoError(t, err)
+	signV4(req, "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "us-east-1", "service",
+		time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		req.Header.Get("Authorization"))
+}
+
+func TestCanonicalEscaping(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in, want string
+	}{
+		{"/bucket/a b/c+d.jpg", "/bucket/a%20b/c%2Bd.jpg"},
+		{"/bucket/a%20b/%C3%A9.png", "/bucket/a%20b/%C3%A9.png"},
+		{"/bucket/~user/x_y-z.gif", "/bucket/~user/x_y-z.gif"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, s3Escape(tt.in))
+		})
+	}
+
+	assert.Equal(t, "a=1&continuation-token=x%2By%3D&list-type=2", canonicalQuery(map[string][]string{
+		"list-type": {"2"}, "continuation-token": {"x+y="}, "a": {"1"},
+	}))
+}
@@ -0,0 +1,82 @@
+package migrate
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// URLSource imports images from arbitrary HTTP origins. The key of each URL
+// is its path without the leading slash, so that
+// "https://cdn.example.com/products/42.jpg" keeps the path "products/42.jpg".
+type URLSource struct {
+	URLs []string
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// ReadURLList reads one URL per line, ignoring blank lines and lines starting
+// with "#".
+func ReadURLList(r io.Reader) ([]string, error) {
+	var urls []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	return urls, sc.Err()
+}
+
+// List implements Source.
+func (s *URLSource) List(_ context.Context, fn func(Object) error) error {
+	for _, raw := range s.URLs {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return err
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("unsupported URL %q", raw)
+		}
+
+		key := strings.Trim(u.Path, "/")
+		if key == "" {
+			key = u.Host
+		}
+		if err := fn(Object{Key: key, URL: raw}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Open implements Source.
+func (s *URLSource) Open(ctx context.Context, obj Object) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, obj.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return get(s.HTTPClient, req)
+}
+
+func get(client *http.Client, req *http.Request) (io.ReadCloser, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("GET %s: unexpected status %s", req.URL.Redacted(), res.Status)
+	}
+	return res.Body, nil
+}
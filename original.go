@@ -0,0 +1,130 @@
+package sdkgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/img-src-io/sdk-go/internal/utils"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/img-src-io/sdk-go/models/operations"
+)
+
+// OpenOriginal downloads the original bytes of an image. Private images are
+// fetched through a freshly minted signed URL. The caller closes the body.
+func (s *Images) OpenOriginal(ctx context.Context, meta *components.MetadataResponse, opts ...operations.Option) (io.ReadCloser, error) {
+	if meta == nil {
+		return nil, errors.New("missing image metadata")
+	}
+
+	src := meta.Urls.Original
+	if meta.Visibility == components.VisibilityPrivate || src == "" {
+		res, err := s.CreateSignedURL(ctx, meta.ID, &components.CreateSignedURLRequest{}, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("error creating signed URL: %w", err)
+		}
+		src = res.GetSignedURLResponse().GetSignedURL()
+	}
+
+	return s.open(ctx, src, "original")
+}
+
+// OpenVariant downloads a transformed variant of an image from the CDN, e.g.
+// a PNG of at most 64×64 pixels. Without a format, the variant is in the
+// format of the original. Private images are fetched through a freshly
+// minted signed URL carrying the transformation. The caller closes the body.
+func (s *Images) OpenVariant(ctx context.Context, meta *components.MetadataResponse, t components.Transformation, opts ...operations.Option) (io.ReadCloser, error) {
+	if meta == nil {
+		return nil, errors.New("missing image metadata")
+	}
+
+	src := meta.Urls.Original
+	if t.Format != nil {
+		src = map[components.Format]string{
+			components.FormatWebp: meta.Urls.Webp,
+			components.FormatAvif: meta.Urls.Avif,
+			components.FormatJpeg: meta.Urls.Jpeg,
+			components.FormatPng:  meta.Urls.Png,
+			components.FormatJxl:  meta.Urls.Jxl,
+		}[*t.Format]
+	}
+	if meta.Visibility == components.VisibilityPrivate || src == "" {
+		res, err := s.CreateSignedURL(ctx, meta.ID, &components.CreateSignedURLRequest{Transformation: &t}, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("error creating signed URL: %w", err)
+		}
+		return s.open(ctx, res.GetSignedURLResponse().GetSignedURL(), "variant")
+	}
+
+	u, err := url.Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing variant URL: %w", err)
+	}
+	q := u.Query()
+	if t.Width != nil {
+		q.Set("w", strconv.FormatInt(*t.Width, 10))
+	}
+	if t.Height != nil {
+		q.Set("h", strconv.FormatInt(*t.Height, 10))
+	}
+	if t.Fit != nil {
+		q.Set("fit", string(*t.Fit))
+	}
+	if t.Quality != nil {
+		q.Set("q", strconv.FormatInt(*t.Quality, 10))
+	}
+	u.RawQuery = q.Encode()
+	return s.open(ctx, u.String(), "variant")
+}
+
+// open downloads src, resolved against the server URL if it is relative.
+// what names the download in errors.
+func (s *Images) open(ctx context.Context, src, what string) (io.ReadCloser, error) {
+	u, err := url.Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s URL: %w", what, err)
+	}
+	if !u.IsAbs() {
+		base, err := url.Parse(utils.ReplaceParameters(s.sdkConfiguration.GetServerDetails()))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing server URL: %w", err)
+		}
+		u = base.ResolveReference(u)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("User-Agent", s.sdkConfiguration.UserAgent)
+
+	res, err := s.sdkConfiguration.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading %s: %w", what, err)
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("error downloading %s: unexpected status %d", what, res.StatusCode)
+	}
+
+	return res.Body, nil
+}
+
+// fetchOriginal is OpenOriginal reading the whole body into memory.
+func (s *Images) fetchOriginal(ctx context.Context, meta *components.MetadataResponse, opts ...operations.Option) ([]byte, error) {
+	body, err := s.OpenOriginal(ctx, meta, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading original: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,57 @@
+package sdkgo_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/img-src-io/sdk-go/internal/fakeapi"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenVariant(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	images := f.SDK().Images
+	public := f.Add("public bytes", "a.png")
+	private := f.Add("private bytes", "b.png")
+	f.Update(private, func(img *fakeapi.Image) { img.Visibility = "private" })
+
+	var mu sync.Mutex
+	var queries []string
+	f.Fail(func(r *http.Request) int {
+		if r.Method == http.MethodGet && r.URL.Query().Get("w") != "" {
+			mu.Lock()
+			queries = append(queries, r.URL.RawQuery)
+			mu.Unlock()
+		}
+		return 0
+	})
+
+	size := int64(64)
+	variant := components.Transformation{Width: &size, Height: &size, Fit: components.FitContain.ToPointer(), Format: components.FormatPng.ToPointer()}
+	read := func(id string) string {
+		res, err := images.GetMetadata(context.Background(), id)
+		require.NoError(t, err)
+		body, err := images.OpenVariant(context.Background(), res.GetMetadataResponse(), variant)
+		require.NoError(t, err)
+		defer body.Close()
+		data, err := io.ReadAll(body)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	assert.Equal(t, "public bytes", read(public))
+	assert.Equal(t, []string{"fit=contain&h=64&w=64"}, queries)
+
+	// The transformation of private images is part of the signed URL.
+	assert.Equal(t, "private bytes", read(private))
+	assert.Equal(t, 1, f.Count("POST /api/v1/images/{id}/signed-url"))
+
+	_, err := images.OpenVariant(context.Background(), nil, variant)
+	assert.ErrorContains(t, err, "missing image metadata")
+}
@@ -0,0 +1,215 @@
+package sdkgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/img-src-io/sdk-go/internal/hooks"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/img-src-io/sdk-go/models/operations"
+)
+
+var (
+	// ErrPathNotFound is returned when no image is stored under a path.
+	ErrPathNotFound = errors.New("path not found")
+	// ErrNotDeduplicated is returned by AddPath when re-uploading an image's
+	// original did not resolve to the same image. The upload is rolled back.
+	ErrNotDeduplicated = errors.New("re-upload did not deduplicate to the same image")
+)
+
+// PathOperationResult describes the outcome of AddPath, CopyPath and MovePath.
+type PathOperationResult struct {
+	ImageID string
+	// From is the source path, empty for AddPath.
+	From string
+	To   string
+	// Paths are the paths of the image after the operation.
+	Paths []string
+}
+
+// AddPath makes the image available under an additional path.
+//
+// The API has no dedicated endpoint for this, so the original is downloaded
+// and uploaded again with the new target path; the server deduplicates the
+// bytes and attaches the path to the existing image. If the upload lands on a
+// different image it is undone and ErrNotDeduplicated is returned.
+func (s *Images) AddPath(ctx context.Context, id string, to string, opts ...operations.Option) (*PathOperationResult, error) {
+	to = strings.Trim(to, "/")
+	if to == "" {
+		return nil, errors.New("target path must not be empty")
+	}
+
+	res, err := s.GetMetadata(ctx, id, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting image: %w", err)
+	}
+	meta := res.GetMetadataResponse()
+
+	data, err := s.fetchOriginal(ctx, meta, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	visibility := meta.Visibility
+	up, err := s.Upload(hooks.WithExactUpload(ctx), &operations.UploadImageRequestBody{
+		File:       &operations.File{FileName: path.Base(to), Content: data},
+		TargetPath: String(to),
+		Visibility: &visibility,
+	}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error adding path %s: %w", to, err)
+	}
+	out := up.GetUploadResponse()
+	if out == nil {
+		return nil, fmt.Errorf("error adding path %s: empty upload response", to)
+	}
+
+	if out.ID != id {
+		err := fmt.Errorf("%w: got %s, want %s", ErrNotDeduplicated, out.ID, id)
+		if rbErr := s.undoUpload(ctx, out, to, opts...); rbErr != nil {
+			return nil, fmt.Errorf("%w; rollback failed: %w", err, rbErr)
+		}
+		return nil, err
+	}
+
+	return &PathOperationResult{ImageID: id, To: to, Paths: out.Paths}, nil
+}
+
+// CopyPath makes the image stored under from also available under to. See
+// AddPath for how the path is attached.
+func (s *Images) CopyPath(ctx context.Context, from string, to string, opts ...operations.Option) (*PathOperationResult, error) {
+	from = strings.Trim(from, "/")
+	img, err := s.FindPath(ctx, from, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.AddPath(ctx, img.ID, to, opts...)
+	if err != nil {
+		return nil, err
+	}
+	result.From = from
+	return result, nil
+}
+
+// MovePath renames a path of an image, e.g. "a/b.jpg" to "c/b.jpg". The new
+// path is added first and the old one removed afterwards; if the removal
+// fails the new path is removed again, so on error the image keeps its
+// original paths. The paths are removed under the username returned by
+// Settings.Get.
+func (s *Images) MovePath(ctx context.Context, from string, to string, opts ...operations.Option) (*PathOperationResult, error) {
+	from = strings.Trim(from, "/")
+	to = strings.Trim(to, "/")
+	if from == to {
+		return nil, errors.New("source and target paths are the same")
+	}
+
+	img, err := s.FindPath(ctx, from, opts...)
+	if err != nil {
+		return nil, err
+	}
+	added := !containsPath(img.Paths, to)
+
+	username, err := s.resolveUsername(ctx, "", opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.AddPath(ctx, img.ID, to, opts...)
+	if err != nil {
+		return nil, err
+	}
+	result.From = from
+
+	res, err := s.DeletePath(ctx, username, from, opts...)
+	if err != nil {
+		err = fmt.Errorf("error removing path %s: %w", from, err)
+		if added {
+			if _, rbErr := s.DeletePath(ctx, username, to, opts...); rbErr != nil {
+				return nil, fmt.Errorf("%w; rollback failed: %w", err, rbErr)
+			}
+		}
+		return nil, err
+	}
+
+	if out := res.GetPathDeleteResponse(); out != nil {
+		result.Paths = out.RemainingPaths
+	}
+	return result, nil
+}
+
+// FindPath returns the image stored under path p by listing its folder. It
+// returns ErrPathNotFound if no image has that path.
+func (s *Images) FindPath(ctx context.Context, p string, opts ...operations.Option) (*components.ImageListItem, error) {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return nil, errors.New("path must not be empty")
+	}
+
+	var folder *string
+	if dir := path.Dir(p); dir != "." {
+		folder = String(dir)
+	}
+
+	res, err := s.List(ctx, Int64(walkPageSize), nil, folder, opts...)
+	for err == nil && res != nil {
+		page := res.GetImageListResponse()
+		if page == nil {
+			break
+		}
+		for i := range page.Images {
+			if containsPath(page.Images[i].Paths, p) {
+				return &page.Images[i], nil
+			}
+		}
+		if !page.HasMore || res.Next == nil {
+			break
+		}
+		res, err = res.Next()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error listing images: %w", err)
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrPathNotFound, p)
+}
+
+// undoUpload removes what a re-upload under path p added: the whole image
+// when it was new, otherwise just the path.
+func (s *Images) undoUpload(ctx context.Context, out *components.UploadResponse, p string, opts ...operations.Option) error {
+	if out.IsNew != nil && *out.IsNew {
+		_, err := s.Delete(ctx, out.ID, opts...)
+		return err
+	}
+
+	username, err := s.resolveUsername(ctx, "", opts...)
+	if err != nil {
+		return err
+	}
+	_, err = s.DeletePath(ctx, username, p, opts...)
+	return err
+}
+
+// resolveUsername returns username, falling back to the account's username
+// from the settings when it is empty.
+func (s *Images) resolveUsername(ctx context.Context, username string, opts ...operations.Option) (string, error) {
+	if username != "" {
+		return username, nil
+	}
+
+	res, err := s.rootSDK.Settings.Get(ctx, opts...)
+	if err != nil {
+		return "", fmt.Errorf("error resolving username: %w", err)
+	}
+	return res.GetSettingsResponse().GetSettings().Username, nil
+}
+
+func containsPath(paths []string, p string) bool {
+	return slices.ContainsFunc(paths, func(q string) bool {
+		return strings.Trim(q, "/") == p
+	})
+}
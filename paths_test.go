@@ -0,0 +1,142 @@
+package sdkgo_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/internal/fakeapi"
+	"github.com/img-src-io/sdk-go/models/operations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImages_MovePath(t *testing.T) {
+	t.Parallel()
+
+	t.Run("moves the path", func(t *testing.T) {
+		t.Parallel()
+		f := fakeapi.New(t)
+		id := f.Add("photo", "a/b.jpg", "keep.jpg")
+
+		res, err := f.SDK().Images.MovePath(context.Background(), "/a/b.jpg", "c/b.jpg")
+		require.NoError(t, err)
+		assert.Equal(t, id, res.ImageID)
+		assert.Equal(t, "a/b.jpg", res.From)
+		assert.Equal(t, "c/b.jpg", res.To)
+		assert.ElementsMatch(t, []string{"keep.jpg", "c/b.jpg"}, res.Paths)
+
+		assert.Equal(t, 1, f.Len())
+		assert.ElementsMatch(t, []string{"keep.jpg", "c/b.jpg"}, f.Image(id).Paths)
+	})
+
+	t.Run("private image", func(t *testing.T) {
+		t.Parallel()
+		f := fakeapi.New(t)
+		id := f.Add("secret", "a/b.jpg")
+		f.Update(id, func(img *fakeapi.Image) { img.Visibility = "private" })
+
+		_, err := f.SDK().Images.MovePath(context.Background(), "a/b.jpg", "c/b.jpg")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"c/b.jpg"}, f.Image(id).Paths)
+		assert.Equal(t, "private", f.Image(id).Visibility)
+		assert.Equal(t, 1, f.Count("POST /api/v1/images/{id}/signed-url"))
+	})
+
+	t.Run("rolls back when removing the source fails", func(t *testing.T) {
+		t.Parallel()
+		f := fakeapi.New(t)
+		id := f.Add("photo", "a/b.jpg")
+		f.Fail(func(r *http.Request) int {
+			if r.Method == http.MethodDelete && strings.HasSuffix(r.URL.Path, "/a/b.jpg") {
+				return http.StatusForbidden
+			}
+			return 0
+		})
+
+		_, err := f.SDK().Images.MovePath(context.Background(), "a/b.jpg", "c/b.jpg")
+		require.Error(t, err)
+		assert.Equal(t, []string{"a/b.jpg"}, f.Image(id).Paths)
+	})
+
+	t.Run("target taken", func(t *testing.T) {
+		t.Parallel()
+		f := fakeapi.New(t)
+		id := f.Add("photo", "a/b.jpg")
+		other := f.Add("other", "c/b.jpg")
+
+		_, err := f.SDK().Images.MovePath(context.Background(), "a/b.jpg", "c/b.jpg")
+		require.Error(t, err)
+		assert.Equal(t, []string{"a/b.jpg"}, f.Image(id).Paths)
+		assert.Equal(t, []string{"c/b.jpg"}, f.Image(other).Paths)
+	})
+
+	t.Run("missing source", func(t *testing.T) {
+		t.Parallel()
+		f := fakeapi.New(t)
+
+		_, err := f.SDK().Images.MovePath(context.Background(), "a/b.jpg", "c/b.jpg")
+		require.ErrorIs(t, err, sdkgo.ErrPathNotFound)
+	})
+}
+
+func TestImages_CopyPath(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	id := f.Add("photo", "a/b.jpg")
+
+	res, err := f.SDK().Images.CopyPath(context.Background(), "a/b.jpg", "c/d/b.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, id, res.ImageID)
+	assert.Equal(t, []string{"a/b.jpg", "c/d/b.jpg"}, f.Image(id).Paths)
+	assert.Equal(t, 1, f.Len())
+}
+
+func TestImages_AddPath_NotDeduplicated(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	id := f.Add("photo", "a/b.jpg")
+
+	// Serve different bytes than were stored, as a re-encoding CDN would.
+	f.Fail(func(r *http.Request) int {
+		if strings.HasPrefix(r.URL.Path, "/cdn/") {
+			f.Update(id, func(img *fakeapi.Image) { img.Data = []byte("re-encoded") })
+		}
+		return 0
+	})
+
+	_, err := f.SDK().Images.AddPath(context.Background(), id, "c/b.jpg")
+	require.ErrorIs(t, err, sdkgo.ErrNotDeduplicated)
+	assert.Equal(t, 1, f.Len())
+	assert.Equal(t, []string{"a/b.jpg"}, f.Image(id).Paths)
+}
+
+func TestImages_AddPath_IgnoresUploadTransforms(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	id := f.Add("photo", "a/b.jpg")
+
+	// A transform rewriting the bytes would defeat the deduplication the
+	// re-upload relies on.
+	sdk := f.SDK(sdkgo.WithUploadTransforms(func(_ string, r io.Reader) (io.Reader, error) {
+		return io.MultiReader(r, strings.NewReader(" stripped")), nil
+	}))
+	res, err := sdk.Images.AddPath(context.Background(), id, "c/b.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, id, res.ImageID)
+	assert.Equal(t, []string{"a/b.jpg", "c/b.jpg"}, f.Image(id).Paths)
+	assert.Equal(t, 1, f.Len())
+
+	// Other uploads are still transformed.
+	_, err = sdk.Images.Upload(context.Background(), &operations.UploadImageRequestBody{File: &operations.File{FileName: "d.jpg", Content: []byte("other")}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, f.Len())
+	found := false
+	for _, img := range f.Images() {
+		found = found || string(img.Data) == "other stripped"
+	}
+	assert.True(t, found)
+}
@@ -0,0 +1,148 @@
+package placeholder
+
+import (
+	"container/list"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DefaultCacheSize is the capacity used by NewLRU when size is not positive.
+const DefaultCacheSize = 4096
+
+// Cache stores placeholders by ImageMetadata.Hash, the SHA-256 of the
+// image content. Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(hash string) (*Placeholder, bool)
+	Set(hash string, p *Placeholder)
+}
+
+// LRU is an in-memory Cache that evicts the least recently used placeholder
+// once it holds more than its capacity.
+type LRU struct {
+	mu      sync.Mutex
+	size    int
+	ll      *list.List
+	entries map[string]*list.Element
+}
+
+type lruItem struct {
+	hash string
+	p    *Placeholder
+}
+
+var _ Cache = (*LRU)(nil)
+
+// NewLRU creates an LRU holding at most size placeholders.
+func NewLRU(size int) *LRU {
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+
+	return &LRU{
+		size:    size,
+		ll:      list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+func (l *LRU) Get(hash string) (*Placeholder, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	el, ok := l.entries[hash]
+	if !ok {
+		return nil, false
+	}
+
+	l.ll.MoveToFront(el)
+	return el.Value.(*lruItem).p, true
+}
+
+func (l *LRU) Set(hash string, p *Placeholder) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if el, ok := l.entries[hash]; ok {
+		el.Value.(*lruItem).p = p
+		l.ll.MoveToFront(el)
+		return
+	}
+
+	l.entries[hash] = l.ll.PushFront(&lruItem{hash: hash, p: p})
+
+	for l.ll.Len() > l.size {
+		oldest := l.ll.Back()
+		l.ll.Remove(oldest)
+		delete(l.entries, oldest.Value.(*lruItem).hash)
+	}
+}
+
+// Len returns the number of placeholders currently held.
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.ll.Len()
+}
+
+// DirCache is a Cache persisting placeholders as JSON files in a directory,
+// so that they survive restarts. Errors writing to it are ignored, the
+// placeholder is computed again next time.
+type DirCache string
+
+var _ Cache = DirCache("")
+
+func (d DirCache) Get(hash string) (*Placeholder, bool) {
+	name, ok := d.file(hash)
+	if !ok {
+		return nil, false
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, false
+	}
+	var p Placeholder
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, false
+	}
+	return &p, true
+}
+
+func (d DirCache) Set(hash string, p *Placeholder) {
+	name, ok := d.file(hash)
+	if !ok {
+		return
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(string(d), 0o755); err != nil {
+		return
+	}
+
+	tmp, err := os.CreateTemp(string(d), filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if err = errors.Join(err, tmp.Close()); err == nil {
+		err = os.Rename(tmp.Name(), name)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// file returns the file of hash, which must be hex so that it cannot name a
+// file outside the directory.
+func (d DirCache) file(hash string) (string, bool) {
+	if _, err := hex.DecodeString(hash); err != nil || hash == "" {
+		return "", false
+	}
+	return filepath.Join(string(d), hash+".json"), true
+}
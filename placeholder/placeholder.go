@@ -0,0 +1,276 @@
+// Package placeholder computes BlurHash, ThumbHash and LQIP placeholders to
+// show while images load, from the bytes of uploads or from a small variant
+// fetched from the CDN, and caches them by the hash of the image content.
+package placeholder
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"sync"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/imgproc"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/img-src-io/sdk-go/models/operations"
+)
+
+const (
+	// DefaultBlurHashX and DefaultBlurHashY are used when
+	// Generator.BlurHashX and BlurHashY are zero.
+	DefaultBlurHashX = 4
+	DefaultBlurHashY = 3
+	// DefaultLQIPSize is used when Generator.LQIPSize is zero.
+	DefaultLQIPSize = 16
+	// DefaultVariantSize is used when Generator.VariantSize is zero.
+	DefaultVariantSize = 64
+)
+
+// lqipQuality is the JPEG quality of LQIPs, which are blurred when shown.
+const lqipQuality = 60
+
+// blurHashSize bounds the thumbnail BlurHash is computed on, which is plenty
+// for the few components it keeps.
+const blurHashSize = 64
+
+// Placeholder is the set of placeholders of an image.
+type Placeholder struct {
+	BlurHash string `json:"blurhash"`
+	// ThumbHash is base64 encoded.
+	ThumbHash string `json:"thumbhash"`
+	// LQIP is a data URI of a tiny JPEG, or a PNG for images with
+	// transparency, to be shown scaled up and blurred.
+	LQIP string `json:"lqip"`
+}
+
+// Generator computes placeholders. The zero value computes them with the
+// defaults; Client is needed to fetch and upload images.
+type Generator struct {
+	Client *sdkgo.Imgsrc
+	// BlurHashX and BlurHashY are the BlurHash components. Default to
+	// DefaultBlurHashX and DefaultBlurHashY.
+	BlurHashX int
+	BlurHashY int
+	// LQIPSize bounds the width and height of LQIPs. Defaults to
+	// DefaultLQIPSize.
+	LQIPSize int
+	// VariantSize bounds the width and height of the variant ForImage fetches
+	// from the CDN. Defaults to DefaultVariantSize.
+	VariantSize int64
+	// Cache holds computed placeholders. Defaults to an LRU of
+	// DefaultCacheSize placeholders.
+	Cache Cache
+	// Options are passed to the API calls.
+	Options []operations.Option
+
+	once  sync.Once
+	cache Cache
+}
+
+func (g *Generator) init() {
+	g.once.Do(func() {
+		g.cache = g.Cache
+		if g.cache == nil {
+			g.cache = NewLRU(0)
+		}
+	})
+}
+
+// Compute computes the placeholders of img.
+func (g *Generator) Compute(img image.Image) (*Placeholder, error) {
+	bx, by := g.BlurHashX, g.BlurHashY
+	if bx == 0 {
+		bx = DefaultBlurHashX
+	}
+	if by == 0 {
+		by = DefaultBlurHashY
+	}
+	lqipSize := g.LQIPSize
+	if lqipSize <= 0 {
+		lqipSize = DefaultLQIPSize
+	}
+
+	blurHash, err := EncodeBlurHash(imgproc.Thumbnail(img, blurHashSize, blurHashSize), bx, by)
+	if err != nil {
+		return nil, err
+	}
+	thumbHash, err := EncodeThumbHash(img)
+	if err != nil {
+		return nil, err
+	}
+	lqip, err := EncodeLQIP(img, lqipSize)
+	if err != nil {
+		return nil, err
+	}
+	return &Placeholder{
+		BlurHash:  blurHash,
+		ThumbHash: base64.StdEncoding.EncodeToString(thumbHash),
+		LQIP:      lqip,
+	}, nil
+}
+
+// FromBytes returns the placeholders of a JPEG, PNG or GIF file, from the
+// cache if they were computed before. Other formats fail with
+// image.ErrFormat.
+func (g *Generator) FromBytes(data []byte) (*Placeholder, error) {
+	g.init()
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	if p, ok := g.cache.Get(hash); ok {
+		return p, nil
+	}
+
+	img, err := imgproc.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	p, err := g.Compute(img)
+	if err != nil {
+		return nil, err
+	}
+	g.cache.Set(hash, p)
+	return p, nil
+}
+
+// ForImage returns the placeholders of a stored image, from the cache if
+// they were computed before, or else from a PNG variant of at most
+// VariantSize pixels fetched from the CDN. Private images are fetched
+// through a signed URL.
+func (g *Generator) ForImage(ctx context.Context, meta *components.MetadataResponse) (*Placeholder, error) {
+	if meta == nil {
+		return nil, errors.New("missing image metadata")
+	}
+	g.init()
+	hash := meta.Metadata.Hash
+	if p, ok := g.cache.Get(hash); ok {
+		return p, nil
+	}
+
+	size := g.VariantSize
+	if size <= 0 {
+		size = DefaultVariantSize
+	}
+	body, err := g.Client.Images.OpenVariant(ctx, meta, components.Transformation{
+		Width:  &size,
+		Height: &size,
+		Fit:    components.FitContain.ToPointer(),
+		Format: components.FormatPng.ToPointer(),
+	}, g.Options...)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error downloading variant: %w", err)
+	}
+	img, err := imgproc.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding variant of image %s: %w", meta.ID, err)
+	}
+	p, err := g.Compute(img)
+	if err != nil {
+		return nil, err
+	}
+	if hash != "" {
+		g.cache.Set(hash, p)
+	}
+	return p, nil
+}
+
+// ForID is ForImage for the image with the given ID.
+func (g *Generator) ForID(ctx context.Context, id string) (*Placeholder, error) {
+	res, err := g.Client.Images.GetMetadata(ctx, id, g.Options...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting image %s: %w", id, err)
+	}
+	return g.ForImage(ctx, res.GetMetadataResponse())
+}
+
+// Transform returns an upload transform computing the placeholders of every
+// JPEG, PNG and GIF file, which are cached and passed to onResult. Files
+// pass through unchanged, and those that cannot be decoded get no
+// placeholder. Put it last in the chain, so that the placeholders are cached
+// by the hash of what is stored.
+func (g *Generator) Transform(onResult func(fileName string, p *Placeholder)) sdkgo.UploadTransform {
+	return func(fileName string, r io.Reader) (io.Reader, error) {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		if p, err := g.FromBytes(data); err == nil && onResult != nil {
+			onResult(fileName, p)
+		}
+		return bytes.NewReader(data), nil
+	}
+}
+
+// Upload is the result of Generator.Upload.
+type Upload struct {
+	Response *operations.UploadImageResponse
+	// Placeholder is nil for files in formats other than JPEG, PNG and GIF.
+	Placeholder *Placeholder
+}
+
+// Upload uploads the file of request and computes its placeholders from the
+// uploaded bytes. Malformed JPEG, PNG and GIF files fail before they are
+// uploaded.
+func (g *Generator) Upload(ctx context.Context, request *operations.UploadImageRequestBody) (*Upload, error) {
+	if request == nil || request.File == nil {
+		return nil, errors.New("missing file")
+	}
+
+	var data []byte
+	switch content := request.File.Content.(type) {
+	case []byte:
+		data = content
+	case io.Reader:
+		var err error
+		if data, err = io.ReadAll(content); err != nil {
+			return nil, fmt.Errorf("error reading file: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported file content %T", content)
+	}
+
+	p, err := g.FromBytes(data)
+	if err != nil && !errors.Is(err, image.ErrFormat) {
+		return nil, err
+	}
+
+	file := *request.File
+	file.Content = data
+	req := *request
+	req.File = &file
+	res, err := g.Client.Images.Upload(ctx, &req, g.Options...)
+	if err != nil {
+		return nil, err
+	}
+	return &Upload{Response: res, Placeholder: p}, nil
+}
+
+// EncodeLQIP returns a data URI of img downscaled to fit size×size, as a
+// JPEG, or a PNG if img has transparency.
+func EncodeLQIP(img image.Image, size int) (string, error) {
+	thumb := imgproc.Thumbnail(img, size, size)
+
+	var buf bytes.Buffer
+	mediaType := "image/jpeg"
+	if o, ok := thumb.(interface{ Opaque() bool }); ok && !o.Opaque() {
+		mediaType = "image/png"
+		if err := png.Encode(&buf, thumb); err != nil {
+			return "", err
+		}
+	} else if err := jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: lqipQuality}); err != nil {
+		return "", err
+	}
+	return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
@@ -0,0 +1,191 @@
+package placeholder_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/internal/fakeapi"
+	"github.com/img-src-io/sdk-go/models/operations"
+	"github.com/img-src-io/sdk-go/placeholder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pattern returns an image without symmetries, so that no factor is on a
+// rounding boundary.
+func pattern(w, h int, transparent bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			a := uint8(255)
+			if transparent {
+				a = uint8((x*7 + y*3 + 40) % 256)
+			}
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x * 6), uint8(y * 11), uint8((x * y) % 256), a})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestEncodeHashes(t *testing.T) {
+	t.Parallel()
+	// The expected hashes were computed with the reference implementations.
+	blurHash, err := placeholder.EncodeBlurHash(pattern(37, 23, false), 4, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "LuE:n]6]wtX3l@WUjvfAgFflfTfk", blurHash)
+
+	thumbHash, err := placeholder.EncodeThumbHash(pattern(37, 23, false))
+	require.NoError(t, err)
+	assert.Equal(t, "m+gNHJphdodwiIiGiIb0ox/3hw==", base64.StdEncoding.EncodeToString(thumbHash))
+
+	thumbHash, err = placeholder.EncodeThumbHash(pattern(37, 23, true))
+	require.NoError(t, err)
+	assert.Equal(t, "W9iFE44nZIZwmobGlS/2iAmYZ6d3eHc=", base64.StdEncoding.EncodeToString(thumbHash))
+
+	_, err = placeholder.EncodeBlurHash(pattern(4, 4, false), 10, 3)
+	assert.ErrorContains(t, err, "invalid BlurHash components")
+	_, err = placeholder.EncodeThumbHash(image.NewNRGBA(image.Rectangle{}))
+	assert.ErrorContains(t, err, "empty image")
+}
+
+func TestEncodeLQIP(t *testing.T) {
+	t.Parallel()
+	lqip, err := placeholder.EncodeLQIP(pattern(200, 100, false), 16)
+	require.NoError(t, err)
+	data, ok := strings.CutPrefix(lqip, "data:image/jpeg;base64,")
+	require.True(t, ok, lqip)
+	raw, err := base64.StdEncoding.DecodeString(data)
+	require.NoError(t, err)
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(raw))
+	require.NoError(t, err)
+	assert.Equal(t, 16, cfg.Width)
+	assert.Equal(t, 8, cfg.Height)
+
+	lqip, err = placeholder.EncodeLQIP(pattern(200, 100, true), 16)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(lqip, "data:image/png;base64,"), lqip)
+}
+
+func TestGeneratorFromBytes(t *testing.T) {
+	t.Parallel()
+	cache := placeholder.NewLRU(0)
+	g := &placeholder.Generator{Cache: cache}
+	data := encodePNG(t, pattern(300, 200, false))
+
+	p, err := g.FromBytes(data)
+	require.NoError(t, err)
+	assert.Len(t, p.BlurHash, 28)
+	assert.NotEmpty(t, p.ThumbHash)
+	assert.True(t, strings.HasPrefix(p.LQIP, "data:image/jpeg;base64,"))
+
+	again, err := g.FromBytes(data)
+	require.NoError(t, err)
+	assert.Same(t, p, again)
+	assert.Equal(t, 1, cache.Len())
+
+	_, err = g.FromBytes([]byte("RIFF\x00\x00\x00\x00WEBPVP8 "))
+	assert.ErrorIs(t, err, image.ErrFormat)
+}
+
+func TestGeneratorForImage(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	client := f.SDK()
+	data := encodePNG(t, pattern(60, 40, true))
+	public := f.Add(string(data), "a.png")
+	private := f.Add(string(encodePNG(t, pattern(40, 60, false))), "b.png")
+	f.Update(private, func(img *fakeapi.Image) { img.Visibility = "private" })
+
+	g := &placeholder.Generator{Client: client}
+	p, err := g.ForID(context.Background(), public)
+	require.NoError(t, err)
+	// The fake CDN serves originals, so the variant is the uploaded image.
+	want, err := (&placeholder.Generator{}).FromBytes(data)
+	require.NoError(t, err)
+	assert.Equal(t, want, p)
+	assert.Equal(t, 1, f.Count("GET /cdn/{id}"))
+
+	_, err = g.ForID(context.Background(), public)
+	require.NoError(t, err)
+	assert.Equal(t, 1, f.Count("GET /cdn/{id}"))
+
+	_, err = g.ForID(context.Background(), private)
+	require.NoError(t, err)
+	assert.Equal(t, 1, f.Count("POST /api/v1/images/{id}/signed-url"))
+	assert.Equal(t, 2, f.Count("GET /cdn/{id}"))
+}
+
+func TestGeneratorUpload(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	dir := t.TempDir()
+	g := &placeholder.Generator{Client: f.SDK(), Cache: placeholder.DirCache(dir)}
+	data := encodePNG(t, pattern(50, 50, false))
+
+	up, err := g.Upload(context.Background(), &operations.UploadImageRequestBody{
+		File: &operations.File{FileName: "a.png", Content: bytes.NewReader(data)},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, up.Placeholder)
+	hash := up.Response.GetUploadResponse().Hash
+	assert.FileExists(t, filepath.Join(dir, hash+".json"))
+
+	cached, ok := placeholder.DirCache(dir).Get(hash)
+	require.True(t, ok)
+	assert.Equal(t, up.Placeholder, cached)
+
+	up, err = g.Upload(context.Background(), &operations.UploadImageRequestBody{
+		File: &operations.File{FileName: "a.webp", Content: []byte("RIFF\x00\x00\x00\x00WEBPVP8 ")},
+	})
+	require.NoError(t, err)
+	assert.Nil(t, up.Placeholder)
+
+	_, ok = placeholder.DirCache(dir).Get("../../etc/passwd")
+	assert.False(t, ok)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestGeneratorTransform(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	cache := placeholder.NewLRU(0)
+	g := &placeholder.Generator{Cache: cache}
+
+	var got []string
+	sdk := f.SDK(sdkgo.WithUploadTransforms(g.Transform(func(fileName string, p *placeholder.Placeholder) {
+		got = append(got, fileName)
+	})))
+	data := encodePNG(t, pattern(30, 20, false))
+	res, err := sdk.Images.Upload(context.Background(), &operations.UploadImageRequestBody{
+		File: &operations.File{FileName: "a.png", Content: data},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.png"}, got)
+	assert.Equal(t, data, f.Image(res.GetUploadResponse().ID).Data)
+
+	sum := sha256.Sum256(data)
+	assert.Equal(t, hex.EncodeToString(sum[:]), res.GetUploadResponse().Hash)
+	_, ok := cache.Get(res.GetUploadResponse().Hash)
+	assert.True(t, ok)
+}
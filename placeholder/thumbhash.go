@@ -0,0 +1,149 @@
+package placeholder
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/img-src-io/sdk-go/imgproc"
+)
+
+// thumbHashMaxSize is the largest width and height ThumbHash encodes.
+const thumbHashMaxSize = 100
+
+// EncodeThumbHash returns the ThumbHash of img, which also encodes its
+// aspect ratio and transparency. Images larger than 100×100 are downscaled
+// first.
+func EncodeThumbHash(img image.Image) ([]byte, error) {
+	img = imgproc.Thumbnail(img, thumbHashMaxSize, thumbHashMaxSize)
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return nil, errors.New("empty image")
+	}
+	n := w * h
+
+	// The average color, weighted by alpha.
+	rgba := make([][4]float64, n)
+	var avgR, avgG, avgB, avgA float64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.NRGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+			// The arithmetic follows the reference implementation, so that the
+			// hashes match to the bit.
+			alpha := float64(c.A) / 255
+			p := [4]float64{float64(c.R), float64(c.G), float64(c.B), alpha}
+			rgba[y*w+x] = p
+			avgR += alpha / 255 * p[0]
+			avgG += alpha / 255 * p[1]
+			avgB += alpha / 255 * p[2]
+			avgA += alpha
+		}
+	}
+	if avgA > 0 {
+		avgR /= avgA
+		avgG /= avgA
+		avgB /= avgA
+	}
+
+	hasAlpha := avgA < float64(n)
+	lLimit := 7.0
+	if hasAlpha {
+		// Fewer luminance components leave room for the alpha channel.
+		lLimit = 5
+	}
+	lx := max(1, int(round(lLimit*float64(w)/float64(max(w, h)))))
+	ly := max(1, int(round(lLimit*float64(h)/float64(max(w, h)))))
+
+	// Convert to luminance, yellow-blue, red-green and alpha, composited
+	// over the average color.
+	l, p, q, a := make([]float64, n), make([]float64, n), make([]float64, n), make([]float64, n)
+	for i, c := range rgba {
+		r := avgR*(1-c[3]) + c[3]/255*c[0]
+		g := avgG*(1-c[3]) + c[3]/255*c[1]
+		bl := avgB*(1-c[3]) + c[3]/255*c[2]
+		l[i] = (r + g + bl) / 3
+		p[i] = (r+g)/2 - bl
+		q[i] = r - g
+		a[i] = c[3]
+	}
+
+	lDC, lAC, lScale := thumbHashChannel(l, w, h, max(3, lx), max(3, ly))
+	pDC, pAC, pScale := thumbHashChannel(p, w, h, 3, 3)
+	qDC, qAC, qScale := thumbHashChannel(q, w, h, 3, 3)
+
+	isLandscape := w > h
+	header24 := int(round(63*lDC)) | int(round(31.5+31.5*pDC))<<6 | int(round(31.5+31.5*qDC))<<12 | int(round(31*lScale))<<18
+	if hasAlpha {
+		header24 |= 1 << 23
+	}
+	header16 := ly
+	if !isLandscape {
+		header16 = lx
+	}
+	header16 |= int(round(63*pScale))<<3 | int(round(63*qScale))<<9
+	if isLandscape {
+		header16 |= 1 << 15
+	}
+	hash := []byte{byte(header24), byte(header24 >> 8), byte(header24 >> 16), byte(header16), byte(header16 >> 8)}
+
+	acs := [][]float64{lAC, pAC, qAC}
+	if hasAlpha {
+		aDC, aAC, aScale := thumbHashChannel(a, w, h, 5, 5)
+		hash = append(hash, byte(int(round(15*aDC))|int(round(15*aScale))<<4))
+		acs = append(acs, aAC)
+	}
+
+	// Pack the AC factors as nibbles.
+	var i int
+	start := len(hash)
+	for _, ac := range acs {
+		for _, f := range ac {
+			if i%2 == 0 {
+				hash = append(hash, 0)
+			}
+			hash[start+i/2] |= byte(int(round(15*f)) << (4 * (i % 2)))
+			i++
+		}
+	}
+	return hash, nil
+}
+
+// thumbHashChannel encodes a channel with the DCT into its constant factor
+// and its varying factors normalized to [0, 1] by their scale.
+func thumbHashChannel(channel []float64, w, h, nx, ny int) (dc float64, ac []float64, scale float64) {
+	fx := make([]float64, w)
+	for cy := 0; cy < ny; cy++ {
+		for cx := 0; cx*ny < nx*(ny-cy); cx++ {
+			for x := range fx {
+				fx[x] = math.Cos(math.Pi / float64(w) * float64(cx) * (float64(x) + 0.5))
+			}
+			var f float64
+			for y := 0; y < h; y++ {
+				fy := math.Cos(math.Pi / float64(h) * float64(cy) * (float64(y) + 0.5))
+				for x, v := range channel[y*w : (y+1)*w] {
+					f += v * fx[x] * fy
+				}
+			}
+			f /= float64(w * h)
+			if cx == 0 && cy == 0 {
+				dc = f
+				continue
+			}
+			ac = append(ac, f)
+			scale = max(scale, math.Abs(f))
+		}
+	}
+	if scale > 0 {
+		for i := range ac {
+			ac[i] = 0.5 + 0.5/scale*ac[i]
+		}
+	}
+	return dc, ac, scale
+}
+
+// round rounds half up, as the reference implementation does.
+func round(v float64) float64 {
+	return math.Floor(v + 0.5)
+}
@@ -0,0 +1,79 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrCircuitOpen is matched by errors.Is for every error returned because a
+// circuit breaker rejected a request.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState describes the state of a circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets every request through and counts consecutive failures.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects every request with a CircuitOpenError.
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of probe requests through to
+	// decide whether the circuit should close again.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(s))
+	}
+}
+
+// CircuitBreakerConfig configures a circuit breaker. A circuit is kept per
+// operation ID and trips on consecutive 5XX responses or connection errors.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the
+	// circuit. Defaults to 5.
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before probe requests are
+	// allowed through. Defaults to 30 seconds.
+	OpenTimeout time.Duration
+	// HalfOpenProbes is the number of concurrent probe requests allowed while
+	// the circuit is half-open. Defaults to 1.
+	HalfOpenProbes int
+	// OnStateChange, if set, is called whenever the circuit of an operation
+	// changes state.
+	OnStateChange func(operationID string, from, to CircuitState)
+}
+
+// CircuitOpenError is returned when a circuit breaker rejects a request
+// without sending it.
+type CircuitOpenError struct {
+	OperationID string
+	// RetryAfter is the time left until the circuit allows a probe request.
+	RetryAfter time.Duration
+}
+
+var _ error = (*CircuitOpenError)(nil)
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s for operation %s (retry after %s)", ErrCircuitOpen, e.OperationID, e.RetryAfter)
+}
+
+// Is reports whether target is ErrCircuitOpen.
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// IsCircuitOpenError returns true if an error value is or contains a
+// CircuitOpenError in its chain of errors.
+func IsCircuitOpenError(err error) bool {
+	return errors.Is(err, ErrCircuitOpen)
+}
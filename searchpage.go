@@ -0,0 +1,213 @@
+package sdkgo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/img-src-io/sdk-go/models/operations"
+)
+
+// DefaultSearchLimit is the page size used when SearchOptions.Limit is zero.
+const DefaultSearchLimit int64 = 20
+
+// ErrInvalidCursor is returned by SearchPaged for a cursor that was not
+// returned by a search for the same query.
+var ErrInvalidCursor = errors.New("invalid search cursor")
+
+// SearchOptions configures Images.SearchPaged. Only the query and the number
+// of results are sent to the API; the search endpoint has no offset or
+// filter parameters, so the other options are applied client-side.
+type SearchOptions struct {
+	// Limit is the number of results per page. Defaults to
+	// DefaultSearchLimit.
+	Limit int64
+	// Offset is the number of search results to skip. Ignored if Cursor is
+	// set.
+	Offset int64
+	// Cursor resumes a search after the page it was returned with.
+	Cursor string
+
+	// PathPrefix keeps results with a path under it.
+	PathPrefix string
+	// Visibility keeps results with this visibility.
+	Visibility components.Visibility
+	// UploadedAfter and UploadedBefore keep results uploaded in this range.
+	UploadedAfter  time.Time
+	UploadedBefore time.Time
+	// MinSize and MaxSize keep results of this size in bytes. Zero means no
+	// bound.
+	MinSize int64
+	MaxSize int64
+	// Format keeps results whose filename or one of whose paths has this
+	// extension, e.g. "png". "jpg" and "jpeg" match each other.
+	Format string
+}
+
+// SearchPage is a page of results of Images.SearchPaged.
+type SearchPage struct {
+	Results []components.SearchResult
+	// Total is the number of images matching the query, as reported by the
+	// API, before client-side filters are applied.
+	Total int64
+	// Offset is the offset of the first search result scanned for this page.
+	Offset int64
+	// HasMore reports whether search results remain after this page.
+	HasMore bool
+	// Cursor resumes the search after this page. Empty if HasMore is false.
+	Cursor string
+	// Next returns the following page, or nil if HasMore is false.
+	Next func() (*SearchPage, error)
+}
+
+// searchCursor is the content of SearchPage.Cursor.
+type searchCursor struct {
+	Query  string `json:"q"`
+	Offset int64  `json:"o"`
+}
+
+// SearchPaged searches images by filename and returns a page of at most
+// opts.Limit results matching the filters of opts. Since the API always
+// returns the first results of a query, a page at offset n is fetched by
+// asking for n+limit results and skipping the first n; filters that reject
+// results make SearchPaged ask for more until the page is full or the search
+// is exhausted.
+func (s *Images) SearchPaged(ctx context.Context, q string, opts *SearchOptions, reqOpts ...operations.Option) (*SearchPage, error) {
+	if opts == nil {
+		opts = &SearchOptions{}
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+
+	offset := opts.Offset
+	if opts.Cursor != "" {
+		c, err := decodeSearchCursor(opts.Cursor)
+		if err != nil || c.Query != q {
+			return nil, ErrInvalidCursor
+		}
+		offset = c.Offset
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	page := &SearchPage{Results: []components.SearchResult{}, Offset: offset}
+	scanned := offset
+	window := limit
+	for int64(len(page.Results)) < limit {
+		res, err := s.Search(ctx, q, Int64(scanned+window), reqOpts...)
+		if err != nil {
+			return nil, err
+		}
+		body := res.GetSearchResponse()
+		if body == nil {
+			return nil, errors.New("empty search response")
+		}
+		page.Total = body.Total
+
+		results := body.Results
+		if int64(len(results)) <= scanned {
+			// Nothing past the offset: either the search is exhausted or the
+			// API caps the number of results it returns.
+			if scanned < page.Total && len(page.Results) == 0 {
+				return nil, fmt.Errorf("search returned %d of %d results and cannot be paged further", len(results), page.Total)
+			}
+			break
+		}
+		for _, r := range results[scanned:] {
+			scanned++
+			if opts.match(r) {
+				page.Results = append(page.Results, r)
+				if int64(len(page.Results)) == limit {
+					break
+				}
+			}
+		}
+		if scanned >= page.Total {
+			break
+		}
+		// Re-fetching the results before the offset is unavoidable, so grow
+		// the window to keep the number of requests logarithmic.
+		window *= 2
+	}
+
+	page.HasMore = scanned < page.Total
+	if page.HasMore {
+		page.Cursor = encodeSearchCursor(searchCursor{Query: q, Offset: scanned})
+		next := *opts
+		next.Cursor = page.Cursor
+		page.Next = func() (*SearchPage, error) {
+			return s.SearchPaged(ctx, q, &next, reqOpts...)
+		}
+	}
+	return page, nil
+}
+
+func (o *SearchOptions) match(r components.SearchResult) bool {
+	if o.Visibility != "" && r.Visibility != o.Visibility {
+		return false
+	}
+	if !o.UploadedAfter.IsZero() && !r.UploadedAt.After(o.UploadedAfter) {
+		return false
+	}
+	if !o.UploadedBefore.IsZero() && !r.UploadedAt.Before(o.UploadedBefore) {
+		return false
+	}
+	if o.MinSize > 0 && r.Size < o.MinSize {
+		return false
+	}
+	if o.MaxSize > 0 && r.Size > o.MaxSize {
+		return false
+	}
+	if prefix := strings.TrimLeft(o.PathPrefix, "/"); prefix != "" {
+		found := false
+		for _, p := range r.Paths {
+			found = found || strings.HasPrefix(strings.TrimLeft(p, "/"), prefix)
+		}
+		if !found {
+			return false
+		}
+	}
+	if o.Format != "" {
+		want := normalizeFormat(o.Format)
+		found := normalizeFormat(path.Ext(r.OriginalFilename)) == want
+		for _, p := range r.Paths {
+			found = found || normalizeFormat(path.Ext(p)) == want
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeFormat(ext string) string {
+	ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+	if ext == "jpg" {
+		return "jpeg"
+	}
+	return ext
+}
+
+func encodeSearchCursor(c searchCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSearchCursor(s string) (searchCursor, error) {
+	var c searchCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
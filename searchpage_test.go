@@ -0,0 +1,123 @@
+package sdkgo_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/internal/fakeapi"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSearchLibrary(t *testing.T) (*fakeapi.Server, []string) {
+	t.Helper()
+	f := fakeapi.New(t)
+	var ids []string
+	for i := 0; i < 25; i++ {
+		name := fmt.Sprintf("photo-%02d.png", i)
+		switch {
+		case i%5 == 0:
+			name = fmt.Sprintf("trips/photo-%02d.jpg", i)
+		case i%3 == 0:
+			name = fmt.Sprintf("trips/photo-%02d.png", i)
+		}
+		ids = append(ids, f.Add(strings.Repeat("x", 10+i), name))
+	}
+	f.Add("other", "other.png")
+	return f, ids
+}
+
+func resultIDs(results []components.SearchResult) []string {
+	ids := []string{}
+	for _, r := range results {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+func TestImages_SearchPaged(t *testing.T) {
+	t.Parallel()
+
+	t.Run("pages with next", func(t *testing.T) {
+		t.Parallel()
+		f, ids := newSearchLibrary(t)
+
+		page, err := f.SDK().Images.SearchPaged(context.Background(), "photo", &sdkgo.SearchOptions{Limit: 10})
+		require.NoError(t, err)
+		assert.EqualValues(t, 25, page.Total)
+
+		var got []string
+		pages := 0
+		for page != nil {
+			pages++
+			got = append(got, resultIDs(page.Results)...)
+			if !page.HasMore {
+				assert.Empty(t, page.Cursor)
+				assert.Nil(t, page.Next)
+				break
+			}
+			page, err = page.Next()
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 3, pages)
+		assert.Equal(t, ids, got)
+	})
+
+	t.Run("offset and cursor", func(t *testing.T) {
+		t.Parallel()
+		f, ids := newSearchLibrary(t)
+		sdk := f.SDK()
+
+		page, err := sdk.Images.SearchPaged(context.Background(), "photo", &sdkgo.SearchOptions{Limit: 5, Offset: 20})
+		require.NoError(t, err)
+		assert.Equal(t, ids[20:], resultIDs(page.Results))
+		assert.False(t, page.HasMore)
+
+		page, err = sdk.Images.SearchPaged(context.Background(), "photo", &sdkgo.SearchOptions{Limit: 5})
+		require.NoError(t, err)
+		require.True(t, page.HasMore)
+		resumed, err := sdk.Images.SearchPaged(context.Background(), "photo", &sdkgo.SearchOptions{Limit: 5, Offset: 1, Cursor: page.Cursor})
+		require.NoError(t, err)
+		assert.Equal(t, ids[5:10], resultIDs(resumed.Results))
+
+		_, err = sdk.Images.SearchPaged(context.Background(), "other", &sdkgo.SearchOptions{Cursor: page.Cursor})
+		assert.ErrorIs(t, err, sdkgo.ErrInvalidCursor)
+		_, err = sdk.Images.SearchPaged(context.Background(), "photo", &sdkgo.SearchOptions{Cursor: "!"})
+		assert.ErrorIs(t, err, sdkgo.ErrInvalidCursor)
+	})
+
+	t.Run("client-side filters", func(t *testing.T) {
+		t.Parallel()
+		f, ids := newSearchLibrary(t)
+		f.Update(ids[3], func(img *fakeapi.Image) { img.Visibility = "private" })
+		f.Update(ids[9], func(img *fakeapi.Image) { img.Visibility = "private" })
+		f.Update(ids[24], func(img *fakeapi.Image) { img.UploadedAt = time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC) })
+		sdk := f.SDK()
+
+		search := func(opts *sdkgo.SearchOptions) []string {
+			t.Helper()
+			var got []string
+			page, err := sdk.Images.SearchPaged(context.Background(), "photo", opts)
+			for ; err == nil; page, err = page.Next() {
+				got = append(got, resultIDs(page.Results)...)
+				if !page.HasMore {
+					break
+				}
+			}
+			require.NoError(t, err)
+			return got
+		}
+
+		assert.Equal(t, []string{ids[0], ids[5], ids[10], ids[15], ids[20]}, search(&sdkgo.SearchOptions{Limit: 2, Format: "jpeg"}))
+		assert.Equal(t, []string{ids[3], ids[6], ids[9], ids[12], ids[18], ids[21], ids[24]}, search(&sdkgo.SearchOptions{PathPrefix: "/trips/", Format: ".PNG"}))
+		assert.Equal(t, []string{ids[3], ids[9]}, search(&sdkgo.SearchOptions{Limit: 1, Visibility: components.VisibilityPrivate}))
+		assert.Equal(t, []string{ids[5], ids[6], ids[7]}, search(&sdkgo.SearchOptions{MinSize: 15, MaxSize: 17}))
+		assert.Equal(t, []string{ids[24]}, search(&sdkgo.SearchOptions{UploadedBefore: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}))
+		assert.Len(t, search(&sdkgo.SearchOptions{UploadedAfter: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}), 24)
+	})
+}
@@ -0,0 +1,219 @@
+// Package signedurl inspects signed image URLs, such as
+// SignedURLResponse.SignedURL and ActiveSignedUrl.SignedURL, without calling
+// the API.
+//
+// Signed URLs have the form
+//
+//	https://cdn.img-src.io/{username}/{path}?token=...&expires=1704153600
+//
+// optionally followed by transformation parameters.
+package signedurl
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/img-src-io/sdk-go/models/components"
+)
+
+// Redacted replaces secret query values in redacted URLs.
+const Redacted = "REDACTED"
+
+var (
+	// ErrNotSigned is returned by Parse for URLs without a signature.
+	ErrNotSigned = errors.New("URL is not signed")
+	// ErrExpired is returned by Validate for expired URLs or URLs expiring
+	// within the requested validity.
+	ErrExpired = errors.New("signed URL expired")
+	// ErrExpiryMismatch is returned by CheckExpiresAt when the expiry in the URL
+	// differs from the one reported by the API.
+	ErrExpiryMismatch = errors.New("signed URL expiry mismatch")
+)
+
+// Query parameters carrying the signature and the expiry. The first name of
+// each list is the documented one.
+var (
+	signatureParams = []string{"token", "sig", "signature"}
+	expiryParams    = []string{"expires", "exp"}
+)
+
+// SignedURL is a parsed signed URL.
+type SignedURL struct {
+	URL *url.URL
+	// Username is the first path segment.
+	Username string
+	// Path is the image path below the username.
+	Path string
+	// ImageID is only set when the URL carries an "id" parameter, as the
+	// documented format addresses images by path.
+	ImageID string
+	// Transformation holds the transformation parameters of the URL. Its
+	// Format falls back to the extension of Path.
+	Transformation components.Transformation
+	ExpiresAt      time.Time
+}
+
+// Parse parses a signed URL.
+func Parse(raw string) (*SignedURL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		// url.Error would repeat the URL, signature included.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("invalid signed URL: %w", err)
+	}
+	if !u.IsAbs() && !strings.HasPrefix(u.Path, "/") {
+		return nil, fmt.Errorf("invalid signed URL %q", Redact(raw))
+	}
+
+	q := u.Query()
+	if first(q, signatureParams) == "" {
+		return nil, ErrNotSigned
+	}
+	exp := first(q, expiryParams)
+	if exp == "" {
+		return nil, fmt.Errorf("%w: missing expiry", ErrNotSigned)
+	}
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expiry %q: %w", exp, err)
+	}
+
+	s := &SignedURL{URL: u, ImageID: q.Get("id"), ExpiresAt: time.Unix(expires, 0)}
+	s.Username, s.Path, _ = strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+
+	t := &s.Transformation
+	if t.Width, err = intParam(q, "w", "width"); err != nil {
+		return nil, err
+	}
+	if t.Height, err = intParam(q, "h", "height"); err != nil {
+		return nil, err
+	}
+	if t.Quality, err = intParam(q, "q", "quality"); err != nil {
+		return nil, err
+	}
+	if v := first(q, []string{"fit"}); v != "" {
+		t.Fit = components.Fit(v).ToPointer()
+	}
+	if v := first(q, []string{"fmt", "format"}); v != "" {
+		t.Format = components.Format(v).ToPointer()
+	} else {
+		t.Format = formatFromExt(s.Path)
+	}
+
+	return s, nil
+}
+
+// Expired reports whether the URL has expired.
+func (s *SignedURL) Expired() bool {
+	return !time.Now().Before(s.ExpiresAt)
+}
+
+// ExpiresWithin reports whether the URL expires within d from now.
+func (s *SignedURL) ExpiresWithin(d time.Duration) bool {
+	return !time.Now().Add(d).Before(s.ExpiresAt)
+}
+
+// Validate returns ErrExpired unless the URL stays valid for at least d, e.g.
+// the time an email may sit unread.
+func (s *SignedURL) Validate(d time.Duration) error {
+	if s.ExpiresWithin(d) {
+		return fmt.Errorf("%w: expires at %s", ErrExpired, s.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// CheckExpiresAt returns ErrExpiryMismatch unless the URL expires at
+// expiresAt, the Unix time reported alongside it by the API.
+func (s *SignedURL) CheckExpiresAt(expiresAt int64) error {
+	if s.ExpiresAt.Unix() != expiresAt {
+		return fmt.Errorf("%w: URL expires at %d, API reported %d", ErrExpiryMismatch, s.ExpiresAt.Unix(), expiresAt)
+	}
+	return nil
+}
+
+// String returns the URL with its signature redacted, so that formatting a
+// SignedURL never leaks it.
+func (s *SignedURL) String() string {
+	return redactURL(s.URL)
+}
+
+// LogValue implements slog.LogValuer.
+func (s *SignedURL) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("url", s.String()),
+		slog.Time("expires_at", s.ExpiresAt),
+	)
+}
+
+// Redact returns raw with the signature replaced by Redacted, for logging.
+// Strings that are not URLs are redacted entirely.
+func Redact(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return Redacted
+	}
+	return redactURL(u)
+}
+
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+
+	cp := *u
+	cp.User = nil
+	q := cp.Query()
+	for _, name := range signatureParams {
+		if q.Has(name) {
+			q.Set(name, Redacted)
+		}
+	}
+	cp.RawQuery = q.Encode()
+	return cp.String()
+}
+
+func first(q url.Values, names []string) string {
+	for _, name := range names {
+		if v := q.Get(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func intParam(q url.Values, names ...string) (*int64, error) {
+	v := first(q, names)
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %w", names[0], v, err)
+	}
+	return &n, nil
+}
+
+func formatFromExt(p string) *components.Format {
+	switch strings.ToLower(strings.TrimPrefix(path.Ext(p), ".")) {
+	case "webp":
+		return components.FormatWebp.ToPointer()
+	case "avif":
+		return components.FormatAvif.ToPointer()
+	case "jpg", "jpeg":
+		return components.FormatJpeg.ToPointer()
+	case "png":
+		return components.FormatPng.ToPointer()
+	case "jxl":
+		return components.FormatJxl.ToPointer()
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package signedurl_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/img-src-io/sdk-go/internal/fakeapi"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/img-src-io/sdk-go/signedurl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	s, err := signedurl.Parse("https://cdn.img-src.io/john/blog/photo.webp?token=s3cr3t&expires=1704153600")
+	require.NoError(t, err)
+	assert.Equal(t, "john", s.Username)
+	assert.Equal(t, "blog/photo.webp", s.Path)
+	assert.Empty(t, s.ImageID)
+	assert.Equal(t, time.Unix(1704153600, 0), s.ExpiresAt)
+	assert.Equal(t, components.Transformation{Format: components.FormatWebp.ToPointer()}, s.Transformation)
+
+	s, err = signedurl.Parse("https://cdn.img-src.io/john/photo.jpg?w=300&h=200&fit=cover&q=75&fmt=avif&id=abc123&sig=s3cr3t&exp=1704153600")
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", s.ImageID)
+	assert.Equal(t, components.Transformation{
+		Width:   ptr[int64](300),
+		Height:  ptr[int64](200),
+		Fit:     components.FitCover.ToPointer(),
+		Quality: ptr[int64](75),
+		Format:  components.FormatAvif.ToPointer(),
+	}, s.Transformation)
+
+	tests := []struct {
+		name, raw, want string
+	}{
+		{"unsigned", "https://cdn.img-src.io/john/photo.webp", "not signed"},
+		{"missing expiry", "https://cdn.img-src.io/john/photo.webp?token=x", "missing expiry"},
+		{"bad expiry", "https://cdn.img-src.io/john/photo.webp?token=x&expires=soon", "invalid expiry"},
+		{"bad width", "https://cdn.img-src.io/john/photo.webp?token=x&expires=1&w=big", "invalid w"},
+		{"not a URL", "john/photo.webp?token=x&expires=1", "invalid signed URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := signedurl.Parse(tt.raw)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+
+	_, err = signedurl.Parse("https://cdn.img-src.io/\x7f?token=s3cr3t")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "s3cr3t")
+}
+
+func TestSignedURL_Expiry(t *testing.T) {
+	t.Parallel()
+	in := func(d time.Duration) *signedurl.SignedURL {
+		s, err := signedurl.Parse(fmt.Sprintf("https://cdn.img-src.io/john/a.png?token=x&expires=%d", time.Now().Add(d).Unix()))
+		require.NoError(t, err)
+		return s
+	}
+
+	soon := in(10 * time.Minute)
+	assert.False(t, soon.Expired())
+	assert.False(t, soon.ExpiresWithin(time.Minute))
+	assert.True(t, soon.ExpiresWithin(time.Hour))
+	assert.NoError(t, soon.Validate(time.Minute))
+	assert.ErrorIs(t, soon.Validate(24*time.Hour), signedurl.ErrExpired)
+
+	gone := in(-time.Minute)
+	assert.True(t, gone.Expired())
+	assert.ErrorIs(t, gone.Validate(0), signedurl.ErrExpired)
+
+	assert.NoError(t, soon.CheckExpiresAt(soon.ExpiresAt.Unix()))
+	assert.ErrorIs(t, soon.CheckExpiresAt(soon.ExpiresAt.Unix()+1), signedurl.ErrExpiryMismatch)
+}
+
+func TestRedact(t *testing.T) {
+	t.Parallel()
+
+	raw := "https://cdn.img-src.io/john/a.png?token=s3cr3t&expires=1704153600&w=10"
+	assert.Equal(t, "https://cdn.img-src.io/john/a.png?expires=1704153600&token=REDACTED&w=10", signedurl.Redact(raw))
+	assert.Equal(t, "https://cdn.img-src.io/x?sig=REDACTED", signedurl.Redact("https://cdn.img-src.io/x?sig=abc"))
+	assert.Equal(t, signedurl.Redacted, signedurl.Redact("%zz"))
+
+	s, err := signedurl.Parse(raw)
+	require.NoError(t, err)
+	assert.NotContains(t, fmt.Sprint(s), "s3cr3t")
+	assert.Contains(t, s.URL.String(), "s3cr3t")
+
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("embedding", "image", s)
+	assert.NotContains(t, buf.String(), "s3cr3t")
+	assert.Contains(t, buf.String(), "image.expires_at=")
+}
+
+func TestParse_APIResponse(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	id := f.Add("private", "a.png")
+
+	res, err := f.SDK().Images.CreateSignedURL(context.Background(), id, &components.CreateSignedURLRequest{
+		ExpiresInSeconds: ptr[int64](600),
+		Transformation:   &components.Transformation{Width: ptr[int64](120)},
+	})
+	require.NoError(t, err)
+	out := res.GetSignedURLResponse()
+
+	s, err := signedurl.Parse(out.SignedURL)
+	require.NoError(t, err)
+	assert.NoError(t, s.CheckExpiresAt(out.ExpiresAt))
+	assert.Equal(t, strconv.FormatInt(out.ExpiresAt, 10), s.URL.Query().Get("exp"))
+	assert.Equal(t, ptr[int64](120), s.Transformation.Width)
+	assert.NoError(t, s.Validate(5*time.Minute))
+	assert.True(t, s.ExpiresWithin(11*time.Minute))
+}
+
+func ptr[T any](v T) *T { return &v }
@@ -0,0 +1,290 @@
+package sdkgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/img-src-io/sdk-go/models/operations"
+)
+
+const (
+	// DefaultSignedURLTTL is the lifetime requested when SignedURLConfig.TTL
+	// is zero.
+	DefaultSignedURLTTL = time.Hour
+	// DefaultSignedURLSafetyMargin is used when SignedURLConfig.SafetyMargin
+	// is zero.
+	DefaultSignedURLSafetyMargin = 5 * time.Minute
+	// DefaultSignedURLMaxEntries is used when SignedURLConfig.MaxEntries is
+	// zero.
+	DefaultSignedURLMaxEntries = 1024
+)
+
+// SignedURLConfig configures a SignedURLManager.
+type SignedURLConfig struct {
+	// TTL is the lifetime requested for new signed URLs. The API accepts
+	// between one minute and one day. Defaults to DefaultSignedURLTTL.
+	TTL time.Duration
+	// SafetyMargin is how long before expiry a URL stops being handed out,
+	// leaving clients time to fetch it. It is capped at half the TTL.
+	// Defaults to DefaultSignedURLSafetyMargin.
+	SafetyMargin time.Duration
+	// RefreshAhead is how long before the safety margin a cached URL is
+	// renewed in the background while still being returned. Zero renews URLs
+	// lazily, on the first call after they passed the safety margin.
+	RefreshAhead time.Duration
+	// MaxEntries bounds the number of cached URLs. Defaults to
+	// DefaultSignedURLMaxEntries.
+	MaxEntries int
+}
+
+// SignedURL is a signed URL and its expiry.
+type SignedURL struct {
+	URL string
+	// ExpiresAt is measured on the local clock.
+	ExpiresAt time.Time
+}
+
+// SignedURLManager hands out signed URLs for private images, reusing them
+// until shortly before they expire instead of calling Images.CreateSignedURL
+// on every render. URLs are cached per image ID and transformation, and
+// concurrent requests for the same URL share a single API call. It is safe for
+// concurrent use.
+type SignedURLManager struct {
+	images *Images
+	cfg    SignedURLConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	entries  map[string]*signedURLEntry
+	inflight map[string]*signedURLCall
+}
+
+type signedURLEntry struct {
+	id  string
+	url SignedURL
+}
+
+type signedURLCall struct {
+	done chan struct{}
+	url  SignedURL
+	err  error
+}
+
+// NewSignedURLManager creates a manager minting URLs with images.
+func NewSignedURLManager(images *Images, cfg SignedURLConfig) *SignedURLManager {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultSignedURLTTL
+	}
+	if cfg.SafetyMargin <= 0 {
+		cfg.SafetyMargin = DefaultSignedURLSafetyMargin
+	}
+	if cfg.SafetyMargin > cfg.TTL/2 {
+		cfg.SafetyMargin = cfg.TTL / 2
+	}
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = DefaultSignedURLMaxEntries
+	}
+
+	return &SignedURLManager{
+		images:   images,
+		cfg:      cfg,
+		now:      time.Now,
+		entries:  map[string]*signedURLEntry{},
+		inflight: map[string]*signedURLCall{},
+	}
+}
+
+// Get returns a signed URL for the image, transformed if t is not nil. The URL
+// stays valid for at least the safety margin.
+func (m *SignedURLManager) Get(ctx context.Context, id string, t *components.Transformation, opts ...operations.Option) (SignedURL, error) {
+	key := signedURLKey(id, t)
+	now := m.now()
+
+	m.mu.Lock()
+	entry, ok := m.entries[key]
+	m.mu.Unlock()
+
+	if ok && m.usable(entry.url, now) {
+		if m.cfg.RefreshAhead > 0 && !entry.url.ExpiresAt.After(now.Add(m.cfg.SafetyMargin+m.cfg.RefreshAhead)) && !m.refreshing(key) {
+			go func() {
+				_, _ = m.mint(context.WithoutCancel(ctx), key, id, t, opts)
+			}()
+		}
+		return entry.url, nil
+	}
+
+	return m.mint(ctx, key, id, t, opts)
+}
+
+// GetForItem is like Get, but first reuses the item's ActiveSignedUrl when no
+// transformation is requested and it is valid for at least the safety margin.
+func (m *SignedURLManager) GetForItem(ctx context.Context, item *components.ImageListItem, t *components.Transformation, opts ...operations.Option) (SignedURL, error) {
+	if item == nil {
+		return SignedURL{}, errors.New("missing image")
+	}
+	if t == nil {
+		m.Prime(item.ID, item.ActiveSignedUrl)
+	}
+	return m.Get(ctx, item.ID, t, opts...)
+}
+
+// Prime caches an untransformed signed URL obtained elsewhere, such as the
+// ActiveSignedUrl of a listed image, unless a URL that lives longer is already
+// cached.
+func (m *SignedURLManager) Prime(id string, active *components.ActiveSignedUrl) {
+	if active == nil || active.SignedURL == "" {
+		return
+	}
+
+	url := SignedURL{URL: active.SignedURL, ExpiresAt: time.Unix(active.ExpiresAt, 0)}
+	if !m.usable(url, m.now()) {
+		return
+	}
+	m.store(signedURLKey(id, nil), id, url)
+}
+
+// Invalidate drops every cached URL of an image, e.g. after it was deleted.
+func (m *SignedURLManager) Invalidate(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, entry := range m.entries {
+		if entry.id == id {
+			delete(m.entries, key)
+		}
+	}
+}
+
+func (m *SignedURLManager) refreshing(key string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.inflight[key]
+	return ok
+}
+
+func (m *SignedURLManager) usable(url SignedURL, now time.Time) bool {
+	return url.ExpiresAt.After(now.Add(m.cfg.SafetyMargin))
+}
+
+// mint creates a signed URL, sharing the API call with concurrent callers for
+// the same key.
+func (m *SignedURLManager) mint(ctx context.Context, key, id string, t *components.Transformation, opts []operations.Option) (SignedURL, error) {
+	m.mu.Lock()
+	if call, ok := m.inflight[key]; ok {
+		m.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.url, call.err
+		case <-ctx.Done():
+			return SignedURL{}, ctx.Err()
+		}
+	}
+	call := &signedURLCall{done: make(chan struct{})}
+	m.inflight[key] = call
+	m.mu.Unlock()
+
+	// The call outlives a cancelled leader so that the followers still get
+	// the URL.
+	call.url, call.err = m.create(context.WithoutCancel(ctx), id, t, opts)
+	if call.err == nil {
+		m.store(key, id, call.url)
+	}
+
+	m.mu.Lock()
+	delete(m.inflight, key)
+	m.mu.Unlock()
+	close(call.done)
+
+	return call.url, call.err
+}
+
+func (m *SignedURLManager) create(ctx context.Context, id string, t *components.Transformation, opts []operations.Option) (SignedURL, error) {
+	ttl := int64(m.cfg.TTL / time.Second)
+	res, err := m.images.CreateSignedURL(ctx, id, &components.CreateSignedURLRequest{
+		ExpiresInSeconds: &ttl,
+		Transformation:   t,
+	}, opts...)
+	if err != nil {
+		return SignedURL{}, err
+	}
+
+	out := res.GetSignedURLResponse()
+	if out == nil || out.SignedURL == "" {
+		return SignedURL{}, errors.New("empty signed URL response")
+	}
+	// The relative lifetime is trusted over the absolute timestamp so that a
+	// skewed local clock does not shorten or extend the cache lifetime.
+	now := m.now()
+	url := SignedURL{URL: out.SignedURL, ExpiresAt: time.Unix(out.ExpiresAt, 0)}
+	if out.ExpiresInSeconds > 0 {
+		url.ExpiresAt = now.Add(time.Duration(out.ExpiresInSeconds) * time.Second)
+	}
+	if !m.usable(url, now) {
+		return SignedURL{}, fmt.Errorf("signed URL expires at %s, within the safety margin", url.ExpiresAt.Format(time.RFC3339))
+	}
+	return url, nil
+}
+
+func (m *SignedURLManager) store(key, id string, url SignedURL) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry, ok := m.entries[key]; ok && entry.url.ExpiresAt.After(url.ExpiresAt) {
+		return
+	}
+	m.entries[key] = &signedURLEntry{id: id, url: url}
+	if len(m.entries) <= m.cfg.MaxEntries {
+		return
+	}
+
+	// Drop whatever can no longer be handed out, then the URLs expiring first.
+	now := m.now()
+	for k, entry := range m.entries {
+		if !m.usable(entry.url, now) {
+			delete(m.entries, k)
+		}
+	}
+	for len(m.entries) > m.cfg.MaxEntries {
+		var oldest string
+		for k, entry := range m.entries {
+			if oldest == "" || entry.url.ExpiresAt.Before(m.entries[oldest].url.ExpiresAt) {
+				oldest = k
+			}
+		}
+		delete(m.entries, oldest)
+	}
+}
+
+// signedURLKey identifies an image and transformation.
+func signedURLKey(id string, t *components.Transformation) string {
+	var b strings.Builder
+	b.WriteString(id)
+	if t == nil {
+		return b.String()
+	}
+
+	add := func(name, value string) {
+		b.WriteString("|" + name + "=" + value)
+	}
+	if t.Width != nil {
+		add("w", strconv.FormatInt(*t.Width, 10))
+	}
+	if t.Height != nil {
+		add("h", strconv.FormatInt(*t.Height, 10))
+	}
+	if t.Fit != nil {
+		add("fit", string(*t.Fit))
+	}
+	if t.Quality != nil {
+		add("q", strconv.FormatInt(*t.Quality, 10))
+	}
+	if t.Format != nil {
+		add("fmt", string(*t.Format))
+	}
+	return b.String()
+}
@@ -0,0 +1,157 @@
+package sdkgo_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/internal/fakeapi"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const signedURLRoute = "POST /api/v1/images/{id}/signed-url"
+
+// newSignedURLManager returns a manager whose clock is advanced by skew.
+func newSignedURLManager(t *testing.T, cfg sdkgo.SignedURLConfig) (*fakeapi.Server, *sdkgo.SignedURLManager, *atomic.Int64) {
+	t.Helper()
+	f := fakeapi.New(t)
+	m := sdkgo.NewSignedURLManager(f.SDK().Images, cfg)
+	var skew atomic.Int64
+	sdkgo.SetSignedURLClock(m, func() time.Time { return time.Now().Add(time.Duration(skew.Load())) })
+	return f, m, &skew
+}
+
+func TestSignedURLManager_Caches(t *testing.T) {
+	t.Parallel()
+	f, m, skew := newSignedURLManager(t, sdkgo.SignedURLConfig{TTL: 10 * time.Minute, SafetyMargin: time.Minute})
+	id := f.Add("private", "a.png")
+	ctx := context.Background()
+
+	first, err := m.Get(ctx, id, nil)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), first.ExpiresAt, 2*time.Second)
+
+	again, err := m.Get(ctx, id, nil)
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+	assert.Equal(t, 1, f.Count(signedURLRoute))
+
+	thumb := &components.Transformation{Width: sdkgo.Int64(200), Format: components.FormatWebp.ToPointer()}
+	small, err := m.Get(ctx, id, thumb)
+	require.NoError(t, err)
+	assert.Contains(t, small.URL, "width=200")
+	assert.NotEqual(t, first.URL, small.URL)
+	_, err = m.Get(ctx, id, &components.Transformation{Width: sdkgo.Int64(200), Format: components.FormatWebp.ToPointer()})
+	require.NoError(t, err)
+	assert.Equal(t, 2, f.Count(signedURLRoute))
+
+	// Within the safety margin a new URL is minted.
+	skew.Store(int64(9*time.Minute + 30*time.Second))
+	renewed, err := m.Get(ctx, id, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.URL, renewed.URL)
+	assert.Equal(t, 3, f.Count(signedURLRoute))
+
+	m.Invalidate(id)
+	_, err = m.Get(ctx, id, thumb)
+	require.NoError(t, err)
+	assert.Equal(t, 4, f.Count(signedURLRoute))
+}
+
+func TestSignedURLManager_ReusesActiveSignedURL(t *testing.T) {
+	t.Parallel()
+	f, m, _ := newSignedURLManager(t, sdkgo.SignedURLConfig{SafetyMargin: 5 * time.Minute})
+	id := f.Add("private", "a.png")
+	ctx := context.Background()
+
+	item := &components.ImageListItem{ID: id, ActiveSignedUrl: &components.ActiveSignedUrl{
+		SignedURL: "https://cdn.example/active",
+		ExpiresAt: time.Now().Add(30 * time.Minute).Unix(),
+	}}
+	got, err := m.GetForItem(ctx, item, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn.example/active", got.URL)
+	assert.Zero(t, f.Count(signedURLRoute))
+
+	// Transformed URLs are never served from the active URL.
+	got, err = m.GetForItem(ctx, item, &components.Transformation{Height: sdkgo.Int64(50)})
+	require.NoError(t, err)
+	assert.NotEqual(t, "https://cdn.example/active", got.URL)
+	assert.Equal(t, 1, f.Count(signedURLRoute))
+
+	// An active URL about to expire is ignored.
+	other := f.Add("other", "b.png")
+	expiring := &components.ImageListItem{ID: other, ActiveSignedUrl: &components.ActiveSignedUrl{
+		SignedURL: "https://cdn.example/expiring",
+		ExpiresAt: time.Now().Add(time.Minute).Unix(),
+	}}
+	got, err = m.GetForItem(ctx, expiring, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, "https://cdn.example/expiring", got.URL)
+	assert.Equal(t, 2, f.Count(signedURLRoute))
+}
+
+func TestSignedURLManager_Singleflight(t *testing.T) {
+	t.Parallel()
+	f, m, _ := newSignedURLManager(t, sdkgo.SignedURLConfig{})
+	id := f.Add("private", "a.png")
+
+	release := make(chan struct{})
+	f.Fail(func(r *http.Request) int {
+		<-release
+		return 0
+	})
+
+	var wg sync.WaitGroup
+	urls := make([]string, 20)
+	for i := range urls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := m.Get(context.Background(), id, nil)
+			assert.NoError(t, err)
+			urls[i] = got.URL
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, 1, f.Count(signedURLRoute))
+	for _, u := range urls {
+		assert.Equal(t, urls[0], u)
+	}
+}
+
+func TestSignedURLManager_RefreshAhead(t *testing.T) {
+	t.Parallel()
+	f, m, skew := newSignedURLManager(t, sdkgo.SignedURLConfig{
+		TTL:          time.Hour,
+		SafetyMargin: 5 * time.Minute,
+		RefreshAhead: 10 * time.Minute,
+	})
+	id := f.Add("private", "a.png")
+	ctx := context.Background()
+
+	first, err := m.Get(ctx, id, nil)
+	require.NoError(t, err)
+
+	// Inside the refresh window the cached URL is still returned while a new
+	// one is minted in the background.
+	skew.Store(int64(50 * time.Minute))
+	got, err := m.Get(ctx, id, nil)
+	require.NoError(t, err)
+	assert.Equal(t, first.URL, got.URL)
+
+	assert.Eventually(t, func() bool {
+		got, err := m.Get(ctx, id, nil)
+		return err == nil && got.URL != first.URL
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 2, f.Count(signedURLRoute))
+}
@@ -0,0 +1,251 @@
+package sdkgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/img-src-io/sdk-go/internal/workpool"
+	"github.com/img-src-io/sdk-go/models/apierrors"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/img-src-io/sdk-go/models/operations"
+	"github.com/img-src-io/sdk-go/retry"
+)
+
+const (
+	// maxSignedURLAttempts is how often a rate limited item, or one failing
+	// with a server error, is attempted before its error is reported.
+	maxSignedURLAttempts = 3
+	// serverErrorBackoff is the pause before an item that failed with a
+	// server error is tried again, multiplied by the attempt number.
+	serverErrorBackoff = 500 * time.Millisecond
+)
+
+// SignedURLSpec describes a signed URL to create.
+type SignedURLSpec struct {
+	ID string
+	// ExpiresIn is the requested lifetime. Zero uses the API default of one
+	// hour.
+	ExpiresIn      time.Duration
+	Transformation *components.Transformation
+}
+
+// SignedURLsOptions configures Images.CreateSignedURLs.
+type SignedURLsOptions struct {
+	// Concurrency is the number of URLs created in parallel. Defaults to 8.
+	Concurrency int
+}
+
+// SignedURLResult is the outcome for a single SignedURLSpec.
+type SignedURLResult struct {
+	Spec      SignedURLSpec
+	URL       string
+	ExpiresAt time.Time
+	Err       error
+}
+
+// SignedURLBatch holds the results of Images.CreateSignedURLs in the order of
+// the specs.
+type SignedURLBatch struct {
+	Results []SignedURLResult
+	// SoonestExpiry is the earliest expiry of the created URLs, suitable for
+	// the Expires header of a page embedding them. It is zero if none was
+	// created.
+	SoonestExpiry time.Time
+	Failed        int
+}
+
+// Err returns the errors of every failed item joined together, or nil.
+func (b *SignedURLBatch) Err() error {
+	if b == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, res := range b.Results {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("image %s: %w", res.Spec.ID, res.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CreateSignedURLs creates a signed URL for every spec with bounded
+// concurrency. The API has no batch endpoint, so every URL costs a request;
+// when the API reports that the rate limit is exhausted, all workers pause
+// until it resets and rate limited items are tried again. Failures of
+// individual items are recorded in the batch rather than aborting the run.
+//
+// The SDK's retry loop would retry rate limited requests on its own, hidden
+// from the other workers, so it is disabled for these requests and any
+// retries option is overridden: items are instead attempted up to three
+// times on 429 and 5XX responses.
+func (s *Images) CreateSignedURLs(ctx context.Context, specs []SignedURLSpec, opts *SignedURLsOptions, reqOpts ...operations.Option) (*SignedURLBatch, error) {
+	if opts == nil {
+		opts = &SignedURLsOptions{}
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 8
+	}
+
+	reqOpts = append(append([]operations.Option(nil), reqOpts...), operations.WithRetries(retry.Config{Strategy: "none"}))
+
+	batch := &SignedURLBatch{Results: make([]SignedURLResult, len(specs))}
+	gate := &rateLimitGate{}
+
+	workpool.ForEach(ctx, len(specs), concurrency, func(ctx context.Context, i int) {
+		res := &batch.Results[i]
+		res.Spec = specs[i]
+
+		for attempt := 1; ; attempt++ {
+			if err := gate.wait(ctx); err != nil {
+				res.Err = err
+				return
+			}
+
+			var httpRes *http.Response
+			res.URL, res.ExpiresAt, httpRes, res.Err = s.createSignedURL(ctx, res.Spec, reqOpts)
+			gate.observe(httpRes)
+			if res.Err == nil || attempt == maxSignedURLAttempts {
+				return
+			}
+			switch status := errorStatus(httpRes, res.Err); {
+			case status == http.StatusTooManyRequests:
+				// The gate holds the next attempt back.
+			case status >= 500:
+				if err := sleep(ctx, time.Duration(attempt)*serverErrorBackoff); err != nil {
+					return
+				}
+			default:
+				return
+			}
+		}
+	})
+
+	for _, res := range batch.Results {
+		switch {
+		case res.Err != nil:
+			batch.Failed++
+		case batch.SoonestExpiry.IsZero() || res.ExpiresAt.Before(batch.SoonestExpiry):
+			batch.SoonestExpiry = res.ExpiresAt
+		}
+	}
+
+	return batch, ctx.Err()
+}
+
+// createSignedURL returns the HTTP response of failed requests too, so that
+// the caller can honour rate limits.
+func (s *Images) createSignedURL(ctx context.Context, spec SignedURLSpec, opts []operations.Option) (string, time.Time, *http.Response, error) {
+	body := &components.CreateSignedURLRequest{Transformation: spec.Transformation}
+	if spec.ExpiresIn > 0 {
+		body.ExpiresInSeconds = Int64(int64(spec.ExpiresIn / time.Second))
+	}
+
+	res, err := s.CreateSignedURL(ctx, spec.ID, body, opts...)
+	if err != nil {
+		var apiErr *apierrors.APIError
+		if errors.As(err, &apiErr) {
+			return "", time.Time{}, apiErr.RawResponse, err
+		}
+		return "", time.Time{}, nil, err
+	}
+
+	out := res.GetSignedURLResponse()
+	if out == nil || out.SignedURL == "" {
+		return "", time.Time{}, res.HTTPMeta.Response, errors.New("empty signed URL response")
+	}
+	return out.SignedURL, time.Unix(out.ExpiresAt, 0), res.HTTPMeta.Response, nil
+}
+
+// errorStatus returns the HTTP status of a failed request. Errors decoded
+// from a JSON body carry no response, so their status is taken from the body.
+func errorStatus(httpRes *http.Response, err error) int {
+	if httpRes != nil {
+		return httpRes.StatusCode
+	}
+	var errRes *apierrors.ErrorResponse
+	if errors.As(err, &errRes) {
+		return int(errRes.Error_.Status)
+	}
+	return 0
+}
+
+// rateLimitGate pauses the workers of a batch while the API rate limit is
+// exhausted.
+type rateLimitGate struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+func (g *rateLimitGate) wait(ctx context.Context) error {
+	g.mu.Lock()
+	d := time.Until(g.until)
+	g.mu.Unlock()
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	return sleep(ctx, d)
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// observe pauses the gate after a 429 response, for as long as its
+// Retry-After header says or a second without one, and after a response
+// reporting that no requests remain until X-RateLimit-Reset.
+func (g *rateLimitGate) observe(res *http.Response) {
+	if res == nil {
+		return
+	}
+
+	var d time.Duration
+	switch {
+	case res.StatusCode == http.StatusTooManyRequests:
+		d = time.Second
+		if v := res.Header.Get("Retry-After"); v != "" {
+			d = parseRateLimitDelay(v, false)
+		}
+	case res.Header.Get("X-RateLimit-Remaining") == "0":
+		d = parseRateLimitDelay(res.Header.Get("X-RateLimit-Reset"), true)
+	default:
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if until := time.Now().Add(d); until.After(g.until) {
+		g.until = until
+	}
+}
+
+// parseRateLimitDelay parses a delay in seconds or, for Retry-After, an HTTP
+// date. Values that look like Unix timestamps are accepted when epoch is set,
+// as some servers send X-RateLimit-Reset that way.
+func parseRateLimitDelay(v string, epoch bool) time.Duration {
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		if epoch && n > 1_000_000_000 {
+			return time.Until(time.Unix(n, 0))
+		}
+		return time.Duration(n) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
@@ -0,0 +1,125 @@
+package sdkgo_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/internal/fakeapi"
+	"github.com/img-src-io/sdk-go/models/apierrors"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/img-src-io/sdk-go/models/operations"
+	"github.com/img-src-io/sdk-go/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateSignedURLs(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	a := f.Add("a", "a.png")
+	b := f.Add("b", "b.png")
+	c := f.Add("c", "c.png")
+
+	// The first request for b is rate limited.
+	var limited atomic.Bool
+	f.Fail(func(r *http.Request) int {
+		if strings.Contains(r.URL.Path, "/"+b+"/") && limited.CompareAndSwap(false, true) {
+			return http.StatusTooManyRequests
+		}
+		return 0
+	})
+
+	specs := []sdkgo.SignedURLSpec{
+		{ID: a, ExpiresIn: 10 * time.Minute},
+		{ID: "missing"},
+		{ID: b, ExpiresIn: 2 * time.Minute, Transformation: &components.Transformation{Width: sdkgo.Int64(64)}},
+		{ID: c},
+	}
+	batch, err := f.SDK().Images.CreateSignedURLs(context.Background(), specs, &sdkgo.SignedURLsOptions{Concurrency: 2},
+		operations.WithRetries(retry.Config{Strategy: "none"}))
+	require.NoError(t, err)
+	require.Len(t, batch.Results, 4)
+
+	for i, res := range batch.Results {
+		assert.Equal(t, specs[i], res.Spec)
+	}
+	assert.Contains(t, batch.Results[0].URL, "/cdn/"+a+"?")
+	assert.Contains(t, batch.Results[2].URL, "/cdn/"+b+"?")
+	assert.Contains(t, batch.Results[2].URL, "width=64")
+	assert.Contains(t, batch.Results[3].URL, "/cdn/"+c+"?")
+
+	var notFound *apierrors.ErrorResponse
+	require.ErrorAs(t, batch.Results[1].Err, &notFound)
+	assert.Equal(t, int64(404), notFound.Error_.Status)
+	assert.Empty(t, batch.Results[1].URL)
+	assert.Equal(t, 1, batch.Failed)
+	require.Error(t, batch.Err())
+
+	assert.Equal(t, batch.Results[2].ExpiresAt, batch.SoonestExpiry)
+	assert.WithinDuration(t, time.Now().Add(2*time.Minute), batch.SoonestExpiry, 2*time.Second)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), batch.Results[3].ExpiresAt, 2*time.Second)
+	assert.Equal(t, 5, f.Count("POST /api/v1/images/{id}/signed-url"))
+}
+
+func TestCreateSignedURLs_DefaultRetriesPauseBatch(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	f.SetRetryAfter(time.Second)
+	var specs []sdkgo.SignedURLSpec
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		specs = append(specs, sdkgo.SignedURLSpec{ID: f.Add(name, name+".png")})
+	}
+
+	var mu sync.Mutex
+	var limitedAt time.Time
+	var during int
+	f.Fail(func(r *http.Request) int {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case limitedAt.IsZero() && strings.Contains(r.URL.Path, "/"+specs[1].ID+"/"):
+			limitedAt = time.Now()
+			return http.StatusTooManyRequests
+		case !limitedAt.IsZero() && time.Since(limitedAt) < 900*time.Millisecond:
+			during++
+		}
+		return 0
+	})
+
+	// The client keeps the SDK's default retries, which would otherwise retry
+	// the 429 out of sight of the other workers.
+	batch, err := f.SDK().Images.CreateSignedURLs(context.Background(), specs, &sdkgo.SignedURLsOptions{Concurrency: 2})
+	require.NoError(t, err)
+	require.NoError(t, batch.Err())
+	// Only a request already in flight may reach the API while paused.
+	assert.LessOrEqual(t, during, 1)
+	assert.Equal(t, 7, f.Count("POST /api/v1/images/{id}/signed-url"))
+}
+
+func TestCreateSignedURLs_RetriesJSONServerErrors(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	id := f.Add("a", "a.png")
+
+	// The fake reports errors with a JSON body, which the SDK decodes into an
+	// *apierrors.ErrorResponse carrying no HTTP response.
+	var failed atomic.Bool
+	f.Fail(func(r *http.Request) int {
+		if strings.HasSuffix(r.URL.Path, "/signed-url") && failed.CompareAndSwap(false, true) {
+			return http.StatusInternalServerError
+		}
+		return 0
+	})
+
+	batch, err := f.SDK().Images.CreateSignedURLs(context.Background(), []sdkgo.SignedURLSpec{{ID: id}}, nil)
+	require.NoError(t, err)
+	require.NoError(t, batch.Err())
+	assert.Contains(t, batch.Results[0].URL, "/cdn/"+id+"?")
+	assert.Equal(t, 2, f.Count("POST /api/v1/images/{id}/signed-url"))
+}
@@ -0,0 +1,223 @@
+package sdkgo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/img-src-io/sdk-go/internal/limit"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/img-src-io/sdk-go/models/operations"
+)
+
+const (
+	// DefaultRemoteMaxSize is used when UploadFromURLOptions.MaxSize is zero.
+	DefaultRemoteMaxSize = 50 << 20
+	// DefaultRemoteTimeout is used when UploadFromURLOptions.Timeout is zero.
+	DefaultRemoteTimeout = 30 * time.Second
+	// DefaultJPEGQuality is used when UploadImageOptions.Quality is zero.
+	DefaultJPEGQuality = 90
+)
+
+// ImageEncoding is the format Images.UploadImage encodes images in.
+type ImageEncoding string
+
+const (
+	ImageEncodingPNG  ImageEncoding = "png"
+	ImageEncodingJPEG ImageEncoding = "jpeg"
+)
+
+// UploadImageOptions configures Images.UploadImage.
+type UploadImageOptions struct {
+	TargetPath string
+	Visibility *components.Visibility
+	// FileName defaults to the base name of TargetPath, or "image", with the
+	// extension of the encoding.
+	FileName string
+	// Quality is the JPEG quality. Defaults to DefaultJPEGQuality.
+	Quality int
+	// ContentType overrides the Content-Type of the file, which is otherwise
+	// detected from the encoded image. See WithUploadContentType.
+	ContentType string
+}
+
+// UploadImage encodes img in memory and uploads it. Upload buffers the whole
+// request body before sending it, so streaming the encoder would not save
+// memory.
+func (s *Images) UploadImage(ctx context.Context, img image.Image, encoding ImageEncoding, opts *UploadImageOptions, reqOpts ...operations.Option) (*operations.UploadImageResponse, error) {
+	if opts == nil {
+		opts = &UploadImageOptions{}
+	}
+
+	var encode func(w io.Writer) error
+	ext := "." + string(encoding)
+	switch encoding {
+	case ImageEncodingPNG:
+		encode = func(w io.Writer) error { return png.Encode(w, img) }
+	case ImageEncodingJPEG:
+		quality := opts.Quality
+		if quality <= 0 {
+			quality = DefaultJPEGQuality
+		}
+		encode = func(w io.Writer) error { return jpeg.Encode(w, img, &jpeg.Options{Quality: quality}) }
+		ext = ".jpg"
+	default:
+		return nil, fmt.Errorf("unsupported image encoding %q", encoding)
+	}
+
+	fileName := opts.FileName
+	if fileName == "" {
+		fileName = "image"
+		if base := path.Base(strings.Trim(opts.TargetPath, "/")); base != "." && base != "" {
+			fileName = strings.TrimSuffix(base, path.Ext(base))
+		}
+		fileName += ext
+	}
+
+	var buf bytes.Buffer
+	if err := encode(&buf); err != nil {
+		return nil, fmt.Errorf("error encoding image: %w", err)
+	}
+
+	if opts.ContentType != "" {
+		ctx = WithUploadContentType(ctx, opts.ContentType)
+	}
+	return s.Upload(ctx, uploadBody(fileName, &buf, opts.TargetPath, opts.Visibility), reqOpts...)
+}
+
+// UploadFromURLOptions configures Images.UploadFromURL.
+type UploadFromURLOptions struct {
+	TargetPath string
+	Visibility *components.Visibility
+	// FileName defaults to the file name of the Content-Disposition header or
+	// the URL, completed with the extension of the Content-Type if it has
+	// none.
+	FileName string
+	// MaxSize is the largest object accepted, in bytes. Larger objects fail
+	// with ErrImageTooLarge. Defaults to DefaultRemoteMaxSize.
+	MaxSize int64
+	// Timeout bounds the download. Defaults to DefaultRemoteTimeout.
+	Timeout time.Duration
+	// HTTPClient fetches the object. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// ContentType overrides the Content-Type of the file, which is otherwise
+	// detected from its contents or extension. See WithUploadContentType.
+	ContentType string
+}
+
+// UploadFromURL streams the object at rawURL into Upload.
+func (s *Images) UploadFromURL(ctx context.Context, rawURL string, opts *UploadFromURLOptions, reqOpts ...operations.Option) (*operations.UploadImageResponse, error) {
+	if opts == nil {
+		opts = &UploadFromURLOptions{}
+	}
+	maxSize := opts.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultRemoteMaxSize
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultRemoteTimeout
+	}
+	client := opts.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported source URL scheme %q", u.Scheme)
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(fetchCtx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s: %w", u.Redacted(), err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching %s: %s", u.Redacted(), res.Status)
+	}
+	if res.ContentLength > maxSize {
+		return nil, fmt.Errorf("%w: %s has %d bytes, limit is %d", ErrImageTooLarge, u.Redacted(), res.ContentLength, maxSize)
+	}
+
+	fileName := opts.FileName
+	if fileName == "" {
+		fileName = remoteFileName(u, res.Header)
+	}
+	body := limit.NewReader(res.Body, maxSize, fmt.Errorf("%w: more than %d bytes", ErrImageTooLarge, maxSize))
+	if opts.ContentType != "" {
+		ctx = WithUploadContentType(ctx, opts.ContentType)
+	}
+	return s.Upload(ctx, uploadBody(fileName, body, opts.TargetPath, opts.Visibility), reqOpts...)
+}
+
+func uploadBody(fileName string, content io.Reader, targetPath string, visibility *components.Visibility) *operations.UploadImageRequestBody {
+	body := &operations.UploadImageRequestBody{
+		File:       &operations.File{FileName: fileName, Content: content},
+		Visibility: visibility,
+	}
+	if targetPath != "" {
+		body.TargetPath = String(targetPath)
+	}
+	return body
+}
+
+// remoteExtensions are the extensions of the image types, where
+// mime.ExtensionsByType would offer several or none.
+var remoteExtensions = map[string]string{
+	"image/jpeg":    ".jpg",
+	"image/png":     ".png",
+	"image/gif":     ".gif",
+	"image/webp":    ".webp",
+	"image/avif":    ".avif",
+	"image/jxl":     ".jxl",
+	"image/heic":    ".heic",
+	"image/heif":    ".heif",
+	"image/svg+xml": ".svg",
+}
+
+// remoteFileName derives the file name of a downloaded object from its
+// Content-Disposition, or else its URL, and its Content-Type.
+func remoteFileName(u *url.URL, header http.Header) string {
+	var name string
+	if _, params, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil {
+		name = params["filename"]
+	}
+	if name == "" {
+		name = path.Base(u.Path)
+	}
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	if name == "." || name == "/" || name == "" {
+		name = "image"
+	}
+
+	if path.Ext(name) == "" {
+		mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+		if ext, ok := remoteExtensions[mediaType]; ok {
+			name += ext
+		} else if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+			name += exts[0]
+		}
+	}
+	return name
+}
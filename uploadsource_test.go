@@ -0,0 +1,125 @@
+package sdkgo_test
+
+import (
+	"bytes"
+	"context"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/internal/fakeapi"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadImage(t *testing.T) {
+	t.Parallel()
+	f := fakeapi.New(t)
+	images := f.SDK().Images
+	img := testImage(40, 20)
+
+	res, err := images.UploadImage(context.Background(), img, sdkgo.ImageEncodingPNG, &sdkgo.UploadImageOptions{TargetPath: "charts/q3"})
+	require.NoError(t, err)
+	stored := f.Image(res.GetUploadResponse().ID)
+	assert.Equal(t, "q3.png", stored.Filename)
+	assert.Equal(t, []string{"charts/q3"}, stored.Paths)
+	decoded, err := png.Decode(bytes.NewReader(stored.Data))
+	require.NoError(t, err)
+	assert.Equal(t, img.Bounds(), decoded.Bounds())
+
+	private := components.VisibilityPrivate
+	res, err = images.UploadImage(context.Background(), img, sdkgo.ImageEncodingJPEG, &sdkgo.UploadImageOptions{Quality: 50, Visibility: &private})
+	require.NoError(t, err)
+	stored = f.Image(res.GetUploadResponse().ID)
+	assert.Equal(t, "image.jpg", stored.Filename)
+	assert.Equal(t, "private", stored.Visibility)
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(stored.Data))
+	require.NoError(t, err)
+	assert.Equal(t, 40, cfg.Width)
+
+	_, err = images.UploadImage(context.Background(), img, "bmp", nil)
+	assert.ErrorContains(t, err, "unsupported image encoding")
+}
+
+func TestUploadFromURL(t *testing.T) {
+	t.Parallel()
+	pngData := encodePNG(t, 8, 8)
+
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/photos/cat.png":
+		case "/download":
+			w.Header().Set("Content-Disposition", `attachment; filename="dog.png"`)
+		case "/render":
+			w.Header().Set("Content-Type", "image/png")
+		case "/big":
+			w.Header().Set("Content-Length", "100000")
+			_, _ = w.Write(make([]byte, 100000))
+			return
+		case "/chunked":
+			for i := 0; i < 10; i++ {
+				_, _ = w.Write(make([]byte, 10000))
+				w.(http.Flusher).Flush()
+			}
+			return
+		case "/slow":
+			select {
+			case <-time.After(5 * time.Second):
+			case <-r.Context().Done():
+			}
+			return
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		// Distinct content, so that the server does not deduplicate uploads.
+		_, _ = w.Write(append(bytes.Clone(pngData), r.URL.Path...))
+	}))
+	t.Cleanup(src.Close)
+
+	f := fakeapi.New(t)
+	images := f.SDK().Images
+
+	t.Run("file names", func(t *testing.T) {
+		for p, want := range map[string]string{
+			"/photos/cat.png": "cat.png",
+			"/download":       "dog.png",
+			"/render?chart=1": "render.png",
+		} {
+			res, err := images.UploadFromURL(context.Background(), src.URL+p, &sdkgo.UploadFromURLOptions{TargetPath: "remote" + strings.SplitN(p, "?", 2)[0]})
+			require.NoError(t, err, p)
+			stored := f.Image(res.GetUploadResponse().ID)
+			assert.Equal(t, want, stored.Filename, p)
+			assert.Equal(t, append(bytes.Clone(pngData), strings.SplitN(p, "?", 2)[0]...), stored.Data)
+		}
+	})
+
+	t.Run("size limit", func(t *testing.T) {
+		for _, p := range []string{"/big", "/chunked"} {
+			_, err := images.UploadFromURL(context.Background(), src.URL+p, &sdkgo.UploadFromURLOptions{MaxSize: 50000})
+			assert.ErrorIs(t, err, sdkgo.ErrImageTooLarge, p)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		start := time.Now()
+		_, err := images.UploadFromURL(context.Background(), src.URL+"/slow", &sdkgo.UploadFromURLOptions{Timeout: 50 * time.Millisecond})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 2*time.Second)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := images.UploadFromURL(context.Background(), src.URL+"/missing", nil)
+		assert.ErrorContains(t, err, "404")
+		_, err = images.UploadFromURL(context.Background(), "file:///etc/passwd", nil)
+		assert.ErrorContains(t, err, "unsupported source URL scheme")
+	})
+
+	assert.Equal(t, 3, f.Len())
+}
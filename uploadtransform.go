@@ -0,0 +1,69 @@
+package sdkgo
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/img-src-io/sdk-go/internal/hooks"
+)
+
+// UploadTransform rewrites the content of a file uploaded by Images.Upload
+// before it is sent, e.g. to strip metadata. The imgproc package provides
+// the built-in transforms. A returned reader other than r that implements
+// io.Closer is closed once the file has been sent.
+type UploadTransform func(fileName string, r io.Reader) (io.Reader, error)
+
+// WithUploadTransforms runs every uploaded file through transforms, in order,
+// before the content type is detected. Repeated uses append to the chain.
+func WithUploadTransforms(transforms ...UploadTransform) SDKOption {
+	return func(sdk *Imgsrc) {
+		chain := ChainUploadTransforms(transforms...)
+		if prev := sdk.hooks.UploadTransform(); prev != nil {
+			chain = ChainUploadTransforms(UploadTransform(prev), chain)
+		}
+		sdk.hooks.ConfigureUploadTransform(hooks.FileTransform(chain))
+	}
+}
+
+// ChainUploadTransforms returns a transform applying transforms in order.
+// Closing its output closes the outputs of every transform.
+func ChainUploadTransforms(transforms ...UploadTransform) UploadTransform {
+	return func(fileName string, r io.Reader) (io.Reader, error) {
+		chain := &chainedReader{Reader: r}
+		for _, t := range transforms {
+			next, err := t(fileName, chain.Reader)
+			if err != nil {
+				_ = chain.Close()
+				return nil, err
+			}
+			if c, ok := next.(io.Closer); ok && !sameReader(next, chain.Reader) {
+				chain.closers = append(chain.closers, c)
+			}
+			chain.Reader = next
+		}
+		if len(chain.closers) == 0 {
+			return chain.Reader, nil
+		}
+		return chain, nil
+	}
+}
+
+type chainedReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (c *chainedReader) Close() error {
+	var err error
+	for i := len(c.closers) - 1; i >= 0; i-- {
+		if cerr := c.closers[i].Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func sameReader(a, b io.Reader) bool {
+	t := reflect.TypeOf(a)
+	return t != nil && t == reflect.TypeOf(b) && t.Comparable() && a == b
+}
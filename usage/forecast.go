@@ -0,0 +1,397 @@
+// Package usage projects end-of-period consumption from successive
+// Usage.Get samples so callers can tell whether they will exceed their plan
+// limits before the current billing period ends.
+package usage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	sdkgo "github.com/img-src-io/sdk-go"
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/img-src-io/sdk-go/models/operations"
+)
+
+// ErrNoSamples is returned by Forecaster.Forecast when no usage sample has been recorded yet.
+var ErrNoSamples = errors.New("usage: no samples recorded")
+
+// Dimension identifies a metered quantity of the plan.
+type Dimension string
+
+const (
+	DimensionUploads         Dimension = "uploads"
+	DimensionBandwidth       Dimension = "bandwidth"
+	DimensionAPIRequests     Dimension = "api_requests"
+	DimensionTransformations Dimension = "transformations"
+	DimensionStorage         Dimension = "storage"
+)
+
+// Dimensions lists every dimension in the order projections are reported.
+var Dimensions = []Dimension{
+	DimensionUploads,
+	DimensionBandwidth,
+	DimensionAPIRequests,
+	DimensionTransformations,
+	DimensionStorage,
+}
+
+// Method selects how the consumption rate is estimated from samples.
+type Method string
+
+const (
+	// MethodLinear uses the average rate between the first and the last sample of the period.
+	MethodLinear Method = "linear"
+	// MethodEWMA uses an exponentially weighted moving average of the rates between successive samples.
+	MethodEWMA Method = "ewma"
+)
+
+const (
+	defaultAlpha      = 0.3
+	defaultMaxSamples = 256
+)
+
+// Sample is a usage snapshot and the time it was observed.
+type Sample struct {
+	ObservedAt time.Time
+	Usage      components.UsageResponse
+}
+
+// Forecaster accumulates usage samples for the current period and projects
+// them to the end of the period. It is safe for concurrent use.
+type Forecaster struct {
+	// Method used to estimate rates. Defaults to MethodLinear.
+	Method Method
+	// Alpha is the smoothing factor in (0, 1] used by MethodEWMA. Defaults to 0.3.
+	Alpha float64
+	// MaxSamples bounds the number of retained samples. Defaults to 256.
+	MaxSamples int
+
+	mu      sync.Mutex
+	samples []Sample
+}
+
+// NewForecaster creates a Forecaster using the given rate estimation method.
+func NewForecaster(method Method) *Forecaster {
+	return &Forecaster{Method: method}
+}
+
+// Add records a usage snapshot observed at the given time. Samples from an
+// earlier period are discarded as soon as a sample from a new period arrives.
+func (f *Forecaster) Add(observedAt time.Time, res *components.UsageResponse) {
+	if res == nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if n := len(f.samples); n > 0 && f.samples[n-1].Usage.CurrentPeriod.Period != res.CurrentPeriod.Period {
+		f.samples = f.samples[:0]
+	}
+
+	f.samples = append(f.samples, Sample{ObservedAt: observedAt, Usage: *res})
+
+	maxSamples := f.MaxSamples
+	if maxSamples <= 0 {
+		maxSamples = defaultMaxSamples
+	}
+	if len(f.samples) > maxSamples {
+		f.samples = append(f.samples[:0], f.samples[len(f.samples)-maxSamples:]...)
+	}
+}
+
+// Poll fetches the current usage with Usage.Get and records it as a sample.
+func (f *Forecaster) Poll(ctx context.Context, client *sdkgo.Usage, opts ...operations.Option) (*components.UsageResponse, error) {
+	res, err := client.Get(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if res.UsageResponse == nil {
+		return nil, fmt.Errorf("usage: empty usage response")
+	}
+
+	f.Add(time.Now(), res.UsageResponse)
+
+	return res.UsageResponse, nil
+}
+
+// Samples returns a copy of the samples retained for the current period.
+func (f *Forecaster) Samples() []Sample {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Sample(nil), f.samples...)
+}
+
+// Projection is the end-of-period estimate for a single dimension.
+type Projection struct {
+	Dimension Dimension
+	// Current is the value reported by the most recent sample.
+	Current int64
+	// RatePerSecond is the estimated consumption rate.
+	RatePerSecond float64
+	// Projected is the estimated value at the end of the period.
+	Projected int64
+	// Limit is the plan limit for the dimension (nil = unlimited).
+	Limit *int64
+	// Overage is the projected amount above Limit.
+	Overage int64
+	// CreditsAvailable is the credit balance that applies to this dimension.
+	CreditsAvailable int64
+	// CreditsCovered is the part of Overage absorbed by CreditsAvailable.
+	CreditsCovered int64
+	// Uncovered is the part of Overage that credits do not cover.
+	Uncovered int64
+	// TimeToExhaustion is the estimated time from the last sample until Limit
+	// is reached. It is nil when the dimension is unlimited or not growing.
+	TimeToExhaustion *time.Duration
+}
+
+// WillExceed reports whether the projection ends the period above the plan limit.
+func (p Projection) WillExceed() bool {
+	return p.Overage > 0
+}
+
+// ExhaustsBeforePeriodEnd reports whether the limit is reached before the period ends.
+func (p Projection) ExhaustsBeforePeriodEnd(remaining time.Duration) bool {
+	return p.TimeToExhaustion != nil && *p.TimeToExhaustion <= remaining
+}
+
+// Forecast is the set of projections computed from the retained samples.
+type Forecast struct {
+	Method Method
+	// Period identifier (YYYY-MM format)
+	Period      string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	// ObservedAt is the time of the most recent sample.
+	ObservedAt time.Time
+	// Remaining is the time left in the period after ObservedAt.
+	Remaining   time.Duration
+	Samples     int
+	Projections []Projection
+}
+
+// Get returns the projection for the given dimension.
+func (f *Forecast) Get(d Dimension) (Projection, bool) {
+	if f == nil {
+		return Projection{}, false
+	}
+	for _, p := range f.Projections {
+		if p.Dimension == d {
+			return p, true
+		}
+	}
+	return Projection{}, false
+}
+
+// Exceeding returns the projections that are expected to end the period above their limit.
+func (f *Forecast) Exceeding() []Projection {
+	if f == nil {
+		return nil
+	}
+	var out []Projection
+	for _, p := range f.Projections {
+		if p.WillExceed() {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Forecast projects every dimension to the end of the current period.
+func (f *Forecaster) Forecast() (*Forecast, error) {
+	f.mu.Lock()
+	samples := append([]Sample(nil), f.samples...)
+	method := f.Method
+	alpha := f.Alpha
+	f.mu.Unlock()
+
+	if len(samples) == 0 {
+		return nil, ErrNoSamples
+	}
+
+	if method == "" {
+		method = MethodLinear
+	}
+	if alpha <= 0 || alpha > 1 {
+		alpha = defaultAlpha
+	}
+
+	last := samples[len(samples)-1]
+	period := last.Usage.CurrentPeriod
+	periodStart := time.Unix(period.PeriodStart, 0)
+	periodEnd := time.Unix(period.PeriodEnd, 0)
+
+	remaining := periodEnd.Sub(last.ObservedAt)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	out := &Forecast{
+		Method:      method,
+		Period:      period.Period,
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+		ObservedAt:  last.ObservedAt,
+		Remaining:   remaining,
+		Samples:     len(samples),
+	}
+
+	for _, d := range Dimensions {
+		var rate float64
+		switch method {
+		case MethodLinear:
+			rate = linearRate(samples, d)
+		case MethodEWMA:
+			rate = ewmaRate(samples, d, alpha)
+		default:
+			return nil, fmt.Errorf("usage: unsupported forecast method %q", method)
+		}
+
+		out.Projections = append(out.Projections, project(last.Usage, d, rate, remaining))
+	}
+
+	return out, nil
+}
+
+func project(u components.UsageResponse, d Dimension, rate float64, remaining time.Duration) Projection {
+	p := Projection{
+		Dimension:        d,
+		Current:          value(u, d),
+		RatePerSecond:    rate,
+		Limit:            limit(u.PlanLimits, d),
+		CreditsAvailable: credits(u.Credits, d),
+	}
+
+	projected := float64(p.Current) + rate*remaining.Seconds()
+	if projected < 0 {
+		projected = 0
+	}
+	p.Projected = int64(math.Round(projected))
+
+	if p.Limit == nil {
+		return p
+	}
+
+	if p.Projected > *p.Limit {
+		p.Overage = p.Projected - *p.Limit
+	}
+	p.CreditsCovered = min(p.Overage, p.CreditsAvailable)
+	p.Uncovered = p.Overage - p.CreditsCovered
+
+	switch {
+	case p.Current >= *p.Limit:
+		var zero time.Duration
+		p.TimeToExhaustion = &zero
+	case rate > 0:
+		tte := time.Duration(float64(*p.Limit-p.Current) / rate * float64(time.Second))
+		p.TimeToExhaustion = &tte
+	}
+
+	return p
+}
+
+// linearRate returns the average rate over the retained samples. With a single
+// sample, periodic counters fall back to the average since the period start.
+func linearRate(samples []Sample, d Dimension) float64 {
+	first, last := samples[0], samples[len(samples)-1]
+	if len(samples) == 1 || !last.ObservedAt.After(first.ObservedAt) {
+		return sinceStartRate(last, d)
+	}
+
+	return float64(value(last.Usage, d)-value(first.Usage, d)) / last.ObservedAt.Sub(first.ObservedAt).Seconds()
+}
+
+// ewmaRate smooths the rates between successive samples, weighting recent
+// intervals more heavily.
+func ewmaRate(samples []Sample, d Dimension, alpha float64) float64 {
+	var (
+		rate   float64
+		seeded bool
+	)
+	for i := 1; i < len(samples); i++ {
+		prev, cur := samples[i-1], samples[i]
+		elapsed := cur.ObservedAt.Sub(prev.ObservedAt).Seconds()
+		if elapsed <= 0 {
+			continue
+		}
+
+		r := float64(value(cur.Usage, d)-value(prev.Usage, d)) / elapsed
+		if !seeded {
+			rate, seeded = r, true
+			continue
+		}
+		rate = alpha*r + (1-alpha)*rate
+	}
+
+	if !seeded {
+		return sinceStartRate(samples[len(samples)-1], d)
+	}
+
+	return rate
+}
+
+func sinceStartRate(s Sample, d Dimension) float64 {
+	if d == DimensionStorage {
+		// Storage is cumulative across periods, so a single sample says nothing about its growth.
+		return 0
+	}
+
+	elapsed := s.ObservedAt.Sub(time.Unix(s.Usage.CurrentPeriod.PeriodStart, 0)).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return float64(value(s.Usage, d)) / elapsed
+}
+
+func value(u components.UsageResponse, d Dimension) int64 {
+	switch d {
+	case DimensionUploads:
+		return u.CurrentPeriod.Uploads
+	case DimensionBandwidth:
+		return u.CurrentPeriod.BandwidthBytes
+	case DimensionAPIRequests:
+		return u.CurrentPeriod.APIRequests
+	case DimensionTransformations:
+		return u.CurrentPeriod.Transformations
+	case DimensionStorage:
+		return u.StorageUsedBytes
+	}
+	return 0
+}
+
+func limit(l components.PlanLimits, d Dimension) *int64 {
+	switch d {
+	case DimensionUploads:
+		return l.MaxUploadsPerMonth
+	case DimensionBandwidth:
+		return l.MaxBandwidthPerMonth
+	case DimensionAPIRequests:
+		return l.MaxAPIRequestsPerMonth
+	case DimensionTransformations:
+		return l.MaxTransformationsPerMonth
+	case DimensionStorage:
+		return l.MaxStorageBytes
+	}
+	return nil
+}
+
+// credits returns the credit balance that can absorb overage for a dimension.
+// Uploads and bandwidth have no credit counterpart.
+func credits(c components.Credits, d Dimension) int64 {
+	switch d {
+	case DimensionAPIRequests:
+		return c.APIRequests
+	case DimensionTransformations:
+		return c.Transformations
+	case DimensionStorage:
+		return c.StorageBytes
+	}
+	return 0
+}
@@ -0,0 +1,195 @@
+package usage
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var periodStart = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+func sample(uploads, apiRequests, storage int64) *components.UsageResponse {
+	return &components.UsageResponse{
+		StorageUsedBytes: storage,
+		PlanLimits: components.PlanLimits{
+			MaxUploadsPerMonth:     int64Ptr(1000),
+			MaxAPIRequestsPerMonth: int64Ptr(10000),
+			MaxStorageBytes:        int64Ptr(1 << 30),
+		},
+		CurrentPeriod: components.CurrentPeriod{
+			Period:      "2026-10",
+			PeriodStart: periodStart.Unix(),
+			PeriodEnd:   periodStart.Add(30 * 24 * time.Hour).Unix(),
+			Uploads:     uploads,
+			APIRequests: apiRequests,
+		},
+		Credits: components.Credits{
+			APIRequests: 500,
+		},
+	}
+}
+
+func int64Ptr(v int64) *int64 { return &v }
+
+func TestForecaster_NoSamples(t *testing.T) {
+	t.Parallel()
+	f := NewForecaster(MethodLinear)
+	_, err := f.Forecast()
+	assert.ErrorIs(t, err, ErrNoSamples)
+}
+
+func TestForecaster_Linear(t *testing.T) {
+	t.Parallel()
+	f := NewForecaster(MethodLinear)
+	day := 24 * time.Hour
+
+	// 20 uploads and 400 API requests per day over 10 days, 20 days remaining.
+	f.Add(periodStart.Add(day), sample(20, 400, 1<<20))
+	f.Add(periodStart.Add(10*day), sample(200, 4000, 1<<20))
+
+	fc, err := f.Forecast()
+	require.NoError(t, err)
+	assert.Equal(t, "2026-10", fc.Period)
+	assert.Equal(t, 20*day, fc.Remaining)
+	assert.Equal(t, 2, fc.Samples)
+
+	uploads, ok := fc.Get(DimensionUploads)
+	require.True(t, ok)
+	assert.Equal(t, int64(600), uploads.Projected)
+	assert.False(t, uploads.WillExceed())
+	require.NotNil(t, uploads.TimeToExhaustion)
+	assert.Equal(t, 40*day, uploads.TimeToExhaustion.Round(time.Minute))
+	assert.False(t, uploads.ExhaustsBeforePeriodEnd(fc.Remaining))
+
+	requests, ok := fc.Get(DimensionAPIRequests)
+	require.True(t, ok)
+	assert.Equal(t, int64(12000), requests.Projected)
+	assert.True(t, requests.WillExceed())
+	assert.Equal(t, int64(2000), requests.Overage)
+	assert.Equal(t, int64(500), requests.CreditsCovered)
+	assert.Equal(t, int64(1500), requests.Uncovered)
+	require.NotNil(t, requests.TimeToExhaustion)
+	assert.Equal(t, 15*day, requests.TimeToExhaustion.Round(time.Minute))
+	assert.True(t, requests.ExhaustsBeforePeriodEnd(fc.Remaining))
+
+	storage, ok := fc.Get(DimensionStorage)
+	require.True(t, ok)
+	assert.Equal(t, int64(1<<20), storage.Projected)
+	assert.Nil(t, storage.TimeToExhaustion)
+
+	bandwidth, ok := fc.Get(DimensionBandwidth)
+	require.True(t, ok)
+	assert.Nil(t, bandwidth.Limit)
+	assert.Zero(t, bandwidth.Overage)
+
+	exceeding := fc.Exceeding()
+	require.Len(t, exceeding, 1)
+	assert.Equal(t, DimensionAPIRequests, exceeding[0].Dimension)
+}
+
+func TestForecaster_SingleSampleUsesPeriodStart(t *testing.T) {
+	t.Parallel()
+	f := NewForecaster(MethodEWMA)
+	f.Add(periodStart.Add(10*24*time.Hour), sample(100, 0, 1<<20))
+
+	fc, err := f.Forecast()
+	require.NoError(t, err)
+
+	uploads, _ := fc.Get(DimensionUploads)
+	assert.Equal(t, int64(300), uploads.Projected)
+
+	storage, _ := fc.Get(DimensionStorage)
+	assert.Zero(t, storage.RatePerSecond)
+}
+
+func TestForecaster_EWMAWeightsRecentRates(t *testing.T) {
+	t.Parallel()
+	day := 24 * time.Hour
+
+	linear := NewForecaster(MethodLinear)
+	ewma := &Forecaster{Method: MethodEWMA, Alpha: 0.5}
+	points := []int64{0, 10, 20, 30, 130}
+	for i, v := range points {
+		at := periodStart.Add(time.Duration(i+1) * day)
+		linear.Add(at, sample(v, 0, 0))
+		ewma.Add(at, sample(v, 0, 0))
+	}
+
+	lf, err := linear.Forecast()
+	require.NoError(t, err)
+	ef, err := ewma.Forecast()
+	require.NoError(t, err)
+
+	lu, _ := lf.Get(DimensionUploads)
+	eu, _ := ef.Get(DimensionUploads)
+	assert.InDelta(t, 32.5/day.Seconds(), lu.RatePerSecond, 1e-9)
+	assert.InDelta(t, 55.0/day.Seconds(), eu.RatePerSecond, 1e-9)
+	assert.Greater(t, eu.Projected, lu.Projected)
+}
+
+func TestForecaster_AlreadyExhausted(t *testing.T) {
+	t.Parallel()
+	f := NewForecaster(MethodLinear)
+	f.Add(periodStart.Add(24*time.Hour), sample(1000, 0, 0))
+
+	fc, err := f.Forecast()
+	require.NoError(t, err)
+
+	uploads, _ := fc.Get(DimensionUploads)
+	require.NotNil(t, uploads.TimeToExhaustion)
+	assert.Zero(t, *uploads.TimeToExhaustion)
+	assert.True(t, uploads.WillExceed())
+}
+
+func TestForecaster_NewPeriodResetsSamples(t *testing.T) {
+	t.Parallel()
+	f := NewForecaster(MethodLinear)
+	f.Add(periodStart.Add(24*time.Hour), sample(10, 0, 0))
+
+	next := sample(1, 0, 0)
+	next.CurrentPeriod.Period = "2026-11"
+	f.Add(periodStart.Add(31*24*time.Hour), next)
+
+	samples := f.Samples()
+	require.Len(t, samples, 1)
+	assert.Equal(t, "2026-11", samples[0].Usage.CurrentPeriod.Period)
+}
+
+func TestForecaster_MaxSamples(t *testing.T) {
+	t.Parallel()
+	f := &Forecaster{MaxSamples: 3}
+	for i := 0; i < 10; i++ {
+		f.Add(periodStart.Add(time.Duration(i)*time.Hour), sample(int64(i), 0, 0))
+	}
+
+	samples := f.Samples()
+	require.Len(t, samples, 3)
+	assert.Equal(t, int64(7), samples[0].Usage.CurrentPeriod.Uploads)
+}
+
+func TestForecaster_UnsupportedMethod(t *testing.T) {
+	t.Parallel()
+	f := NewForecaster(Method("quadratic"))
+	f.Add(periodStart.Add(time.Hour), sample(1, 0, 0))
+	_, err := f.Forecast()
+	assert.Error(t, err)
+}
+
+func TestForecaster_ConcurrentAdd(t *testing.T) {
+	t.Parallel()
+	f := NewForecaster(MethodEWMA)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			f.Add(periodStart.Add(time.Duration(i)*time.Minute), sample(int64(i), 0, 0))
+		}(i)
+	}
+	wg.Wait()
+	assert.Len(t, f.Samples(), 20)
+}
@@ -0,0 +1,74 @@
+package sdkgo
+
+import (
+	"context"
+	"strings"
+
+	"github.com/img-src-io/sdk-go/models/components"
+	"github.com/img-src-io/sdk-go/models/operations"
+)
+
+// walkPageSize is the page size used when enumerating the library.
+const walkPageSize int64 = 100
+
+// Walk calls fn once for every image stored under folder, descending
+// into subfolders. An empty folder walks the whole library. Images reachable
+// through several paths are reported once.
+func (s *Images) Walk(ctx context.Context, folder string, fn func(components.ImageListItem) error, opts ...operations.Option) error {
+	seen := map[string]struct{}{}
+	pending := []string{strings.Trim(folder, "/")}
+
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+
+		var path *string
+		if current != "" {
+			path = String(current)
+		}
+
+		res, err := s.List(ctx, Int64(walkPageSize), nil, path, opts...)
+		for first := true; err == nil && res != nil; first = false {
+			page := res.GetImageListResponse()
+			if page == nil {
+				break
+			}
+
+			for _, img := range page.Images {
+				if _, ok := seen[img.ID]; ok {
+					continue
+				}
+				seen[img.ID] = struct{}{}
+
+				if err := fn(img); err != nil {
+					return err
+				}
+			}
+
+			// Folders are repeated on every page of a listing.
+			if first {
+				for _, f := range page.Folders {
+					pending = append(pending, joinPath(current, f.Name))
+				}
+			}
+
+			if !page.HasMore || res.Next == nil {
+				break
+			}
+			res, err = res.Next()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func joinPath(folder, name string) string {
+	name = strings.Trim(name, "/")
+	if folder == "" {
+		return name
+	}
+	return folder + "/" + name
+}